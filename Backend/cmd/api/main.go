@@ -3,16 +3,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Suthar345Piyush/invoicego/internal/config"
 	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/fx"
 	"github.com/Suthar345Piyush/invoicego/internal/handler"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
 	"github.com/Suthar345Piyush/invoicego/internal/middleware"
 	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/storage"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
@@ -52,20 +62,119 @@ func main() {
 
 	defer db.Close()
 
+	// outgoing mail , smtp / file / log depending on MAIL_DRIVER
+
+	mail, err := mailer.New(&cfg.Mail)
+
+	if err != nil {
+		log.Fatal("Failed to set up mailer:", err)
+	}
+
+	// file storage for uploads like business logos
+
+	store, err := storage.New(&cfg.Storage)
+
+	if err != nil {
+		log.Fatal("Failed to set up storage:", err)
+	}
+
+	// pdf rendering , loads the fonts from PDF_FONT_DIR
+
+	pdfService, err := service.NewPDFService(db, store, &cfg.PDF)
+
+	if err != nil {
+		log.Fatal("Failed to load pdf fonts:", err)
+	}
+
+	// exchange rates , the user's own rates first and then the rates file when FX_RATES_FILE is set
+
+	exchangeRateService := service.NewExchangeRateService(db)
+	rates := fx.Chain{exchangeRateService}
+
+	if cfg.FX.RatesFile != "" {
+		fileRates, err := fx.NewFileProvider(cfg.FX.RatesFile)
+
+		if err != nil {
+			log.Fatal("Failed to load exchange rates file:", err)
+		}
+
+		rates = append(rates, fileRates)
+	}
+
 	// initializing the auth , user and client service
 
 	userService := service.NewUserService(db)
-	authService := service.NewAuthService(userService, &cfg.JWT)
+	sessionService := service.NewSessionService(db, &cfg.JWT)
+	verificationService := service.NewEmailVerificationService(db, userService, mail, &cfg.JWT, cfg.Public.BaseURL)
+	twoFactorService := service.NewTwoFactorService(db, userService, util.SystemClock{}, &cfg.TwoFactor, cfg.JWT.Secret)
+	authService := service.NewAuthService(db, userService, sessionService, verificationService, twoFactorService, &cfg.JWT)
+	passwordService := service.NewPasswordService(db, userService, sessionService, mail, util.SystemClock{}, &cfg.JWT, cfg.Public.AppURL)
 	clientService := service.NewClientService(db)
-	invoiceService := service.NewInvoiceService(db, userService)
-	pdfService := service.NewPDFService()
+	invoiceService := service.NewInvoiceService(db, userService, rates)
+	paymentService := service.NewPaymentService(db, rates)
+	creditNoteService := service.NewCreditNoteService(db)
+	overdueService := service.NewOverdueService(db, util.SystemClock{})
+	reminderService := service.NewReminderService(db, mail, util.SystemClock{})
+	trackingService := service.NewTrackingService(db, cfg.Public.BaseURL, cfg.Public.SigningSecret)
+	shareLinkService := service.NewShareLinkService(db, invoiceService, userService, pdfService, cfg.Public.BaseURL, cfg.Public.SigningSecret)
+	invoiceEmailService := service.NewInvoiceEmailService(db, invoiceService, userService, pdfService, trackingService, mail)
+	recurringService := service.NewRecurringService(db, invoiceService, invoiceEmailService)
+	brandingService := service.NewBrandingService(db, store, userService)
+	estimateService := service.NewEstimateService(db, invoiceService, userService, pdfService, util.SystemClock{}, cfg.Public.BaseURL, cfg.Public.SigningSecret)
 
 	// initializing the auth and user handlers
 
 	authHandler := handler.NewAuthHandler(authService)
 	userHandler := handler.NewUserHandler(userService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	verificationHandler := handler.NewEmailVerificationHandler(verificationService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
 	clientHandler := handler.NewClientHandler(clientService)
 	invoiceHandler := handler.NewInvoiceHandler(invoiceService, pdfService, userService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	creditNoteHandler := handler.NewCreditNoteHandler(creditNoteService, invoiceService, pdfService, userService)
+	recurringHandler := handler.NewRecurringHandler(recurringService)
+	overdueHandler := handler.NewOverdueHandler(overdueService)
+	reminderHandler := handler.NewReminderHandler(reminderService)
+	invoiceEmailHandler := handler.NewInvoiceEmailHandler(invoiceEmailService)
+	trackingHandler := handler.NewTrackingHandler(trackingService)
+	publicInvoiceHandler := handler.NewPublicInvoiceHandler(shareLinkService)
+	brandingHandler := handler.NewBrandingHandler(brandingService)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateService)
+	estimateHandler := handler.NewEstimateHandler(estimateService)
+	publicEstimateHandler := handler.NewPublicEstimateHandler(estimateService)
+
+	// background jobs , stopped when the process gets an interrupt
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// jobs are tracked so shutdown can wait for a run in progress to finish
+
+	var jobs sync.WaitGroup
+
+	runJob := func(run func(context.Context, time.Duration), interval time.Duration) {
+		jobs.Add(1)
+
+		go func() {
+			defer jobs.Done()
+			run(ctx, interval)
+		}()
+	}
+
+	runJob(recurringService.Run, cfg.Jobs.RecurringInterval)
+	runJob(overdueService.Run, cfg.Jobs.OverdueInterval)
+	runJob(reminderService.Run, cfg.Jobs.ReminderInterval)
+	runJob(estimateService.Run, cfg.Jobs.EstimateExpiryInterval)
+
+	// revoked sessions are loaded before serving , then kept in sync
+
+	if err := sessionService.SyncRevoked(); err != nil {
+		log.Fatal("Failed to load revoked sessions:", err)
+	}
+
+	runJob(sessionService.Run, cfg.Jobs.SessionSyncInterval)
 
 	// setting router using chi framework
 	//NewRouter returns a mux object which implements router interface
@@ -87,7 +196,7 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
-	// api routes for auth - login and register
+	// api routes for auth - login , register and refresh
 
 	r.Route("/api/v1", func(r chi.Router) {
 
@@ -97,16 +206,73 @@ func main() {
 			r.Use(middleware.RateLimit(10, time.Minute)) // 10 req/minute
 			r.Post("/auth/register", authHandler.Register)
 			r.Post("/auth/login", authHandler.Login)
+			r.Post("/auth/login/2fa", authHandler.LoginTwoFactor)
+			r.Post("/auth/refresh", authHandler.Refresh)
+			r.Get("/auth/verify-email", verificationHandler.VerifyEmailLink)
+			r.Post("/auth/verify-email", verificationHandler.VerifyEmail)
+			r.Post("/auth/reset-password", passwordHandler.ResetPassword)
+
+			// forgot password emails a link , limited on its own like the verification resend
+
+			r.With(middleware.RateLimit(5, time.Hour)).Post("/auth/forgot-password", passwordHandler.ForgotPassword)
+		})
+
+		// public tracking pixel , loaded by the client's email app
+
+		r.Get("/track/open/{token}", trackingHandler.TrackOpen)
+
+		// client facing invoice pages behind a share link , rate limited like the auth routes
+
+		r.Route("/public/invoices/{token}", func(r chi.Router) {
+			r.Use(middleware.RateLimit(60, time.Minute))
+			r.Get("/", publicInvoiceHandler.ViewInvoice)
+			r.Get("/pdf", publicInvoiceHandler.DownloadPDF)
+			r.Post("/acknowledge", publicInvoiceHandler.Acknowledge)
+			r.Post("/dispute", publicInvoiceHandler.Dispute)
+		})
+
+		// client facing estimate pages , the link is in the estimate once it's sent
+
+		r.Route("/public/estimates/{token}", func(r chi.Router) {
+			r.Use(middleware.RateLimit(60, time.Minute))
+			r.Get("/", publicEstimateHandler.ViewEstimate)
+			r.Get("/pdf", publicEstimateHandler.DownloadPDF)
+			r.Post("/accept", publicEstimateHandler.Accept)
+			r.Post("/decline", publicEstimateHandler.Decline)
 		})
 
 		// protected routes
 
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.Auth(cfg.JWT.Secret))
+			r.Use(middleware.Auth(cfg.JWT.Secret, sessionService))
+
+			// logout and sessions
+
+			r.Post("/auth/logout", sessionHandler.Logout)
+
+			// resending the verification email , limited on its own so it can't be used to flood an inbox
+
+			r.With(middleware.RateLimit(3, time.Hour)).Post("/auth/resend-verification", verificationHandler.ResendVerification)
+			r.Get("/users/me/sessions", sessionHandler.ListSessions)
+			r.Delete("/users/me/sessions", sessionHandler.RevokeAllSessions)
+			r.Delete("/users/me/sessions/{id}", sessionHandler.RevokeSession)
 
 			// user routes
 
 			r.Get("/users/me", userHandler.GetMe)
+			r.Patch("/users/me", userHandler.UpdateMe)
+			r.Put("/users/me/password", passwordHandler.ChangePassword)
+
+			// two-factor authentication
+
+			r.Post("/users/me/2fa/setup", twoFactorHandler.Setup)
+			r.Post("/users/me/2fa/confirm", twoFactorHandler.Confirm)
+			r.Post("/users/me/2fa/disable", twoFactorHandler.Disable)
+			r.Post("/users/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+			r.Post("/users/me/logo", brandingHandler.UploadLogo)
+			r.Get("/users/me/logo", brandingHandler.GetLogo)
+			r.Delete("/users/me/logo", brandingHandler.DeleteLogo)
+			r.Put("/users/me/branding", brandingHandler.UpdateBranding)
 
 			// client routes
 
@@ -116,6 +282,7 @@ func main() {
 				r.Get("/{id}", clientHandler.GetClient)
 				r.Put("/{id}", clientHandler.UpdateClient)
 				r.Delete("/{id}", clientHandler.DeleteClient)
+				r.Patch("/{id}/reminders", reminderHandler.PauseClientReminders)
 			})
 
 			// invoice routes
@@ -124,11 +291,79 @@ func main() {
 				r.Get("/", invoiceHandler.ListInvoices)
 				r.Post("/", invoiceHandler.CreateInvoice)
 				r.Get("/stats", invoiceHandler.GetStats)
+				r.Post("/sweep-overdue", overdueHandler.SweepOverdue)
 				r.Get("/{id}", invoiceHandler.GetInvoice)
+				r.Put("/{id}", invoiceHandler.UpdateInvoice)
 				r.Patch("/{id}/status", invoiceHandler.UpdateInvoiceStatus)
+				r.Post("/{id}/send", invoiceEmailHandler.SendInvoice)
+				r.Get("/{id}/history", invoiceHandler.GetInvoiceHistory)
+				r.Get("/{id}/timeline", invoiceHandler.GetInvoiceTimeline)
+				r.Get("/{id}/reminders", reminderHandler.ListInvoiceReminders)
+				r.Patch("/{id}/reminders", reminderHandler.PauseInvoiceReminders)
+				r.Post("/{id}/payments", paymentHandler.RecordPayment)
+				r.Get("/{id}/payments", paymentHandler.ListPayments)
+				r.Delete("/{id}/payments/{paymentID}", paymentHandler.DeletePayment)
+				r.Post("/{id}/credit-notes", creditNoteHandler.CreateCreditNote)
+				r.Get("/{id}/credit-notes", creditNoteHandler.ListInvoiceCreditNotes)
+				r.Post("/{id}/share-links", publicInvoiceHandler.CreateShareLink)
+				r.Get("/{id}/share-links", publicInvoiceHandler.ListShareLinks)
+				r.Delete("/{id}/share-links/{linkID}", publicInvoiceHandler.RevokeShareLink)
 				r.Delete("/{id}", invoiceHandler.DeleteInvoice)
 				r.Post("/{id}/duplicate", invoiceHandler.DuplicateInvoice)
 				r.Get("/{id}/download", invoiceHandler.GeneratePDF)
+				r.Get("/{id}/archives", invoiceHandler.ListPDFArchives)
+				r.Get("/{id}/archives/{archiveID}", invoiceHandler.DownloadPDFArchive)
+			})
+
+			// credit notes , issued under /invoices/{id}/credit-notes
+
+			r.Route("/credit-notes", func(r chi.Router) {
+				r.Get("/", creditNoteHandler.ListCreditNotes)
+				r.Get("/{id}", creditNoteHandler.GetCreditNote)
+				r.Get("/{id}/download", creditNoteHandler.DownloadCreditNote)
+			})
+
+			// estimates , converted into invoices once accepted
+
+			r.Route("/estimates", func(r chi.Router) {
+				r.Get("/", estimateHandler.ListEstimates)
+				r.Post("/", estimateHandler.CreateEstimate)
+				r.Get("/{id}", estimateHandler.GetEstimate)
+				r.Put("/{id}", estimateHandler.UpdateEstimate)
+				r.Patch("/{id}/status", estimateHandler.UpdateEstimateStatus)
+				r.Post("/{id}/convert", estimateHandler.ConvertEstimate)
+				r.Get("/{id}/download", estimateHandler.DownloadEstimate)
+				r.Delete("/{id}", estimateHandler.DeleteEstimate)
+			})
+
+			// recurring invoice schedules
+
+			r.Route("/recurring-schedules", func(r chi.Router) {
+				r.Get("/", recurringHandler.ListSchedules)
+				r.Post("/", recurringHandler.CreateSchedule)
+				r.Get("/{id}", recurringHandler.GetSchedule)
+				r.Put("/{id}", recurringHandler.UpdateSchedule)
+				r.Delete("/{id}", recurringHandler.DeleteSchedule)
+			})
+
+			// payment reminder templates and sequence
+
+			r.Route("/reminder-templates", func(r chi.Router) {
+				r.Get("/", reminderHandler.ListTemplates)
+				r.Post("/", reminderHandler.CreateTemplate)
+				r.Put("/{id}", reminderHandler.UpdateTemplate)
+				r.Delete("/{id}", reminderHandler.DeleteTemplate)
+			})
+
+			r.Get("/reminder-rules", reminderHandler.GetRules)
+			r.Put("/reminder-rules", reminderHandler.UpdateRules)
+
+			// exchange rates the user entered
+
+			r.Route("/exchange-rates", func(r chi.Router) {
+				r.Get("/", exchangeRateHandler.ListRates)
+				r.Put("/", exchangeRateHandler.SetRate)
+				r.Delete("/{id}", exchangeRateHandler.DeleteRate)
 			})
 
 		})
@@ -141,7 +376,40 @@ func main() {
 	fmt.Printf("Server starting on http://localhost%s\n", addr)
 	fmt.Printf("Environment: %s\n", cfg.Server.Env)
 
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatal("Server failed to start:", err)
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: r,
 	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// serving until an interrupt , or until the server fails on its own
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed to start:", err)
+		}
+	case <-ctx.Done():
+	}
+
+	// graceful shutdown , requests in flight get some time to finish and then the jobs are waited for
+
+	fmt.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+
+	stop()
+	jobs.Wait()
+
+	fmt.Println("Server stopped")
 }
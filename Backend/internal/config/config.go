@@ -16,10 +16,17 @@ import (
 
 // parent struct for all the config
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	TwoFactor TwoFactorConfig
+	CORS      CORSConfig
+	Jobs      JobsConfig
+	Mail      MailConfig
+	Public    PublicConfig
+	Storage   StorageConfig
+	PDF       PDFConfig
+	FX        FXConfig
 }
 
 type ServerConfig struct {
@@ -36,16 +43,102 @@ type DatabaseConfig struct {
 	SSLMode  string
 }
 
+// VerifyExpiry is how long an email verification link works , ResetExpiry the same for a password reset link
+
 type JWTConfig struct {
 	Secret        string
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
+	VerifyExpiry  time.Duration
+	ResetExpiry   time.Duration
+}
+
+// two-factor login , Issuer is the name shown in the authenticator app
+// EncryptionKey encrypts the totp secrets in the database , ChallengeExpiry is how long the second login step can take
+
+type TwoFactorConfig struct {
+	Issuer          string
+	EncryptionKey   string
+	ChallengeExpiry time.Duration
 }
 
 type CORSConfig struct {
 	AllowedOrigins []string
 }
 
+// how often the background jobs run
+
+type JobsConfig struct {
+	RecurringInterval time.Duration
+	OverdueInterval   time.Duration
+	ReminderInterval  time.Duration
+
+	// how often sent estimates past their expiry date are marked expired
+
+	EstimateExpiryInterval time.Duration
+
+	// how often the revoked sessions are reloaded from the database , for logouts on other instances
+
+	SessionSyncInterval time.Duration
+}
+
+// outgoing mail , driver is smtp , file or log
+
+type MailConfig struct {
+	Driver   string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Dir      string
+}
+
+// public (unauthenticated) urls put into emails and share links
+// BaseURL is where the api is reachable from outside , SigningSecret signs the ids in those urls
+// AppURL is the frontend , for links that open a page of the app like the password reset
+
+type PublicConfig struct {
+	BaseURL       string
+	AppURL        string
+	SigningSecret string
+}
+
+// uploaded and generated files , driver is local (Dir on disk) or s3 (any s3 compatible service)
+// S3Endpoint is empty for aws , or the address of a compatible service like minio
+// S3PathStyle puts the bucket in the path (endpoint/bucket/key) instead of the host name , most compatible services need it
+
+type StorageConfig struct {
+	Driver      string
+	Dir         string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// truetype fonts embedded in generated pdfs
+// each font is a file name prefix in FontDir , like DejaVuSansCondensed for DejaVuSansCondensed.ttf and DejaVuSansCondensed-Bold.ttf
+// serif and mono are optional , the sans font is used for them when they are empty
+// fallback fonts draw the characters the main fonts have no glyphs for (devanagari , cjk ...) , run by run
+// they are not shipped with the repo , `make fonts` downloads the default ones and a missing one is skipped
+
+type PDFConfig struct {
+	FontDir       string
+	SansFont      string
+	SerifFont     string
+	MonoFont      string
+	FallbackFonts []string
+}
+
+// exchange rates , RatesFile is an optional csv of "date,from,to,rate" rows used when a user has no rate of their own
+
+type FXConfig struct {
+	RatesFile string
+}
+
 // load function for loading .env file
 
 func Load() (*Config, error) {
@@ -70,6 +163,60 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRY: %w", err)
 	}
 
+	verifyExpiry, err := time.ParseDuration(getEnv("EMAIL_VERIFICATION_EXPIRY", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_EXPIRY: %w", err)
+	}
+
+	resetExpiry, err := time.ParseDuration(getEnv("PASSWORD_RESET_EXPIRY", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASSWORD_RESET_EXPIRY: %w", err)
+	}
+
+	challengeExpiry, err := time.ParseDuration(getEnv("TWO_FACTOR_CHALLENGE_EXPIRY", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TWO_FACTOR_CHALLENGE_EXPIRY: %w", err)
+	}
+
+	// secrets that only have a default for development , anywhere else they have to be set
+
+	env := getEnv("ENV", "development")
+
+	if err := requireOutsideDevelopment(env, "TOTP_ENCRYPTION_KEY"); err != nil {
+		return nil, err
+	}
+
+	if err := requireOutsideDevelopment(env, "LINK_SIGNING_SECRET"); err != nil {
+		return nil, err
+	}
+
+	// background jobs intervals
+
+	recurringInterval, err := time.ParseDuration(getEnv("RECURRING_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RECURRING_INTERVAL: %w", err)
+	}
+
+	overdueInterval, err := time.ParseDuration(getEnv("OVERDUE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OVERDUE_INTERVAL: %w", err)
+	}
+
+	reminderInterval, err := time.ParseDuration(getEnv("REMINDER_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REMINDER_INTERVAL: %w", err)
+	}
+
+	estimateExpiryInterval, err := time.ParseDuration(getEnv("ESTIMATE_EXPIRY_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ESTIMATE_EXPIRY_INTERVAL: %w", err)
+	}
+
+	sessionSyncInterval, err := time.ParseDuration(getEnv("SESSION_SYNC_INTERVAL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SESSION_SYNC_INTERVAL: %w", err)
+	}
+
 	// returning the overall config
 
 	return &Config{
@@ -78,7 +225,7 @@ func Load() (*Config, error) {
 
 			Server: ServerConfig{
 				Port: getEnv("PORT", "8080"),
-				Env:  getEnv("ENV", "development"),
+				Env:  env,
 			},
 
 			// db config
@@ -96,6 +243,14 @@ func Load() (*Config, error) {
 				Secret:        getEnv("JWT_SECRET", "secret-key-production"),
 				AccessExpiry:  accessExpiry,
 				RefreshExpiry: refreshExpiry,
+				VerifyExpiry:  verifyExpiry,
+				ResetExpiry:   resetExpiry,
+			},
+
+			TwoFactor: TwoFactorConfig{
+				Issuer:          getEnv("TOTP_ISSUER", "InvoiceGo"),
+				EncryptionKey:   getEnv("TOTP_ENCRYPTION_KEY", "totp-key-production"),
+				ChallengeExpiry: challengeExpiry,
 			},
 
 			CORS: CORSConfig{
@@ -103,6 +258,59 @@ func Load() (*Config, error) {
 					getEnv("ALLOWED_ORIGINS", "http://localhost:3000"),
 				},
 			},
+
+			Jobs: JobsConfig{
+				RecurringInterval: recurringInterval,
+				OverdueInterval:   overdueInterval,
+				ReminderInterval:  reminderInterval,
+
+				EstimateExpiryInterval: estimateExpiryInterval,
+
+				SessionSyncInterval: sessionSyncInterval,
+			},
+
+			// mail config , defaults match the MailHog container in docker-compose.yml
+
+			Mail: MailConfig{
+				Driver:   getEnv("MAIL_DRIVER", "log"),
+				Host:     getEnv("SMTP_HOST", "localhost"),
+				Port:     getEnv("SMTP_PORT", "1025"),
+				Username: getEnv("SMTP_USERNAME", ""),
+				Password: getEnv("SMTP_PASSWORD", ""),
+				From:     getEnv("MAIL_FROM", "InvoiceGo <no-reply@invoicego.local>"),
+				Dir:      getEnv("MAIL_DIR", "tmp/mail"),
+			},
+
+			Public: PublicConfig{
+				BaseURL:       strings.TrimRight(getEnv("PUBLIC_BASE_URL", "http://localhost:8080"), "/"),
+				AppURL:        strings.TrimRight(getEnv("APP_URL", "http://localhost:3000"), "/"),
+				SigningSecret: getEnv("LINK_SIGNING_SECRET", "link-secret-production"),
+			},
+
+			Storage: StorageConfig{
+				Driver: getEnv("STORAGE_DRIVER", "local"),
+				Dir:    getEnv("STORAGE_DIR", "storage"),
+
+				S3Endpoint:  strings.TrimRight(getEnv("S3_ENDPOINT", ""), "/"),
+				S3Region:    getEnv("S3_REGION", "us-east-1"),
+				S3Bucket:    getEnv("S3_BUCKET", ""),
+				S3AccessKey: getEnv("S3_ACCESS_KEY_ID", ""),
+				S3SecretKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+				S3PathStyle: getEnv("S3_FORCE_PATH_STYLE", "false") == "true",
+			},
+
+			PDF: PDFConfig{
+				FontDir:   getEnv("PDF_FONT_DIR", "fonts"),
+				SansFont:  getEnv("PDF_FONT_SANS", "DejaVuSansCondensed"),
+				SerifFont: getEnv("PDF_FONT_SERIF", ""),
+				MonoFont:  getEnv("PDF_FONT_MONO", ""),
+
+				FallbackFonts: splitList(getEnv("PDF_FONT_FALLBACKS", "NotoSansDevanagari,NotoSansSC")),
+			},
+
+			FX: FXConfig{
+				RatesFile: getEnv("FX_RATES_FILE", ""),
+			},
 		},
 		nil
 }
@@ -131,6 +339,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// a secret whose default is only good for development , ENV other than development needs it set
+
+func requireOutsideDevelopment(env, key string) error {
+
+	if env != "development" && os.Getenv(key) == "" {
+		return fmt.Errorf("%s must be set when ENV is %s", key, env)
+	}
+
+	return nil
+}
+
 // getting the env as integer
 
 func getEnvAsInt(key string, defaultValue int) int {
@@ -144,3 +363,18 @@ func getEnvAsInt(key string, defaultValue int) int {
 
 	return defaultValue
 }
+
+// comma separated list , empty entries dropped
+
+func splitList(value string) []string {
+
+	list := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,69 @@
+// credit notes - corrections of sent or paid invoices , which can't be edited or deleted any more
+
+package domain
+
+import (
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// credit note against one invoice , amounts are what's credited and come off the invoice's balance
+// it's in the invoice's currency and converted to the base currency at the invoice's rate
+
+type CreditNote struct {
+	ID               uuid.UUID         `json:"id"`
+	UserID           uuid.UUID         `json:"user_id"`
+	InvoiceID        uuid.UUID         `json:"invoice_id"`
+	InvoiceNumber    string            `json:"invoice_number"`
+	ClientID         uuid.UUID         `json:"client_id"`
+	CreditNoteNumber string            `json:"credit_note_number"`
+	IssueDate        time.Time         `json:"issue_date"`
+	Reason           string            `json:"reason"`
+	Currency         string            `json:"currency"`
+	BaseCurrency     string            `json:"base_currency"`
+	ExchangeRate     money.Rate        `json:"exchange_rate"`
+	Subtotal         money.Decimal     `json:"subtotal"`
+	TaxAmount        money.Decimal     `json:"tax_amount"`
+	CGSTAmount       money.Decimal     `json:"cgst_amount"`
+	SGSTAmount       money.Decimal     `json:"sgst_amount"`
+	IGSTAmount       money.Decimal     `json:"igst_amount"`
+	DiscountAmount   money.Decimal     `json:"discount_amount"`
+	TotalAmount      money.Decimal     `json:"total_amount"`
+	CreatedAt        time.Time         `json:"created_at"`
+	Items            []*CreditNoteItem `json:"items,omitempty"`
+}
+
+// one reversed invoice line , amount and tax are the credited part of the line's (discounted) amount and tax
+
+type CreditNoteItem struct {
+	ID            uuid.UUID     `json:"id"`
+	CreditNoteID  uuid.UUID     `json:"credit_note_id"`
+	InvoiceItemID uuid.UUID     `json:"invoice_item_id"`
+	Description   string        `json:"description"`
+	HSNSAC        *string       `json:"hsn_sac,omitempty"`
+	Quantity      money.Decimal `json:"quantity"`
+	UnitPrice     money.Decimal `json:"unit_price"`
+	Amount        money.Decimal `json:"amount"`
+	TaxRate       money.Decimal `json:"tax_rate"`
+	TaxAmount     money.Decimal `json:"tax_amount"`
+	SortOrder     int           `json:"sort_order"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// issue_date defaults to today
+// without items every line of the invoice is credited for whatever earlier credit notes left of it
+
+type CreateCreditNoteRequest struct {
+	IssueDate *string              `json:"issue_date,omitempty"`
+	Reason    string               `json:"reason" validate:"required,max=500"`
+	Items     []*CreditNoteItemReq `json:"items,omitempty" validate:"omitempty,min=1,dive"`
+}
+
+// quantity of an invoice line to reverse , the line's remaining quantity when it's not given
+
+type CreditNoteItemReq struct {
+	InvoiceItemID uuid.UUID      `json:"invoice_item_id" validate:"required"`
+	Quantity      *money.Decimal `json:"quantity,omitempty" validate:"omitempty,gt=0"`
+}
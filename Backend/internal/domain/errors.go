@@ -2,7 +2,10 @@
 
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUserNotFound         = errors.New("user not found")
@@ -15,4 +18,57 @@ var (
 	ErrInternalServer       = errors.New("internal server error")
 	ErrEmailNotVerified     = errors.New("email not verified")
 	ErrInvoiceLimitExceeded = errors.New("monthly invoice limit exceeded")
+	ErrInvoiceNotFound      = errors.New("invoice not found")
+	ErrInvoiceNotEditable   = errors.New("only draft invoices can be edited")
+	ErrClientNotFound       = errors.New("client not found")
+
+	ErrInvalidStatusTransition  = errors.New("invalid invoice status transition")
+	ErrStatusSetByPayments      = errors.New("paid and partially_paid are set by recording payments")
+	ErrInvoiceNotPayable        = errors.New("invoice cannot accept payments in its current status")
+	ErrPaymentExceedsBalance    = errors.New("payment amount exceeds the balance due")
+	ErrPaymentNotFound          = errors.New("payment not found")
+	ErrScheduleNotFound         = errors.New("recurring schedule not found")
+	ErrReminderTemplateNotFound = errors.New("reminder template not found")
+	ErrClientEmailMissing       = errors.New("client has no email address")
+	ErrInvoiceNotSendable       = errors.New("canceled invoices cannot be sent")
+	ErrEmailDeliveryFailed      = errors.New("email could not be delivered")
+	ErrInvoiceNotShareable      = errors.New("draft and canceled invoices cannot be shared")
+	ErrShareLinkNotFound        = errors.New("share link not found")
+	ErrShareLinkExpired         = errors.New("share link has expired or was revoked")
+	ErrLogoNotFound             = errors.New("logo not found")
+	ErrLogoTooLarge             = errors.New("logo file is too large")
+	ErrPDFArchiveNotFound       = errors.New("archived pdf not found")
+	ErrExchangeRateNotFound     = errors.New("exchange rate not found")
+	ErrCreditNoteNotFound       = errors.New("credit note not found")
+	ErrInvoiceNotCreditable     = errors.New("invoice cannot be credited in its current status")
+	ErrEstimateNotFound         = errors.New("estimate not found")
+	ErrEstimateNotEditable      = errors.New("only draft estimates can be edited")
+	ErrEstimateNotConvertible   = errors.New("only accepted estimates can be converted into an invoice")
+	ErrEstimateConverted        = errors.New("estimate was already converted into an invoice")
+	ErrEstimateClosed           = errors.New("estimate is no longer open for a response")
+	ErrEstimateExpired          = errors.New("estimate has expired")
+	ErrRefreshTokenReused       = errors.New("refresh token was already used, please log in again")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrIncorrectPassword        = errors.New("current password is incorrect")
+	ErrTwoFactorAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp        = errors.New("two-factor setup was not started")
+	ErrInvalidTwoFactorCode     = errors.New("invalid two-factor code")
 )
+
+// error for a status change that the transition table doesn't allow
+// errors.Is(err , ErrInvalidStatusTransition) matches it
+
+type InvalidStatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change invoice status from %s to %s", e.From, e.To)
+}
+
+func (e *InvalidStatusTransitionError) Is(target error) bool {
+	return target == ErrInvalidStatusTransition
+}
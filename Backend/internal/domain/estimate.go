@@ -0,0 +1,154 @@
+// estimates - quotes sent to a client before the work starts , turned into an invoice once accepted
+
+package domain
+
+import (
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// estimate with the same lines , taxes and templates as an invoice
+// PublicURL is the client's link to view , accept or decline it , there once the estimate is sent
+// InvoiceID is the invoice the estimate was converted into
+
+type Estimate struct {
+	ID                 uuid.UUID      `json:"id"`
+	UserID             uuid.UUID      `json:"user_id"`
+	ClientID           uuid.UUID      `json:"client_id"`
+	EstimateNumber     string         `json:"estimate_number"`
+	Status             string         `json:"status"`
+	IssueDate          time.Time      `json:"issue_date"`
+	ExpiryDate         time.Time      `json:"expiry_date"`
+	Currency           string         `json:"currency"`
+	Subtotal           money.Decimal  `json:"subtotal"`
+	TaxRate            money.Decimal  `json:"tax_rate"`
+	TaxAmount          money.Decimal  `json:"tax_amount"`
+	TaxScheme          string         `json:"tax_scheme"`
+	RoundingMode       string         `json:"rounding_mode"`
+	TaxRounding        string         `json:"tax_rounding"`
+	PlaceOfSupply      *string        `json:"place_of_supply,omitempty"`
+	SellerGSTIN        *string        `json:"seller_gstin,omitempty"`
+	BuyerGSTIN         *string        `json:"buyer_gstin,omitempty"`
+	CGSTAmount         money.Decimal  `json:"cgst_amount"`
+	SGSTAmount         money.Decimal  `json:"sgst_amount"`
+	IGSTAmount         money.Decimal  `json:"igst_amount"`
+	DiscountAmount     money.Decimal  `json:"discount_amount"`
+	TotalAmount        money.Decimal  `json:"total_amount"`
+	TemplateID         string         `json:"template_id"`
+	Notes              *string        `json:"notes,omitempty"`
+	TermsAndConditions *string        `json:"terms_and_conditions,omitempty"`
+	PublicURL          *string        `json:"public_url,omitempty"`
+	SentAt             *time.Time     `json:"sent_at,omitempty"`
+	RespondedAt        *time.Time     `json:"responded_at,omitempty"`
+	ClientNote         *string        `json:"client_note,omitempty"`
+	InvoiceID          *uuid.UUID     `json:"invoice_id,omitempty"`
+	ConvertedAt        *time.Time     `json:"converted_at,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	Items              []*InvoiceItem `json:"items,omitempty"`
+	Client             *Client        `json:"client,omitempty"`
+
+	TaxSummary []*TaxRateSummary `json:"tax_summary,omitempty"`
+}
+
+// lines are given the same way as invoice lines
+
+type CreateEstimateRequest struct {
+	ClientID           uuid.UUID               `json:"client_id" validate:"required"`
+	IssueDate          string                  `json:"issue_date" validate:"required"`
+	ExpiryDate         string                  `json:"expiry_date" validate:"required"`
+	Currency           string                  `json:"currency" validate:"required,len=3"`
+	TaxRate            money.Decimal           `json:"tax_rate" validate:"gte=0,lte=100"`
+	TaxScheme          string                  `json:"tax_scheme" validate:"omitempty,oneof=flat gst"`
+	RoundingMode       string                  `json:"rounding_mode" validate:"omitempty,oneof=half_up half_even"`
+	TaxRounding        string                  `json:"tax_rounding" validate:"omitempty,oneof=line invoice"`
+	PlaceOfSupply      *string                 `json:"place_of_supply,omitempty"`
+	DiscountAmount     money.Decimal           `json:"discount_amount" validate:"gte=0"`
+	TemplateID         string                  `json:"template_id" validate:"omitempty,oneof=default modern minimal professional"`
+	Notes              *string                 `json:"notes,omitempty"`
+	TermsAndConditions *string                 `json:"terms_and_conditions,omitempty"`
+	Items              []*CreateInvoiceItemReq `json:"items" validate:"required,min=1,dive"`
+}
+
+type UpdateEstimateRequest struct {
+	ClientID           *uuid.UUID              `json:"client_id,omitempty"`
+	IssueDate          *string                 `json:"issue_date,omitempty"`
+	ExpiryDate         *string                 `json:"expiry_date,omitempty"`
+	Currency           *string                 `json:"currency,omitempty" validate:"omitempty,len=3"`
+	TaxRate            *money.Decimal          `json:"tax_rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	TaxScheme          *string                 `json:"tax_scheme,omitempty" validate:"omitempty,oneof=flat gst"`
+	RoundingMode       *string                 `json:"rounding_mode,omitempty" validate:"omitempty,oneof=half_up half_even"`
+	TaxRounding        *string                 `json:"tax_rounding,omitempty" validate:"omitempty,oneof=line invoice"`
+	PlaceOfSupply      *string                 `json:"place_of_supply,omitempty"`
+	DiscountAmount     *money.Decimal          `json:"discount_amount,omitempty" validate:"omitempty,gte=0"`
+	TemplateID         *string                 `json:"template_id,omitempty" validate:"omitempty,oneof=default modern minimal professional"`
+	Notes              *string                 `json:"notes,omitempty"`
+	TermsAndConditions *string                 `json:"terms_and_conditions,omitempty"`
+	Items              []*CreateInvoiceItemReq `json:"items,omitempty" validate:"omitempty,min=1,dive"`
+}
+
+// accepted / declined here is the owner recording the client's answer given outside the link
+
+type UpdateEstimateStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=sent accepted declined expired"`
+}
+
+// the invoice an estimate is converted into , issued today and due in 30 days when the dates aren't given
+
+type ConvertEstimateRequest struct {
+	IssueDate *string `json:"issue_date,omitempty"`
+	DueDate   *string `json:"due_date,omitempty"`
+}
+
+// what the client sees through the estimate link
+
+type PublicEstimate struct {
+	Estimate *Estimate `json:"estimate"`
+	Business *User     `json:"-"`
+}
+
+// estimate statuses
+
+const (
+	EstimateStatusDraft    = "draft"
+	EstimateStatusSent     = "sent"
+	EstimateStatusAccepted = "accepted"
+	EstimateStatusDeclined = "declined"
+	EstimateStatusExpired  = "expired"
+)
+
+// allowed estimate status changes , accepted , declined and expired are final
+// sent ones are expired by the sweeper once the expiry date passes
+
+var estimateStatusTransitions = map[string][]string{
+	EstimateStatusDraft: {EstimateStatusSent},
+	EstimateStatusSent:  {EstimateStatusAccepted, EstimateStatusDeclined, EstimateStatusExpired},
+}
+
+// checking an estimate status change , errors.Is(err , ErrInvalidStatusTransition) matches the error
+
+func ValidateEstimateTransition(from, to string) error {
+
+	for _, allowed := range estimateStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+
+	return &InvalidEstimateTransitionError{From: from, To: to}
+}
+
+type InvalidEstimateTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidEstimateTransitionError) Error() string {
+	return "cannot change estimate status from " + e.From + " to " + e.To
+}
+
+func (e *InvalidEstimateTransitionError) Is(target error) bool {
+	return target == ErrInvalidStatusTransition
+}
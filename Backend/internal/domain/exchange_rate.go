@@ -0,0 +1,31 @@
+// exchange rates users enter themselves
+
+package domain
+
+import (
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// one unit of from_currency is worth rate units of to_currency , from effective_date until a newer rate
+
+type ExchangeRate struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	FromCurrency  string     `json:"from_currency"`
+	ToCurrency    string     `json:"to_currency"`
+	Rate          money.Rate `json:"rate"`
+	EffectiveDate time.Time  `json:"effective_date"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// a rate for a pair and day that already has one replaces it
+
+type SetExchangeRateRequest struct {
+	FromCurrency  string     `json:"from_currency" validate:"required,len=3"`
+	ToCurrency    string     `json:"to_currency" validate:"required,len=3"`
+	Rate          money.Rate `json:"rate" validate:"required,gt=0"`
+	EffectiveDate string     `json:"effective_date" validate:"required"`
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"time"
 
+	"github.com/Suthar345Piyush/invoicego/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -16,73 +17,169 @@ type Invoice struct {
 	DueDate            time.Time      `json:"due_date"`
 	PaidDate           *time.Time     `json:"paid_date,omitempty"`
 	Currency           string         `json:"currency"`
-	Subtotal           float64        `json:"subtotal"`
-	TaxRate            float64        `json:"tax_rate"`
-	TaxAmount          float64        `json:"tax_amount"`
-	DiscountAmount     float64        `json:"discount_amount"`
-	TotalAmount        float64        `json:"total_amount"`
+	BaseCurrency       string         `json:"base_currency"`
+	ExchangeRate       money.Rate     `json:"exchange_rate"`
+	Subtotal           money.Decimal  `json:"subtotal"`
+	TaxRate            money.Decimal  `json:"tax_rate"`
+	TaxAmount          money.Decimal  `json:"tax_amount"`
+	TaxScheme          string         `json:"tax_scheme"`
+	RoundingMode       string         `json:"rounding_mode"`
+	TaxRounding        string         `json:"tax_rounding"`
+	PlaceOfSupply      *string        `json:"place_of_supply,omitempty"`
+	SellerGSTIN        *string        `json:"seller_gstin,omitempty"`
+	BuyerGSTIN         *string        `json:"buyer_gstin,omitempty"`
+	CGSTAmount         money.Decimal  `json:"cgst_amount"`
+	SGSTAmount         money.Decimal  `json:"sgst_amount"`
+	IGSTAmount         money.Decimal  `json:"igst_amount"`
+	DiscountAmount     money.Decimal  `json:"discount_amount"`
+	TotalAmount        money.Decimal  `json:"total_amount"`
+	AmountPaid         money.Decimal  `json:"amount_paid"`
+	AmountCredited     money.Decimal  `json:"amount_credited"`
+	BalanceDue         money.Decimal  `json:"balance_due"`
 	TemplateID         string         `json:"template_id"`
 	Notes              *string        `json:"notes,omitempty"`
 	TermsAndConditions *string        `json:"terms_and_conditions,omitempty"`
 	PDFURL             *string        `json:"pdf_url,omitempty"`
 	PDFGeneratedAt     *time.Time     `json:"pdf_generated_at,omitempty"`
+	PDFKey             *string        `json:"-"`
 	EmailSent          bool           `json:"email_sent"`
 	EmailSentAt        *time.Time     `json:"email_sent_at,omitempty"`
 	EmailOpened        bool           `json:"email_opened"`
 	EmailOpenedAt      *time.Time     `json:"email_opened_at,omitempty"`
+	RemindersPaused    bool           `json:"reminders_paused"`
 	CreatedAt          time.Time      `json:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at"`
 	Items              []*InvoiceItem `json:"items,omitempty"`
 	Client             *Client        `json:"client,omitempty"`
+
+	// tax by rate , worked out from the items when the full invoice is loaded
+
+	TaxSummary []*TaxRateSummary `json:"tax_summary,omitempty"`
 }
 
+// invoice line , estimates keep their lines in the same shape without an invoice_id
+
 type InvoiceItem struct {
-	ID          uuid.UUID `json:"id"`
-	InvoiceID   uuid.UUID `json:"invoice_id"`
-	Description string    `json:"description"`
-	Quantity    float64   `json:"quantity"`
-	UnitPrice   float64   `json:"unit_price"`
-	Amount      float64   `json:"amount"`
-	SortOrder   int       `json:"sort_order"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             uuid.UUID     `json:"id"`
+	InvoiceID      uuid.UUID     `json:"invoice_id,omitzero"`
+	Description    string        `json:"description"`
+	Quantity       money.Decimal `json:"quantity"`
+	UnitPrice      money.Decimal `json:"unit_price"`
+	Amount         money.Decimal `json:"amount"`
+	DiscountType   *string       `json:"discount_type,omitempty"`
+	DiscountValue  money.Decimal `json:"discount_value"`
+	DiscountAmount money.Decimal `json:"discount_amount"`
+	HSNSAC         *string       `json:"hsn_sac,omitempty"`
+	TaxRate        money.Decimal `json:"tax_rate"`
+	TaxAmount      money.Decimal `json:"tax_amount"`
+	SortOrder      int           `json:"sort_order"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
 }
 
+// exchange_rate is how much of the user's base currency one unit of the invoice currency is worth
+// it's looked up for the issue date when it isn't given
+
 type CreateInvoiceRequest struct {
 	ClientID           uuid.UUID               `json:"client_id" validate:"required"`
 	IssueDate          string                  `json:"issue_date" validate:"required"`
 	DueDate            string                  `json:"due_date" validate:"required"`
-	Currency           string                  `json:"currency" validate:"required, len=3"`
-	TaxRate            float64                 `json:"tax_rate" validate:"gte=0, lte=100"`
-	DiscountAmount     float64                 `json:"discount_amount" validate:"gte-0"`
-	TemplateID         string                  `json:"template_id"`
+	Currency           string                  `json:"currency" validate:"required,len=3"`
+	ExchangeRate       *money.Rate             `json:"exchange_rate,omitempty"`
+	TaxRate            money.Decimal           `json:"tax_rate" validate:"gte=0,lte=100"`
+	TaxScheme          string                  `json:"tax_scheme" validate:"omitempty,oneof=flat gst"`
+	RoundingMode       string                  `json:"rounding_mode" validate:"omitempty,oneof=half_up half_even"`
+	TaxRounding        string                  `json:"tax_rounding" validate:"omitempty,oneof=line invoice"`
+	PlaceOfSupply      *string                 `json:"place_of_supply,omitempty"`
+	DiscountAmount     money.Decimal           `json:"discount_amount" validate:"gte=0"`
+	TemplateID         string                  `json:"template_id" validate:"omitempty,oneof=default modern minimal professional"`
 	Notes              *string                 `json:"notes,omitempty"`
 	TermsAndConditions *string                 `json:"terms_and_conditions,omitempty"`
-	Items              []*CreateInvoiceItemReq `json:"items" validation:"required, min=1, dive"`
+	Items              []*CreateInvoiceItemReq `json:"items" validate:"required,min=1,dive"`
 }
 
+// tax_rate is the line's own rate , lines without one use the invoice's tax_rate
+// discount_value is a percentage or an amount depending on discount_type , it comes off quantity * unit_price before tax
+// hsn_sac is the line's hsn / sac code , required on gst invoices
+
 type CreateInvoiceItemReq struct {
-	Description string  `json:"description" validate:"required"`
-	Quantity    float64 `json:"quantity" validtate:"required, gte=0"`
-	UnitPrice   float64 `json:"unit_price" validate:"required , gte=0"`
+	Description   string         `json:"description" validate:"required"`
+	Quantity      money.Decimal  `json:"quantity" validate:"required,gt=0"`
+	UnitPrice     money.Decimal  `json:"unit_price" validate:"gte=0"`
+	TaxRate       *money.Decimal `json:"tax_rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	DiscountType  string         `json:"discount_type,omitempty" validate:"omitempty,oneof=percentage fixed"`
+	DiscountValue money.Decimal  `json:"discount_value" validate:"gte=0"`
+	HSNSAC        *string        `json:"hsn_sac,omitempty"`
 }
 
 type UpdateInvoiceRequest struct {
-	ClientID           *uuid.UUID              `json:"client_id,omitmepty"`
+	ClientID           *uuid.UUID              `json:"client_id,omitempty"`
 	IssueDate          *string                 `json:"issue_date,omitempty"`
 	DueDate            *string                 `json:"due_date,omitempty"`
 	Currency           *string                 `json:"currency,omitempty" validate:"omitempty,len=3"`
-	TaxRate            *float64                `json:"tax_rate,omitempty" validate:"omitempty,gte=0,lte=100"`
-	DiscountAmount     *float64                `json:"discount_amount,omitempty" validate:"omitempty,gte=0"`
-	TemplateID         *string                 `json:"template_id,omitempty"`
+	ExchangeRate       *money.Rate             `json:"exchange_rate,omitempty"`
+	TaxRate            *money.Decimal          `json:"tax_rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	TaxScheme          *string                 `json:"tax_scheme,omitempty" validate:"omitempty,oneof=flat gst"`
+	RoundingMode       *string                 `json:"rounding_mode,omitempty" validate:"omitempty,oneof=half_up half_even"`
+	TaxRounding        *string                 `json:"tax_rounding,omitempty" validate:"omitempty,oneof=line invoice"`
+	PlaceOfSupply      *string                 `json:"place_of_supply,omitempty"`
+	DiscountAmount     *money.Decimal          `json:"discount_amount,omitempty" validate:"omitempty,gte=0"`
+	TemplateID         *string                 `json:"template_id,omitempty" validate:"omitempty,oneof=default modern minimal professional"`
 	Notes              *string                 `json:"notes,omitempty"`
 	TermsAndConditions *string                 `json:"terms_and_conditions,omitempty"`
 	Items              []*CreateInvoiceItemReq `json:"items,omitempty" validate:"omitempty,min=1,dive"`
 }
 
 type UpdateInvoiceStatusRequest struct {
-	Status   string  `json:"status" validate:"requrired, oneof=draft sent paid overdue canceled"`
-	PaidDate *string `json:"paid_date,omitempty"`
+	Status string  `json:"status" validate:"required,oneof=draft sent paid partially_paid overdue canceled"`
+	Reason *string `json:"reason,omitempty" validate:"omitempty,max=500"`
+}
+
+// emailing an invoice , subject and body fall back to the built in text when empty
+
+type SendInvoiceRequest struct {
+	Subject *string  `json:"subject,omitempty" validate:"omitempty,max=255"`
+	Body    *string  `json:"body,omitempty" validate:"omitempty,max=10000"`
+	Cc      []string `json:"cc,omitempty" validate:"max=10,dive,email"`
+	Bcc     []string `json:"bcc,omitempty" validate:"max=10,dive,email"`
+}
+
+// one row of the invoice status timeline
+// FromStatus is empty for the row written when the invoice is created
+// ChangedBy is empty when the system changed the status
+
+type InvoiceStatusChange struct {
+	ID         uuid.UUID  `json:"id"`
+	InvoiceID  uuid.UUID  `json:"invoice_id"`
+	ChangedBy  *uuid.UUID `json:"changed_by,omitempty"`
+	FromStatus *string    `json:"from_status,omitempty"`
+	ToStatus   string     `json:"to_status"`
+	Reason     *string    `json:"reason,omitempty"`
+	ChangedAt  time.Time  `json:"changed_at"`
+}
+
+// copy of a pdf exactly as it was emailed to the client , never changed or removed
+
+type InvoicePDFArchive struct {
+	ID           uuid.UUID  `json:"id"`
+	InvoiceID    uuid.UUID  `json:"invoice_id"`
+	EmailEventID *uuid.UUID `json:"email_event_id,omitempty"`
+	StorageKey   string     `json:"-"`
+	Checksum     string     `json:"checksum"`
+	SizeBytes    int        `json:"size_bytes"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// tax of one rate on an invoice
+// on gst invoices intra state supplies split the tax into cgst + sgst and the rest pay igst
+
+type TaxRateSummary struct {
+	Rate          money.Decimal `json:"rate"`
+	TaxableAmount money.Decimal `json:"taxable_amount"`
+	TaxAmount     money.Decimal `json:"tax_amount"`
+	CGSTAmount    money.Decimal `json:"cgst_amount,omitzero"`
+	SGSTAmount    money.Decimal `json:"sgst_amount,omitzero"`
+	IGSTAmount    money.Decimal `json:"igst_amount,omitzero"`
 }
 
 type InvoiceListResponse struct {
@@ -93,25 +190,88 @@ type InvoiceListResponse struct {
 	TotalPages int        `json:"total_pages"`
 }
 
+// revenue figures are in the user's base currency , by_currency has the same figures in each invoice currency
+
 type InvoiceStats struct {
-	TotalInvoices   int     `json:"total_invoices"`
-	DraftInvoices   int     `json:"draft_invoices"`
-	SentInvoices    int     `json:"sent_invoices"`
-	PaidInvoices    int     `json:"paid_invoices"`
-	OverdueInvoices int     `json:"overdue_invoices"`
-	TotalRevenue    float64 `json:"total_revenue"`
-	PendingRevenue  float64 `json:"pending_revenue"`
-	OverdueRevenue  float64 `json:"overdue_revenue"`
+	TotalInvoices         int              `json:"total_invoices"`
+	DraftInvoices         int              `json:"draft_invoices"`
+	SentInvoices          int              `json:"sent_invoices"`
+	PartiallyPaidInvoices int              `json:"partially_paid_invoices"`
+	PaidInvoices          int              `json:"paid_invoices"`
+	OverdueInvoices       int              `json:"overdue_invoices"`
+	BaseCurrency          string           `json:"base_currency"`
+	TotalRevenue          money.Decimal    `json:"total_revenue"`
+	PendingRevenue        money.Decimal    `json:"pending_revenue"`
+	OverdueRevenue        money.Decimal    `json:"overdue_revenue"`
+	TotalCredited         money.Decimal    `json:"total_credited"`
+	ByCurrency            []*CurrencyStats `json:"by_currency"`
+}
+
+// invoice counts and revenue of one currency , amounts in that currency
+
+type CurrencyStats struct {
+	Currency       string        `json:"currency"`
+	TotalInvoices  int           `json:"total_invoices"`
+	TotalRevenue   money.Decimal `json:"total_revenue"`
+	PendingRevenue money.Decimal `json:"pending_revenue"`
+	OverdueRevenue money.Decimal `json:"overdue_revenue"`
+	TotalCredited  money.Decimal `json:"total_credited"`
 }
 
 // some constants related to invoice status
 
 const (
-	InvoiceStatusDraft    = "draft"
-	InvoiceStatusSent     = "sent"
-	InvoiceStatusPaid     = "paid"
-	InvoiceStatusOverdue  = "overdue"
-	InvoiceStatusCanceled = "canceled"
+	InvoiceStatusDraft         = "draft"
+	InvoiceStatusSent          = "sent"
+	InvoiceStatusPartiallyPaid = "partially_paid"
+	InvoiceStatusPaid          = "paid"
+	InvoiceStatusOverdue       = "overdue"
+	InvoiceStatusCanceled      = "canceled"
+)
+
+// allowed status transitions , paid and canceled are final so they have no entry
+// moves into paid / partially_paid happen when payments are recorded , moves out of them when payments are deleted
+
+var invoiceStatusTransitions = map[string][]string{
+	InvoiceStatusDraft:         {InvoiceStatusSent, InvoiceStatusCanceled},
+	InvoiceStatusSent:          {InvoiceStatusPartiallyPaid, InvoiceStatusPaid, InvoiceStatusOverdue, InvoiceStatusCanceled},
+	InvoiceStatusPartiallyPaid: {InvoiceStatusPaid, InvoiceStatusOverdue, InvoiceStatusCanceled},
+	InvoiceStatusOverdue:       {InvoiceStatusPartiallyPaid, InvoiceStatusPaid, InvoiceStatusCanceled},
+}
+
+// checking a status change against the transition table
+
+func ValidateStatusTransition(from, to string) error {
+
+	for _, allowed := range invoiceStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+
+	return &InvalidStatusTransitionError{From: from, To: to}
+}
+
+// tax schemes , flat taxes every line at its rate , gst also needs hsn / sac codes and splits the tax into cgst / sgst / igst
+
+const (
+	TaxSchemeFlat = "flat"
+	TaxSchemeGST  = "gst"
+)
+
+// where tax is rounded , each line's tax on its own or once per rate on the invoice's summed lines
+// how halves are rounded is the invoice's rounding_mode (money.HalfUp / money.HalfEven)
+
+const (
+	TaxRoundingLine    = "line"
+	TaxRoundingInvoice = "invoice"
+)
+
+// line discount types
+
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
 )
 
 // some template constants
@@ -0,0 +1,51 @@
+// invoice events - activity on an invoice besides status changes , and the merged timeline
+
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// one event on an invoice
+// RelatedID points at what the event belongs to , e.g. the email an open was tracked for
+
+type InvoiceEvent struct {
+	ID         uuid.UUID  `json:"id"`
+	InvoiceID  uuid.UUID  `json:"invoice_id"`
+	Type       string     `json:"type"`
+	RelatedID  *uuid.UUID `json:"related_id,omitempty"`
+	IPAddress  *string    `json:"ip_address,omitempty"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	Details    *string    `json:"details,omitempty"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// invoice event type constants
+
+const (
+	InvoiceEventEmailSent          = "email_sent"
+	InvoiceEventEmailOpened        = "email_opened"
+	InvoiceEventShareLinkCreated   = "share_link_created"
+	InvoiceEventShareLinkRevoked   = "share_link_revoked"
+	InvoiceEventShareLinkAccessed  = "share_link_accessed"
+	InvoiceEventClientAcknowledged = "client_acknowledged"
+	InvoiceEventClientDisputed     = "client_disputed"
+)
+
+// one entry of the invoice timeline , either a status change or an event
+
+type TimelineEntry struct {
+	Kind         string               `json:"kind"`
+	OccurredAt   time.Time            `json:"occurred_at"`
+	StatusChange *InvoiceStatusChange `json:"status_change,omitempty"`
+	Event        *InvoiceEvent        `json:"event,omitempty"`
+}
+
+// timeline entry kinds
+
+const (
+	TimelineKindStatusChange = "status_change"
+	TimelineKindEvent        = "event"
+)
@@ -0,0 +1,51 @@
+// payments recorded against an invoice
+
+package domain
+
+import (
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// one payment (instalment) received for an invoice , amount is in the invoice currency
+
+type Payment struct {
+	ID           uuid.UUID     `json:"id"`
+	InvoiceID    uuid.UUID     `json:"invoice_id"`
+	UserID       uuid.UUID     `json:"user_id"`
+	Amount       money.Decimal `json:"amount"`
+	Currency     string        `json:"currency"`
+	BaseCurrency string        `json:"base_currency"`
+	ExchangeRate money.Rate    `json:"exchange_rate"`
+	Method       string        `json:"method"`
+	Reference    *string       `json:"reference,omitempty"`
+	PaidAt       time.Time     `json:"paid_at"`
+	Notes        *string       `json:"notes,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// paid_at defaults to today when it's not given
+// exchange_rate (to the user's base currency) is looked up for paid_at when it's not given
+
+type CreatePaymentRequest struct {
+	Amount       money.Decimal `json:"amount" validate:"required,gt=0"`
+	ExchangeRate *money.Rate   `json:"exchange_rate,omitempty"`
+	Method       string        `json:"method" validate:"required,oneof=bank_transfer cash card cheque upi online other"`
+	Reference    *string       `json:"reference,omitempty" validate:"omitempty,max=255"`
+	PaidAt       *string       `json:"paid_at,omitempty"`
+	Notes        *string       `json:"notes,omitempty"`
+}
+
+// payment method constants
+
+const (
+	PaymentMethodBankTransfer = "bank_transfer"
+	PaymentMethodCash         = "cash"
+	PaymentMethodCard         = "card"
+	PaymentMethodCheque       = "cheque"
+	PaymentMethodUPI          = "upi"
+	PaymentMethodOnline       = "online"
+	PaymentMethodOther        = "other"
+)
@@ -0,0 +1,56 @@
+// recurring invoice schedules
+
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// schedule that creates a copy of a template invoice every period
+// NextRunDate is empty once the schedule has no further periods
+
+type RecurringSchedule struct {
+	ID                uuid.UUID  `json:"id"`
+	UserID            uuid.UUID  `json:"user_id"`
+	TemplateInvoiceID uuid.UUID  `json:"template_invoice_id"`
+	Frequency         string     `json:"frequency"`
+	Rule              *string    `json:"rule,omitempty"`
+	StartDate         time.Time  `json:"start_date"`
+	EndDate           *time.Time `json:"end_date,omitempty"`
+	NextRunDate       *time.Time `json:"next_run_date,omitempty"`
+	AutoSend          bool       `json:"auto_send"`
+	IsActive          bool       `json:"is_active"`
+	LastRunAt         *time.Time `json:"last_run_at,omitempty"`
+	LastError         *string    `json:"last_error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// rule is required for the custom frequency , see the recurrence rules in the service
+
+type CreateRecurringScheduleRequest struct {
+	TemplateInvoiceID uuid.UUID `json:"template_invoice_id" validate:"required"`
+	Frequency         string    `json:"frequency" validate:"required,oneof=weekly monthly quarterly yearly custom"`
+	Rule              *string   `json:"rule,omitempty"`
+	StartDate         string    `json:"start_date" validate:"required"`
+	EndDate           *string   `json:"end_date,omitempty"`
+	AutoSend          bool      `json:"auto_send"`
+}
+
+type UpdateRecurringScheduleRequest struct {
+	EndDate  *string `json:"end_date,omitempty"`
+	AutoSend *bool   `json:"auto_send,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// frequency constants
+
+const (
+	FrequencyWeekly    = "weekly"
+	FrequencyMonthly   = "monthly"
+	FrequencyQuarterly = "quarterly"
+	FrequencyYearly    = "yearly"
+	FrequencyCustom    = "custom"
+)
@@ -0,0 +1,82 @@
+// payment reminders (dunning) - templates , the reminder sequence and the per invoice log
+
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// email template of a reminder
+// subject and body can use {{client_name}} , {{invoice_number}} , {{amount_due}} , {{currency}} , {{due_date}} , {{days_overdue}} and {{business_name}}
+
+type ReminderTemplate struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReminderTemplateRequest struct {
+	Name    string `json:"name" validate:"required,max=100"`
+	Subject string `json:"subject" validate:"required,max=255"`
+	Body    string `json:"body" validate:"required"`
+}
+
+// one step of the reminder sequence
+// OffsetDays is counted from the due date , -3 = three days before , 0 = on the due date
+// with RepeatEveryDays the step repeats after its first occurrence , until the invoice is paid
+// TemplateID empty means the built in template
+
+type ReminderRule struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	TemplateID      *uuid.UUID `json:"template_id,omitempty"`
+	OffsetDays      int        `json:"offset_days"`
+	RepeatEveryDays *int       `json:"repeat_every_days,omitempty"`
+	SortOrder       int        `json:"sort_order"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type ReminderRuleRequest struct {
+	TemplateID      *uuid.UUID `json:"template_id,omitempty"`
+	OffsetDays      int        `json:"offset_days" validate:"gte=-365,lte=365"`
+	RepeatEveryDays *int       `json:"repeat_every_days,omitempty" validate:"omitempty,gte=1,lte=365"`
+}
+
+// replacing the whole sequence at once , an empty list turns reminders off
+
+type UpdateReminderRulesRequest struct {
+	Rules []*ReminderRuleRequest `json:"rules" validate:"max=20,dive"`
+}
+
+// one reminder sent (or tried) for an invoice
+
+type InvoiceReminder struct {
+	ID           uuid.UUID  `json:"id"`
+	InvoiceID    uuid.UUID  `json:"invoice_id"`
+	RuleID       *uuid.UUID `json:"rule_id,omitempty"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	SentTo       string     `json:"sent_to"`
+	Subject      string     `json:"subject"`
+	Status       string     `json:"status"`
+	Error        *string    `json:"error,omitempty"`
+	SentAt       *time.Time `json:"sent_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type PauseRemindersRequest struct {
+	Paused bool `json:"paused"`
+}
+
+// reminder log status constants
+
+const (
+	ReminderStatusPending = "pending"
+	ReminderStatusSent    = "sent"
+	ReminderStatusFailed  = "failed"
+)
@@ -0,0 +1,30 @@
+// sessions - one per login , listed so the user can sign out devices they don't recognise
+
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Current marks the session the request came from
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	Device     *string   `json:"device,omitempty"`
+	IPAddress  *string   `json:"ip_address,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// where a login or refresh came from , taken from the request by the handler
+
+type SessionMeta struct {
+	Device    string
+	IPAddress string
+	UserAgent string
+}
@@ -0,0 +1,41 @@
+// share links - public , expiring and revocable links to an invoice for the client
+
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ShareLink struct {
+	ID             uuid.UUID  `json:"id"`
+	InvoiceID      uuid.UUID  `json:"invoice_id"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	URL            string     `json:"url"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	AccessCount    int        `json:"access_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// link lifetime in days , 30 when empty
+
+type CreateShareLinkRequest struct {
+	ExpiresInDays int `json:"expires_in_days,omitempty" validate:"omitempty,gte=1,lte=365"`
+}
+
+// client acknowledging or disputing the invoice through the link
+
+type ClientResponseRequest struct {
+	Note string `json:"note,omitempty" validate:"max=1000"`
+}
+
+// what the client sees through a share link
+
+type PublicInvoice struct {
+	Invoice  *Invoice      `json:"invoice"`
+	Business *User         `json:"-"`
+	Response *InvoiceEvent `json:"response,omitempty"`
+}
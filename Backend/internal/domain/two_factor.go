@@ -0,0 +1,35 @@
+// two-factor authentication - authenticator app codes and one time recovery codes
+
+package domain
+
+// setup answer , the uri goes into a qr code for the authenticator app , the secret is for typing it in by hand
+
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// recovery codes are only ever shown once , when they are made
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// code from the authenticator app , or a recovery code where the request allows one
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// second login step , the challenge token from the first step and a code
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	Device         string `json:"device,omitempty" validate:"omitempty,max=100"`
+}
@@ -17,38 +17,117 @@ type User struct {
 	BusinessEmail       *string    `json:"business_email,omitempty"`
 	TaxID               *string    `json:"tax_id,omitempty"`
 	LogoURL             *string    `json:"logo_url,omitempty"`
+	LogoKey             *string    `json:"-"`
+	BrandPrimaryColor   *string    `json:"brand_primary_color,omitempty"`
+	BrandAccentColor    *string    `json:"brand_accent_color,omitempty"`
+	BrandFont           *string    `json:"brand_font,omitempty"`
+	ReplyToEmail        *string    `json:"reply_to_email,omitempty"`
 	SubscriptionTier    string     `json:"subscription_tier"`
 	SubscriptionStatus  string     `json:"subscription_status"`
 	MonthlyInvoiceCount int        `json:"monthly_invoice_count"`
 	MonthlyInvoiceLimit int        `json:"monthly_invoice_limit"`
 	DefaultCurrency     string     `json:"default_currency"`
+	BaseCurrency        string     `json:"base_currency"`
 	DefaultPaymentTerms int        `json:"default_payment_terms"`
 	InvoiceNumberPrefix string     `json:"invoice_number_prefix"`
 	NextInvoiceNumber   int        `json:"next_invoice_number"`
+	Timezone            string     `json:"timezone"`
 	EmailVerified       bool       `json:"email_verified"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
 	IsActive            bool       `json:"is_active"`
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at"`
 	LastLoginAt         *time.Time `json:"last_login_at,omitempty"`
 }
 
+// name shown to clients , the business name when it's set
+
+func (u *User) DisplayName() string {
+
+	if u.BusinessName != nil && *u.BusinessName != "" {
+		return *u.BusinessName
+	}
+
+	return u.FullName
+}
+
+// device is an optional name for the session , like "Work laptop" , it's guessed from the user agent when missing
+
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 	FullName string `json:"full_name" validate:"required,min=2"`
+	Device   string `json:"device,omitempty" validate:"omitempty,max=100"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Device   string `json:"device,omitempty" validate:"omitempty,max=100"`
 }
 
+// with two-factor on , the first step only gives a challenge token , exchanged with a code for the tokens
+
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	User         *User  `json:"user"`
+	AccessToken       string `json:"access_token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	User              *User  `json:"user,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+// user settings update , nil fields are left unchanged
+
+// an empty reply_to_email clears it
+// base_currency is what stats and reports are converted to
+
+type UpdateUserRequest struct {
+	Timezone     *string `json:"timezone,omitempty"`
+	ReplyToEmail *string `json:"reply_to_email,omitempty"`
+	BaseCurrency *string `json:"base_currency,omitempty" validate:"omitempty,len=3"`
+}
+
+// brand settings for generated pdfs , colours as "#rrggbb"
+// nil fields are left unchanged , "" goes back to the template's own colour or font
+
+type UpdateBrandingRequest struct {
+	PrimaryColor *string `json:"primary_color,omitempty"`
+	AccentColor  *string `json:"accent_color,omitempty"`
+	Font         *string `json:"font,omitempty"`
 }
 
+// fonts a brand can pick
+
+const (
+	BrandFontHelvetica = "helvetica"
+	BrandFontTimes     = "times"
+	BrandFontCourier   = "courier"
+)
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
+
+// forgot password only needs the address , the answer is the same whether it has an account or not
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// token from the reset email and the new password
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+// token from the verification email
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,253 @@
+// file provider - rates imported from a csv file , works offline
+
+package fx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+// rates from a csv file with the rows "date,from,to,rate" , like "2026-01-02,EUR,USD,1.0354"
+// a header row and lines starting with # are skipped
+// the file is read again when it changes , so a fresh export can be dropped in while the server runs
+// pairs missing from the file are worked out through a currency both sides have a rate with (USD -> EUR -> INR)
+
+type FileProvider struct {
+	path string
+
+	mu       sync.Mutex
+	modified time.Time
+	rates    map[pair][]datedRate
+}
+
+type pair struct {
+	from string
+	to   string
+}
+
+type datedRate struct {
+	date time.Time
+	rate money.Rate
+}
+
+// file provider function , fails when the file can't be read or has a bad row
+
+func NewFileProvider(path string) (*FileProvider, error) {
+
+	p := &FileProvider{path: path}
+
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *FileProvider) Rate(_ uuid.UUID, from, to string, on time.Time) (money.Rate, error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// a file that went bad is logged and the rates read before are kept
+
+	if info, err := os.Stat(p.path); err == nil && !info.ModTime().Equal(p.modified) {
+		if err := p.reload(); err != nil {
+			log.Printf("fx: %v", err)
+			p.modified = info.ModTime()
+		}
+	}
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+
+	if rate, ok, err := p.lookup(from, to, day); ok || err != nil {
+		return rate, err
+	}
+
+	for _, via := range p.currencies() {
+
+		if via == from || via == to {
+			continue
+		}
+
+		first, ok, err := p.lookup(from, via, day)
+
+		if err != nil {
+			return money.Rate{}, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		second, ok, err := p.lookup(via, to, day)
+
+		if err != nil {
+			return money.Rate{}, err
+		}
+
+		if ok {
+			return crossRate(first, second, from, via, to)
+		}
+	}
+
+	return money.Rate{}, ErrRateNotFound
+}
+
+// rate of a pair on a day , from the pair itself or the inverse of the opposite pair
+// an error when the opposite rate is too small to turn round
+
+func (p *FileProvider) lookup(from, to string, day time.Time) (money.Rate, bool, error) {
+
+	if rate, ok := latest(p.rates[pair{from, to}], day); ok {
+		return rate, true, nil
+	}
+
+	if rate, ok := latest(p.rates[pair{to, from}], day); ok {
+
+		inverse, err := rate.Inverse()
+
+		if err != nil {
+			return money.Rate{}, false, fmt.Errorf("inverting the %s/%s rate %s: %w", to, from, rate, err)
+		}
+
+		return inverse, true, nil
+	}
+
+	return money.Rate{}, false, nil
+}
+
+// from -> via -> to , an error when the two rates multiply out of range
+
+func crossRate(first, second money.Rate, from, via, to string) (money.Rate, error) {
+
+	rate, err := first.Times(second)
+
+	if err != nil {
+		return money.Rate{}, fmt.Errorf("crossing %s/%s through %s: %w", from, to, via, err)
+	}
+
+	return rate, nil
+}
+
+// latest rate on or before the day , rates are sorted by date
+
+func latest(rates []datedRate, day time.Time) (money.Rate, bool) {
+
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].date.After(day) })
+
+	if i == 0 {
+		return money.Rate{}, false
+	}
+
+	return rates[i-1].rate, true
+}
+
+// every currency in the file , sorted so crossing is always done through the same one
+
+func (p *FileProvider) currencies() []string {
+
+	seen := map[string]bool{}
+
+	for key := range p.rates {
+		seen[key.from] = true
+		seen[key.to] = true
+	}
+
+	list := make([]string, 0, len(seen))
+
+	for currency := range seen {
+		list = append(list, currency)
+	}
+
+	sort.Strings(list)
+
+	return list
+}
+
+// reading the whole file , the old rates are only replaced when every row is good
+
+func (p *FileProvider) reload() error {
+
+	f, err := os.Open(p.path)
+
+	if err != nil {
+		return fmt.Errorf("opening rates file: %w", err)
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	rates := map[pair][]datedRate{}
+
+	for line := 1; ; line++ {
+
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("rates file: %w", err)
+		}
+
+		date, err := time.Parse(dateLayout, strings.TrimSpace(record[0]))
+
+		// the header row
+
+		if err != nil && line == 1 {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("rates file line %d: invalid date %q", line, record[0])
+		}
+
+		rate, err := money.ParseRate(record[3])
+
+		if err != nil {
+			return fmt.Errorf("rates file line %d: %w", line, err)
+		}
+
+		key := pair{from: strings.ToUpper(strings.TrimSpace(record[1])), to: strings.ToUpper(strings.TrimSpace(record[2]))}
+
+		if len(key.from) != 3 || len(key.to) != 3 {
+			return fmt.Errorf("rates file line %d: currencies must be 3 letter codes", line)
+		}
+
+		rates[key] = append(rates[key], datedRate{date: date, rate: rate})
+	}
+
+	for _, list := range rates {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].date.Before(list[j].date) })
+	}
+
+	p.rates = rates
+	p.modified = info.ModTime()
+
+	return nil
+}
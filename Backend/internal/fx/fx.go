@@ -0,0 +1,51 @@
+// fx - exchange rates between currencies behind pluggable providers (manual rate table , imported rate file)
+
+package fx
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// every provider implements this
+// the rate is how many units of to buy one unit of from , the latest one known on the given day
+// userID is for providers that keep rates per user , others ignore it
+
+type Provider interface {
+	Rate(userID uuid.UUID, from, to string, on time.Time) (money.Rate, error)
+}
+
+// providers asked in order , the first one that knows the rate wins
+// a currency against itself is always 1
+
+type Chain []Provider
+
+func (c Chain) Rate(userID uuid.UUID, from, to string, on time.Time) (money.Rate, error) {
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	if from == to {
+		return money.One, nil
+	}
+
+	for _, provider := range c {
+
+		rate, err := provider.Rate(userID, from, to, on)
+
+		if err == nil {
+			return rate, nil
+		}
+
+		if !errors.Is(err, ErrRateNotFound) {
+			return money.Rate{}, err
+		}
+	}
+
+	return money.Rate{}, ErrRateNotFound
+}
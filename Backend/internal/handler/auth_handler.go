@@ -4,6 +4,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/Suthar345Piyush/invoicego/internal/domain"
@@ -23,7 +24,16 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 	return &AuthHandler{authService: authService}
 }
 
-//  auth handler function - REGISTER & LOGIN
+// where the request came from , recorded on the session
+
+func sessionMeta(r *http.Request) *domain.SessionMeta {
+	return &domain.SessionMeta{
+		IPAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+//  auth handler function - REGISTER , LOGIN & REFRESH
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req domain.RegisterRequest
@@ -38,7 +48,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	// incoming response
 
-	resp, err := h.authService.Register(&req)
+	resp, err := h.authService.Register(&req, sessionMeta(r))
 
 	if err != nil {
 		if err == domain.ErrUserAlreadyExists {
@@ -71,7 +81,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// response completion
 	//passing request address to the authservice
 
-	resp, err := h.authService.Login(&req)
+	resp, err := h.authService.Login(&req, sessionMeta(r))
 
 	if err != nil {
 
@@ -86,8 +96,75 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	// with two-factor on the password was right but the code is still needed
+
+	if resp.TwoFactorRequired {
+		util.WriteSuccess(w, http.StatusOK, resp, "Two-factor code required")
+		return
+	}
+
 	// if response returned correct , then sending correct status with it
 
 	util.WriteSuccess(w, http.StatusOK, resp, "Login Successful")
 
 }
+
+// second login step for users with two-factor on
+
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorLoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	resp, err := h.authService.LoginTwoFactor(&req, sessionMeta(r))
+
+	if err != nil {
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			util.WriteError(w, http.StatusBadRequest, err)
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrInvalidTwoFactorCode):
+			util.WriteError(w, http.StatusUnauthorized, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, resp, "Login Successful")
+
+}
+
+// REFRESH function , a refresh token for a new token pair
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshTokenRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	resp, err := h.authService.Refresh(&req, sessionMeta(r))
+
+	if err != nil {
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			util.WriteError(w, http.StatusBadRequest, err)
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrTokenExpired), errors.Is(err, domain.ErrRefreshTokenReused):
+			util.WriteError(w, http.StatusUnauthorized, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, resp, "Token refreshed successfully")
+
+}
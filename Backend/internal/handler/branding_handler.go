@@ -0,0 +1,168 @@
+// branding handler - business logo and brand colours used on generated pdfs
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+)
+
+type BrandingHandler struct {
+	brandingService *service.BrandingService
+}
+
+// function to making branding handler
+
+func NewBrandingHandler(brandingService *service.BrandingService) *BrandingHandler {
+	return &BrandingHandler{brandingService: brandingService}
+}
+
+// uploading the logo , multipart form with the image in the "logo" field
+
+func (h *BrandingHandler) UploadLogo(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	// room for the multipart headers on top of the image itself
+
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxLogoBytes+64<<10)
+
+	file, _, err := r.FormFile("logo")
+
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			util.WriteError(w, http.StatusRequestEntityTooLarge, domain.ErrLogoTooLarge)
+			return
+		}
+
+		util.WriteError(w, http.StatusBadRequest, errors.New("logo file is required"))
+		return
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	user, err := h.brandingService.UploadLogo(claims.UserID, data)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrLogoTooLarge):
+			util.WriteError(w, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, domain.ErrInvalidInput):
+			util.WriteError(w, http.StatusBadRequest, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, user, "Logo uploaded successfully")
+}
+
+// serving the logo image
+
+func (h *BrandingHandler) GetLogo(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	data, contentType, err := h.brandingService.GetLogo(claims.UserID)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrLogoNotFound) {
+			util.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// removing the logo
+
+func (h *BrandingHandler) DeleteLogo(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	if err := h.brandingService.DeleteLogo(claims.UserID); err != nil {
+		if errors.Is(err, domain.ErrLogoNotFound) {
+			util.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Logo deleted successfully")
+}
+
+// updating brand colours and font
+
+func (h *BrandingHandler) UpdateBranding(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.UpdateBrandingRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	user, err := h.brandingService.UpdateBranding(claims.UserID, &req)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			util.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, user, "Branding updated successfully")
+}
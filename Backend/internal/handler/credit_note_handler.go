@@ -0,0 +1,229 @@
+// credit note handler - issuing credit notes against invoices , listing them and their pdfs
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CreditNoteHandler struct {
+	creditNoteService *service.CreditNoteService
+	invoiceService    *service.InvoiceService
+	pdfService        *service.PDFService
+	userService       *service.UserService
+}
+
+// credit note handler function
+
+func NewCreditNoteHandler(creditNoteService *service.CreditNoteService, invoiceService *service.InvoiceService, pdfService *service.PDFService, userService *service.UserService) *CreditNoteHandler {
+	return &CreditNoteHandler{
+		creditNoteService: creditNoteService,
+		invoiceService:    invoiceService,
+		pdfService:        pdfService,
+		userService:       userService,
+	}
+}
+
+// mapping credit note errors to status codes
+
+func writeCreditNoteError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrInvoiceNotFound), errors.Is(err, domain.ErrCreditNoteNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrInvoiceNotCreditable):
+		util.WriteError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrInvalidInput):
+		util.WriteError(w, http.StatusBadRequest, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// issuing a credit note against an invoice
+
+func (h *CreditNoteHandler) CreateCreditNote(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	var req domain.CreateCreditNoteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	// validating the input
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	note, err := h.creditNoteService.CreateCreditNote(claims.UserID, invoiceID, &req)
+
+	if err != nil {
+		writeCreditNoteError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, note, "Credit note issued successfully")
+
+}
+
+// credit notes of one invoice
+
+func (h *CreditNoteHandler) ListInvoiceCreditNotes(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	notes, err := h.creditNoteService.ListCreditNotes(claims.UserID, &invoiceID)
+
+	if err != nil {
+		writeCreditNoteError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, notes, "Credit notes retrieved successfully")
+
+}
+
+// every credit note of the user
+
+func (h *CreditNoteHandler) ListCreditNotes(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	notes, err := h.creditNoteService.ListCreditNotes(claims.UserID, nil)
+
+	if err != nil {
+		writeCreditNoteError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, notes, "Credit notes retrieved successfully")
+
+}
+
+// one credit note with its lines
+
+func (h *CreditNoteHandler) GetCreditNote(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	creditNoteID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid credit note ID"))
+		return
+	}
+
+	note, err := h.creditNoteService.GetCreditNote(claims.UserID, creditNoteID)
+
+	if err != nil {
+		writeCreditNoteError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, note, "Credit note retrieved successfully")
+
+}
+
+// downloading the credit note pdf , credit notes never change so it's rendered on request
+
+func (h *CreditNoteHandler) DownloadCreditNote(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	creditNoteID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid credit note ID"))
+		return
+	}
+
+	note, err := h.creditNoteService.GetCreditNote(claims.UserID, creditNoteID)
+
+	if err != nil {
+		writeCreditNoteError(w, err)
+		return
+	}
+
+	// the source invoice gives the client , gst details and currency
+
+	invoice, err := h.invoiceService.GetInvoiceByID(claims.UserID, note.InvoiceID)
+
+	if err != nil {
+		writeCreditNoteError(w, err)
+		return
+	}
+
+	user, err := h.userService.GetUserByID(claims.UserID)
+
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pdfBytes, err := h.pdfService.GenerateCreditNotePDF(note, invoice, user)
+
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+note.CreditNoteNumber+".pdf")
+	w.Header().Set("Content-length", strconv.Itoa(len(pdfBytes)))
+
+	w.Write(pdfBytes)
+}
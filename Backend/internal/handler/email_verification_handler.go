@@ -0,0 +1,111 @@
+// email verification handler - the link from the email , and resending it
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+)
+
+type EmailVerificationHandler struct {
+	verificationService *service.EmailVerificationService
+}
+
+// email verification handler function
+
+func NewEmailVerificationHandler(verificationService *service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{verificationService: verificationService}
+}
+
+// the link opened from the email , answers with a plain page for the browser
+
+func (h *EmailVerificationHandler) VerifyEmailLink(w http.ResponseWriter, r *http.Request) {
+
+	err := h.verificationService.VerifyEmail(r.URL.Query().Get("token"))
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch {
+	case err == nil:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("Your email address is verified. You can close this page."))
+	case errors.Is(err, domain.ErrTokenExpired):
+		http.Error(w, "This link has expired. Please request a new verification email.", http.StatusGone)
+	case errors.Is(err, domain.ErrInvalidToken):
+		http.Error(w, "This verification link is not valid.", http.StatusBadRequest)
+	default:
+		log.Printf("email verification: %v", err)
+		http.Error(w, "Something went wrong, please try again later.", http.StatusInternalServerError)
+	}
+
+}
+
+// same as the link , for api callers that pass the token on
+
+func (h *EmailVerificationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+
+	var req domain.VerifyEmailRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.verificationService.VerifyEmail(req.Token); err != nil {
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrTokenExpired):
+			util.WriteError(w, http.StatusBadRequest, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Email verified successfully")
+
+}
+
+// emailing a new link to the logged in user
+
+func (h *EmailVerificationHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	if err := h.verificationService.ResendVerification(claims.UserID); err != nil {
+
+		switch {
+		case errors.Is(err, domain.ErrEmailAlreadyVerified):
+			util.WriteError(w, http.StatusConflict, err)
+		case errors.Is(err, domain.ErrUserNotFound):
+			util.WriteError(w, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrEmailDeliveryFailed):
+			util.WriteError(w, http.StatusBadGateway, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Verification email sent successfully")
+
+}
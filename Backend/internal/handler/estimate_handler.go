@@ -0,0 +1,313 @@
+// estimate handler - estimates of the user , their pdfs and turning them into invoices
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type EstimateHandler struct {
+	estimateService *service.EstimateService
+}
+
+// estimate handler function
+
+func NewEstimateHandler(estimateService *service.EstimateService) *EstimateHandler {
+	return &EstimateHandler{estimateService: estimateService}
+}
+
+// mapping estimate errors to status codes
+
+func writeEstimateError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrEstimateNotFound), errors.Is(err, domain.ErrClientNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrEstimateNotEditable), errors.Is(err, domain.ErrEstimateNotConvertible), errors.Is(err, domain.ErrEstimateConverted), errors.Is(err, domain.ErrInvalidStatusTransition):
+		util.WriteError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrInvoiceLimitExceeded):
+		util.WriteError(w, http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrExchangeRateNotFound):
+		util.WriteError(w, http.StatusBadRequest, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// estimate id from the url
+
+func estimateID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid estimate ID"))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// creating a draft estimate
+
+func (h *EstimateHandler) CreateEstimate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.CreateEstimateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	estimate, err := h.estimateService.CreateEstimate(claims.UserID, &req)
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, estimate, "Estimate created successfully")
+
+}
+
+// estimates of the user , ?status= filters them
+
+func (h *EstimateHandler) ListEstimates(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	estimates, err := h.estimateService.ListEstimates(claims.UserID, r.URL.Query().Get("status"))
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, estimates, "Estimates retrieved successfully")
+
+}
+
+func (h *EstimateHandler) GetEstimate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	id, ok := estimateID(w, r)
+
+	if !ok {
+		return
+	}
+
+	estimate, err := h.estimateService.GetEstimate(claims.UserID, id)
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, estimate, "Estimate retrieved successfully")
+
+}
+
+// editing a draft estimate
+
+func (h *EstimateHandler) UpdateEstimate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	id, ok := estimateID(w, r)
+
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateEstimateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	estimate, err := h.estimateService.UpdateEstimate(claims.UserID, id, &req)
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, estimate, "Estimate updated successfully")
+
+}
+
+// sending the estimate , or recording the client's answer by hand
+
+func (h *EstimateHandler) UpdateEstimateStatus(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	id, ok := estimateID(w, r)
+
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateEstimateStatusRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	estimate, err := h.estimateService.UpdateEstimateStatus(claims.UserID, id, &req)
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, estimate, "Estimate status updated successfully")
+
+}
+
+func (h *EstimateHandler) DeleteEstimate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	id, ok := estimateID(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := h.estimateService.DeleteEstimate(claims.UserID, id); err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Estimate deleted successfully")
+
+}
+
+// turning an accepted estimate into a draft invoice
+
+func (h *EstimateHandler) ConvertEstimate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	id, ok := estimateID(w, r)
+
+	if !ok {
+		return
+	}
+
+	var req domain.ConvertEstimateRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+			return
+		}
+	}
+
+	invoice, err := h.estimateService.ConvertToInvoice(claims.UserID, id, &req)
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, invoice, "Estimate converted into an invoice successfully")
+
+}
+
+// downloading the estimate pdf
+
+func (h *EstimateHandler) DownloadEstimate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	id, ok := estimateID(w, r)
+
+	if !ok {
+		return
+	}
+
+	pdfBytes, estimate, err := h.estimateService.GetEstimatePDF(claims.UserID, id)
+
+	if err != nil {
+		writeEstimateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+estimate.EstimateNumber+".pdf")
+	w.Header().Set("Content-length", strconv.Itoa(len(pdfBytes)))
+
+	w.Write(pdfBytes)
+}
@@ -0,0 +1,123 @@
+// exchange rate handler - the user's own rate table
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ExchangeRateHandler struct {
+	exchangeRateService *service.ExchangeRateService
+}
+
+// exchange rate handler function
+
+func NewExchangeRateHandler(exchangeRateService *service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{exchangeRateService: exchangeRateService}
+}
+
+// mapping rate errors to status codes
+
+func writeExchangeRateError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrExchangeRateNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrInvalidInput):
+		util.WriteError(w, http.StatusBadRequest, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// listing the user's rates
+
+func (h *ExchangeRateHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	rates, err := h.exchangeRateService.ListRates(claims.UserID)
+
+	if err != nil {
+		writeExchangeRateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, rates, "Exchange rates retrieved successfully")
+
+}
+
+// adding a rate , or replacing the pair's rate on that day
+
+func (h *ExchangeRateHandler) SetRate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.SetExchangeRateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rate, err := h.exchangeRateService.SetRate(claims.UserID, &req)
+
+	if err != nil {
+		writeExchangeRateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, rate, "Exchange rate saved successfully")
+
+}
+
+// deleting a rate , invoices and payments already converted with it keep their rate
+
+func (h *ExchangeRateHandler) DeleteRate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	rateID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid exchange rate ID"))
+		return
+	}
+
+	if err := h.exchangeRateService.DeleteRate(claims.UserID, rateID); err != nil {
+		writeExchangeRateError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Exchange rate deleted successfully")
+
+}
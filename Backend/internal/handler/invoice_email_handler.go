@@ -0,0 +1,80 @@
+// invoice email handler - sending invoices to clients
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type InvoiceEmailHandler struct {
+	invoiceEmailService *service.InvoiceEmailService
+}
+
+// invoice email handler function
+
+func NewInvoiceEmailHandler(invoiceEmailService *service.InvoiceEmailService) *InvoiceEmailHandler {
+	return &InvoiceEmailHandler{invoiceEmailService: invoiceEmailService}
+}
+
+// emailing the invoice pdf to the client , the body is optional
+
+func (h *InvoiceEmailHandler) SendInvoice(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	var req domain.SendInvoiceRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+			return
+		}
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	invoice, err := h.invoiceEmailService.SendInvoice(claims.UserID, invoiceID, &req)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvoiceNotFound):
+			util.WriteError(w, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvoiceNotSendable), errors.Is(err, domain.ErrClientEmailMissing):
+			util.WriteError(w, http.StatusConflict, err)
+		case errors.Is(err, domain.ErrEmailNotVerified):
+			util.WriteError(w, http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrEmailDeliveryFailed):
+			util.WriteError(w, http.StatusBadGateway, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, invoice, "Invoice sent successfully")
+
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Suthar345Piyush/invoicego/internal/domain"
 	"github.com/Suthar345Piyush/invoicego/internal/middleware"
@@ -75,6 +76,11 @@ func (h *InvoiceHandler) CreateInvoice(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, domain.ErrInvalidInput) || errors.Is(err, domain.ErrExchangeRateNotFound) {
+			util.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
 		util.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -141,6 +147,10 @@ func (h *InvoiceHandler) ListInvoices(w http.ResponseWriter, r *http.Request) {
 
 	status := r.URL.Query().Get("status")
 
+	// opened_unpaid=true keeps only invoices whose email was opened but that aren't paid yet
+
+	openedUnpaid, _ := strconv.ParseBool(r.URL.Query().Get("opened_unpaid"))
+
 	if page < 1 {
 		page = 1
 	}
@@ -149,7 +159,7 @@ func (h *InvoiceHandler) ListInvoices(w http.ResponseWriter, r *http.Request) {
 		pageSize = 20
 	}
 
-	invoices, err := h.invoiceService.GetInvoiceByUserID(claims.UserID, page, pageSize, status)
+	invoices, err := h.invoiceService.GetInvoiceByUserID(claims.UserID, page, pageSize, status, openedUnpaid)
 
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, err)
@@ -160,6 +170,61 @@ func (h *InvoiceHandler) ListInvoices(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// function for editing a draft invoice
+
+func (h *InvoiceHandler) UpdateInvoice(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceIDStr := chi.URLParam(r, "id")
+	invoiceID, err := uuid.Parse(invoiceIDStr)
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	var req domain.UpdateInvoiceRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	// validating the input
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	invoice, err := h.invoiceService.UpdateInvoice(claims.UserID, invoiceID, &req)
+
+	// 409 conflict - invoice exists but is no longer a draft
+
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvoiceNotFound):
+			util.WriteError(w, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvoiceNotEditable):
+			util.WriteError(w, http.StatusConflict, err)
+		case errors.Is(err, domain.ErrClientNotFound), errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrExchangeRateNotFound):
+			util.WriteError(w, http.StatusBadRequest, err)
+		default:
+			util.WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, invoice, "Invoice updated successfully")
+
+}
+
 // function for updating the invoice status
 
 func (h *InvoiceHandler) UpdateInvoiceStatus(w http.ResponseWriter, r *http.Request) {
@@ -200,7 +265,14 @@ func (h *InvoiceHandler) UpdateInvoiceStatus(w http.ResponseWriter, r *http.Requ
 	invoice, err := h.invoiceService.UpdateInvoiceStatus(claims.UserID, invoiceID, &req)
 
 	if err != nil {
-		util.WriteError(w, http.StatusBadRequest, err)
+		switch {
+		case errors.Is(err, domain.ErrInvoiceNotFound):
+			util.WriteError(w, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvalidStatusTransition), errors.Is(err, domain.ErrStatusSetByPayments):
+			util.WriteError(w, http.StatusConflict, err)
+		default:
+			util.WriteError(w, http.StatusBadRequest, err)
+		}
 		return
 	}
 
@@ -208,6 +280,75 @@ func (h *InvoiceHandler) UpdateInvoiceStatus(w http.ResponseWriter, r *http.Requ
 
 }
 
+// status history (timeline) of an invoice
+
+func (h *InvoiceHandler) GetInvoiceHistory(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceIDStr := chi.URLParam(r, "id")
+	invoiceID, err := uuid.Parse(invoiceIDStr)
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	history, err := h.invoiceService.GetInvoiceStatusHistory(claims.UserID, invoiceID)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrInvoiceNotFound) {
+			util.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, history, "Invoice history retrieved successfully")
+
+}
+
+// invoice timeline - status changes , emails , opens and other events in time order
+
+func (h *InvoiceHandler) GetInvoiceTimeline(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	timeline, err := h.invoiceService.GetInvoiceTimeline(claims.UserID, invoiceID)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrInvoiceNotFound) {
+			util.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, timeline, "Invoice timeline retrieved successfully")
+
+}
+
 // invoice delete function
 
 func (h *InvoiceHandler) DeleteInvoice(w http.ResponseWriter, r *http.Request) {
@@ -269,15 +410,27 @@ func (h *InvoiceHandler) GeneratePDF(w http.ResponseWriter, r *http.Request) {
 
 	// getting user details
 
-	user, err := h.userService.GetUserByID(invoice.ClientID)
+	user, err := h.userService.GetUserByID(claims.UserID)
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	// generate pdf method
+	// the etag changes whenever something printed on the pdf changes , so a client's copy can be reused until then
+
+	etag := `"` + h.pdfService.PDFETag(invoice, user) + `"`
 
-	pdfBytes, err := h.pdfService.GenerateInvoicePDF(invoice, user)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// stored pdf , rendered again only when the invoice changed
+
+	pdfBytes, _, err := h.pdfService.GetInvoicePDF(invoice, user)
 
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, err)
@@ -297,6 +450,96 @@ func (h *InvoiceHandler) GeneratePDF(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// If-None-Match holds a list of etags (or *) , weak ones compare equal to strong ones
+
+func etagMatches(header, etag string) bool {
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// archived copies of the pdfs emailed to the client
+
+func (h *InvoiceHandler) ListPDFArchives(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	archives, err := h.pdfService.ListArchives(claims.UserID, invoiceID)
+
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, archives, "PDF archives retrieved successfully")
+}
+
+// downloading one archived pdf , byte for byte what was emailed
+
+func (h *InvoiceHandler) DownloadPDFArchive(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	archiveID, err := uuid.Parse(chi.URLParam(r, "archiveID"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid archive ID"))
+		return
+	}
+
+	archive, pdfBytes, err := h.pdfService.GetArchive(claims.UserID, invoiceID, archiveID)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrPDFArchiveNotFound) {
+			util.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// archives never change , so the checksum works as a strong etag
+
+	w.Header().Set("Content-type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+archive.ID.String()+".pdf")
+	w.Header().Set("Content-length", strconv.Itoa(len(pdfBytes)))
+	w.Header().Set("ETag", `"`+archive.Checksum+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdfBytes)
+}
+
 // duplicate invoice function
 
 func (h *InvoiceHandler) DuplicateInvoice(w http.ResponseWriter, r *http.Request) {
@@ -318,6 +561,13 @@ func (h *InvoiceHandler) DuplicateInvoice(w http.ResponseWriter, r *http.Request
 
 	invoice, err := h.invoiceService.DuplicateInvoice(claims.UserID, invoiceID)
 
+	// the copy is issued today , which may have no rate for its currency yet
+
+	if errors.Is(err, domain.ErrExchangeRateNotFound) {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -341,6 +591,13 @@ func (h *InvoiceHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := h.invoiceService.GetInvoiceStats(claims.UserID)
 
+	// invoices made in an old base currency need a rate to the current one
+
+	if errors.Is(err, domain.ErrExchangeRateNotFound) {
+		util.WriteError(w, http.StatusConflict, err)
+		return
+	}
+
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, err)
 		return
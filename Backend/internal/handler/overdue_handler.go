@@ -0,0 +1,44 @@
+// overdue handler - manual trigger for the overdue sweeper
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+)
+
+type OverdueHandler struct {
+	overdueService *service.OverdueService
+}
+
+// overdue handler function
+
+func NewOverdueHandler(overdueService *service.OverdueService) *OverdueHandler {
+	return &OverdueHandler{overdueService: overdueService}
+}
+
+// running the sweep right away for the current user's invoices , used for backfilling
+
+func (h *OverdueHandler) SweepOverdue(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	count, err := h.overdueService.Sweep(&claims.UserID)
+
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, map[string]int{"marked_overdue": count}, "Overdue sweep completed successfully")
+
+}
@@ -0,0 +1,107 @@
+// password handler - forgot password , reset and change password
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+)
+
+type PasswordHandler struct {
+	passwordService *service.PasswordService
+}
+
+// password handler function
+
+func NewPasswordHandler(passwordService *service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService: passwordService}
+}
+
+// mapping password errors to status codes
+
+func writePasswordError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrTokenExpired):
+		util.WriteError(w, http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrIncorrectPassword):
+		util.WriteError(w, http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrUserNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// the same answer whether the email has an account or not
+
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+
+	var req domain.ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := h.passwordService.ForgotPassword(&req); err != nil {
+		writePasswordError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "If an account exists for this email, a password reset link has been sent")
+
+}
+
+// new password with the token from the reset email
+
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+
+	var req domain.ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(&req); err != nil {
+		writePasswordError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Password reset successfully, please log in again")
+
+}
+
+// changing the password , every session is signed out including this one
+
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.ChangePasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := h.passwordService.ChangePassword(claims.UserID, &req); err != nil {
+		writePasswordError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Password changed successfully, please log in again")
+
+}
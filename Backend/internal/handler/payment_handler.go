@@ -0,0 +1,148 @@
+// payment handler - payments ledger routes under an invoice
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type PaymentHandler struct {
+	paymentService *service.PaymentService
+}
+
+// payment handler function
+
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+// mapping ledger errors to status codes
+
+func writePaymentError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrInvoiceNotFound), errors.Is(err, domain.ErrPaymentNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrInvoiceNotPayable), errors.Is(err, domain.ErrInvalidStatusTransition):
+		util.WriteError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrPaymentExceedsBalance), errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrExchangeRateNotFound):
+		util.WriteError(w, http.StatusBadRequest, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// recording a payment for an invoice
+
+func (h *PaymentHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	var req domain.CreatePaymentRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	// validating the input
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payment, err := h.paymentService.RecordPayment(claims.UserID, invoiceID, &req)
+
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, payment, "Payment recorded successfully")
+
+}
+
+// listing payments of an invoice
+
+func (h *PaymentHandler) ListPayments(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	payments, err := h.paymentService.ListPayments(claims.UserID, invoiceID)
+
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, payments, "Payments retrieved successfully")
+
+}
+
+// deleting a payment from an invoice
+
+func (h *PaymentHandler) DeletePayment(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	paymentID, err := uuid.Parse(chi.URLParam(r, "paymentID"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid payment ID"))
+		return
+	}
+
+	if err := h.paymentService.DeletePayment(claims.UserID, invoiceID, paymentID); err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Payment deleted successfully")
+
+}
@@ -0,0 +1,252 @@
+// public estimate handler - the client's page of an estimate , where it's accepted or declined (no auth)
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+type PublicEstimateHandler struct {
+	estimateService *service.EstimateService
+}
+
+// public estimate handler function
+
+func NewPublicEstimateHandler(estimateService *service.EstimateService) *PublicEstimateHandler {
+	return &PublicEstimateHandler{estimateService: estimateService}
+}
+
+// plain error page for the browser
+
+func writePublicEstimateError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrEstimateNotFound):
+		http.Error(w, "This estimate link is not valid.", http.StatusNotFound)
+	case errors.Is(err, domain.ErrEstimateExpired):
+		http.Error(w, "This estimate has expired. Please ask the sender for a new one.", http.StatusGone)
+	case errors.Is(err, domain.ErrEstimateClosed):
+		http.Error(w, "This estimate was already answered.", http.StatusConflict)
+	default:
+		log.Printf("public estimate: %v", err)
+		http.Error(w, "Something went wrong, please try again later.", http.StatusInternalServerError)
+	}
+}
+
+// json errors for api callers
+
+func writeEstimateResponseError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrEstimateNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrEstimateExpired):
+		util.WriteError(w, http.StatusGone, err)
+	case errors.Is(err, domain.ErrEstimateClosed):
+		util.WriteError(w, http.StatusConflict, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// html page of the estimate , with accept / decline while it's waiting for an answer
+
+func (h *PublicEstimateHandler) ViewEstimate(w http.ResponseWriter, r *http.Request) {
+
+	public, err := h.estimateService.ViewEstimate(chi.URLParam(r, "token"))
+
+	if err != nil {
+		writePublicEstimateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	view := &publicEstimateView{
+		Estimate:     public.Estimate,
+		BusinessName: public.Business.DisplayName(),
+		Business:     public.Business,
+		BasePath:     strings.TrimSuffix(r.URL.Path, "/"),
+	}
+
+	if err := publicEstimatePage.Execute(w, view); err != nil {
+		log.Printf("public estimate: rendering page: %v", err)
+	}
+
+}
+
+// pdf download through the link
+
+func (h *PublicEstimateHandler) DownloadPDF(w http.ResponseWriter, r *http.Request) {
+
+	pdfBytes, estimateNumber, err := h.estimateService.DownloadEstimatePDF(chi.URLParam(r, "token"))
+
+	if err != nil {
+		writePublicEstimateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+estimateNumber+".pdf")
+	w.Header().Set("Content-length", strconv.Itoa(len(pdfBytes)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdfBytes)
+
+}
+
+// accepting the estimate
+
+func (h *PublicEstimateHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+// declining the estimate
+
+func (h *PublicEstimateHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+// form posts go back to the page , json posts get json back , like the invoice responses
+
+func (h *PublicEstimateHandler) respond(w http.ResponseWriter, r *http.Request, accept bool) {
+
+	token := chi.URLParam(r, "token")
+
+	isForm := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+
+	var req domain.ClientResponseRequest
+
+	if isForm {
+		req.Note = strings.TrimSpace(r.FormValue("note"))
+	} else if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+			return
+		}
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := h.estimateService.Respond(token, accept, req.Note)
+
+	if isForm {
+		if err != nil {
+			writePublicEstimateError(w, err)
+			return
+		}
+
+		http.Redirect(w, r, "/api/v1/public/estimates/"+token, http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		writeEstimateResponseError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Response recorded successfully")
+
+}
+
+// values the page template needs
+
+type publicEstimateView struct {
+	Estimate     *domain.Estimate
+	BusinessName string
+	Business     *domain.User
+	BasePath     string
+}
+
+var publicEstimatePage = template.Must(template.New("estimate").Funcs(publicPageFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Estimate {{.Estimate.EstimateNumber}} from {{.BusinessName}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; color: #222; max-width: 820px; margin: 32px auto; padding: 0 16px; }
+h1 { color: #0066cc; margin-bottom: 4px; }
+table { width: 100%; border-collapse: collapse; margin: 16px 0; }
+th, td { padding: 8px; border-bottom: 1px solid #ddd; text-align: left; }
+th { background: #c8dcff; }
+td.num, th.num { text-align: right; }
+.meta td { border: none; padding: 2px 8px 2px 0; }
+.totals { width: 320px; margin-left: auto; }
+.notice { padding: 12px; border-radius: 4px; background: #eef6ee; margin: 16px 0; }
+.notice.declined { background: #fdeeee; }
+form { display: inline-block; margin-right: 12px; vertical-align: top; }
+textarea { width: 320px; height: 60px; display: block; margin-bottom: 6px; }
+</style>
+</head>
+<body>
+<h1>ESTIMATE</h1>
+<p><strong>{{.BusinessName}}</strong>{{with .Business.BusinessAddress}}<br>{{.}}{{end}}{{with .Business.BusinessEmail}}<br>{{.}}{{end}}{{with .Business.BusinessPhone}}<br>{{.}}{{end}}</p>
+
+<table class="meta">
+<tr><td>Estimate number</td><td>{{.Estimate.EstimateNumber}}</td></tr>
+<tr><td>Issue date</td><td>{{date .Estimate.IssueDate}}</td></tr>
+<tr><td>Valid until</td><td>{{date .Estimate.ExpiryDate}}</td></tr>
+<tr><td>Status</td><td>{{.Estimate.Status}}</td></tr>
+{{with .Estimate.SellerGSTIN}}<tr><td>GSTIN</td><td>{{.}}</td></tr>{{end}}
+{{with .Estimate.BuyerGSTIN}}<tr><td>Buyer GSTIN</td><td>{{.}}</td></tr>{{end}}
+{{with .Estimate.PlaceOfSupply}}<tr><td>Place of supply</td><td>{{.}}</td></tr>{{end}}
+</table>
+
+{{with .Estimate.Client}}<p>Prepared for:<br><strong>{{.Name}}</strong>{{with .CompanyName}}<br>{{.}}{{end}}{{with .AddressLine1}}<br>{{.}}{{end}}{{with .AddressLine2}}<br>{{.}}{{end}}</p>{{end}}
+
+<table>
+<tr><th>Description</th><th class="num">Quantity</th><th class="num">Unit price</th><th class="num">Discount</th><th class="num">Tax</th><th class="num">Amount</th></tr>
+{{range .Estimate.Items}}<tr><td>{{.Description}}</td><td class="num">{{qty .Quantity}}</td><td class="num">{{price $.Estimate.Currency .UnitPrice}}</td><td class="num">{{if not .DiscountAmount.IsZero}}-{{money $.Estimate.Currency .DiscountAmount}}{{end}}</td><td class="num">{{qty .TaxRate}}%</td><td class="num">{{money $.Estimate.Currency .Amount}}</td></tr>
+{{end}}</table>
+
+<table class="totals">
+<tr><td>Subtotal</td><td class="num">{{money .Estimate.Currency .Estimate.Subtotal}}</td></tr>
+{{if eq .Estimate.TaxScheme "gst"}}{{if not .Estimate.CGSTAmount.IsZero}}<tr><td>CGST</td><td class="num">{{money .Estimate.Currency .Estimate.CGSTAmount}}</td></tr>
+<tr><td>SGST</td><td class="num">{{money .Estimate.Currency .Estimate.SGSTAmount}}</td></tr>{{end}}
+{{if not .Estimate.IGSTAmount.IsZero}}<tr><td>IGST</td><td class="num">{{money .Estimate.Currency .Estimate.IGSTAmount}}</td></tr>{{end}}
+{{else}}{{range .Estimate.TaxSummary}}{{if not .TaxAmount.IsZero}}<tr><td>Tax ({{qty .Rate}}%)</td><td class="num">{{money $.Estimate.Currency .TaxAmount}}</td></tr>{{end}}
+{{end}}{{end}}
+{{if not .Estimate.DiscountAmount.IsZero}}<tr><td>Discount</td><td class="num">-{{money .Estimate.Currency .Estimate.DiscountAmount}}</td></tr>{{end}}
+<tr><td><strong>Total</strong></td><td class="num"><strong>{{.Estimate.Currency}} {{money .Estimate.Currency .Estimate.TotalAmount}}</strong></td></tr>
+</table>
+
+{{with .Estimate.Notes}}<p><strong>Notes</strong><br>{{.}}</p>{{end}}
+{{with .Estimate.TermsAndConditions}}<p><strong>Terms and conditions</strong><br>{{.}}</p>{{end}}
+
+<p><a href="{{.BasePath}}/pdf">Download PDF</a></p>
+
+{{if eq .Estimate.Status "sent"}}
+<form method="post" action="{{.BasePath}}/accept">
+<textarea name="note" maxlength="1000" placeholder="Optional note"></textarea>
+<button type="submit">Accept estimate</button>
+</form>
+<form method="post" action="{{.BasePath}}/decline">
+<textarea name="note" maxlength="1000" placeholder="Anything we should change?"></textarea>
+<button type="submit">Decline estimate</button>
+</form>
+{{else if eq .Estimate.Status "accepted"}}<div class="notice">You accepted this estimate{{with .Estimate.RespondedAt}} on {{date .}}{{end}}.{{with .Estimate.ClientNote}}<br>Note: {{.}}{{end}}</div>
+{{else if eq .Estimate.Status "declined"}}<div class="notice declined">You declined this estimate{{with .Estimate.RespondedAt}} on {{date .}}{{end}}.{{with .Estimate.ClientNote}}<br>Note: {{.}}{{end}}</div>
+{{else}}<div class="notice declined">This estimate has expired. Please ask the sender for a new one.</div>
+{{end}}
+</body>
+</html>
+`))
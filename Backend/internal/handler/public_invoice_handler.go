@@ -0,0 +1,389 @@
+// public invoice handler - share links , the owner endpoints and the client facing pages (no auth)
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type PublicInvoiceHandler struct {
+	shareLinkService *service.ShareLinkService
+}
+
+// public invoice handler function
+
+func NewPublicInvoiceHandler(shareLinkService *service.ShareLinkService) *PublicInvoiceHandler {
+	return &PublicInvoiceHandler{shareLinkService: shareLinkService}
+}
+
+// mapping share link errors to status codes
+
+func writeShareLinkError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrInvoiceNotFound), errors.Is(err, domain.ErrShareLinkNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrShareLinkExpired):
+		util.WriteError(w, http.StatusGone, err)
+	case errors.Is(err, domain.ErrInvoiceNotShareable):
+		util.WriteError(w, http.StatusConflict, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// OWNER ENDPOINTS
+
+// creating a share link for an invoice
+
+func (h *PublicInvoiceHandler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	var req domain.CreateShareLinkRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+			return
+		}
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	link, err := h.shareLinkService.CreateLink(claims.UserID, invoiceID, &req)
+
+	if err != nil {
+		writeShareLinkError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, link, "Share link created successfully")
+
+}
+
+// listing share links of an invoice
+
+func (h *PublicInvoiceHandler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	links, err := h.shareLinkService.ListLinks(claims.UserID, invoiceID)
+
+	if err != nil {
+		writeShareLinkError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, links, "Share links retrieved successfully")
+
+}
+
+// revoking a share link
+
+func (h *PublicInvoiceHandler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	linkID, err := uuid.Parse(chi.URLParam(r, "linkID"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid share link ID"))
+		return
+	}
+
+	if err := h.shareLinkService.RevokeLink(claims.UserID, invoiceID, linkID); err != nil {
+		writeShareLinkError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Share link revoked successfully")
+
+}
+
+// CLIENT FACING ENDPOINTS
+
+// plain error page for the browser
+
+func writePublicError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrShareLinkNotFound), errors.Is(err, domain.ErrInvoiceNotFound):
+		http.Error(w, "This invoice link is not valid.", http.StatusNotFound)
+	case errors.Is(err, domain.ErrShareLinkExpired):
+		http.Error(w, "This invoice link has expired. Please ask the sender for a new one.", http.StatusGone)
+	default:
+		log.Printf("public invoice: %v", err)
+		http.Error(w, "Something went wrong, please try again later.", http.StatusInternalServerError)
+	}
+}
+
+// read only html page of the invoice
+
+func (h *PublicInvoiceHandler) ViewInvoice(w http.ResponseWriter, r *http.Request) {
+
+	token := chi.URLParam(r, "token")
+
+	public, err := h.shareLinkService.ViewInvoice(token, clientIP(r), r.UserAgent())
+
+	if err != nil {
+		writePublicError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	if err := publicInvoicePage.Execute(w, publicInvoicePageData(public, r.URL.Path)); err != nil {
+		log.Printf("public invoice: rendering page: %v", err)
+	}
+
+}
+
+// pdf download through the link
+
+func (h *PublicInvoiceHandler) DownloadPDF(w http.ResponseWriter, r *http.Request) {
+
+	token := chi.URLParam(r, "token")
+
+	pdfBytes, invoiceNumber, err := h.shareLinkService.DownloadPDF(token, clientIP(r), r.UserAgent())
+
+	if err != nil {
+		writePublicError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+invoiceNumber+".pdf")
+	w.Header().Set("Content-length", strconv.Itoa(len(pdfBytes)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdfBytes)
+
+}
+
+// acknowledging the invoice
+
+func (h *PublicInvoiceHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+// disputing the invoice
+
+func (h *PublicInvoiceHandler) Dispute(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+// the page posts a form and is sent back to the invoice , api callers post json and get json back
+
+func (h *PublicInvoiceHandler) respond(w http.ResponseWriter, r *http.Request, dispute bool) {
+
+	token := chi.URLParam(r, "token")
+
+	isForm := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+
+	var req domain.ClientResponseRequest
+
+	if isForm {
+		req.Note = strings.TrimSpace(r.FormValue("note"))
+	} else if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+			return
+		}
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := h.shareLinkService.Respond(token, dispute, req.Note, clientIP(r), r.UserAgent())
+
+	if isForm {
+		if err != nil {
+			writePublicError(w, err)
+			return
+		}
+
+		http.Redirect(w, r, "/api/v1/public/invoices/"+token, http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		writeShareLinkError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Response recorded successfully")
+
+}
+
+// values the page template needs
+
+type publicInvoiceView struct {
+	Invoice      *domain.Invoice
+	BusinessName string
+	Business     *domain.User
+	Response     *domain.InvoiceEvent
+	Disputed     bool
+	BasePath     string
+}
+
+func publicInvoicePageData(public *domain.PublicInvoice, path string) *publicInvoiceView {
+
+	return &publicInvoiceView{
+		Invoice:      public.Invoice,
+		BusinessName: public.Business.DisplayName(),
+		Business:     public.Business,
+		Response:     public.Response,
+		Disputed:     public.Response != nil && public.Response.Type == domain.InvoiceEventClientDisputed,
+		BasePath:     strings.TrimSuffix(path, "/"),
+	}
+}
+
+// formatting helpers of the client facing pages
+
+var publicPageFuncs = template.FuncMap{
+	"money": func(currency string, v money.Decimal) string { return v.StringFixed(money.MinorUnits(currency)) },
+	"price": func(currency string, v money.Decimal) string {
+		return v.StringFixed(max(money.MinorUnits(currency), v.Places()))
+	},
+	"qty":  func(v money.Decimal) string { return v.String() },
+	"date": func(v time.Time) string { return v.Format("January 2, 2006") },
+}
+
+var publicInvoicePage = template.Must(template.New("invoice").Funcs(publicPageFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Invoice {{.Invoice.InvoiceNumber}} from {{.BusinessName}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; color: #222; max-width: 820px; margin: 32px auto; padding: 0 16px; }
+h1 { color: #0066cc; margin-bottom: 4px; }
+table { width: 100%; border-collapse: collapse; margin: 16px 0; }
+th, td { padding: 8px; border-bottom: 1px solid #ddd; text-align: left; }
+th { background: #c8dcff; }
+td.num, th.num { text-align: right; }
+.meta td { border: none; padding: 2px 8px 2px 0; }
+.totals { width: 320px; margin-left: auto; }
+.notice { padding: 12px; border-radius: 4px; background: #eef6ee; margin: 16px 0; }
+.notice.disputed { background: #fdeeee; }
+form { display: inline-block; margin-right: 12px; vertical-align: top; }
+textarea { width: 320px; height: 60px; display: block; margin-bottom: 6px; }
+</style>
+</head>
+<body>
+<h1>{{if eq .Invoice.TaxScheme "gst"}}TAX INVOICE{{else}}INVOICE{{end}}</h1>
+<p><strong>{{.BusinessName}}</strong>{{with .Business.BusinessAddress}}<br>{{.}}{{end}}{{with .Business.BusinessEmail}}<br>{{.}}{{end}}{{with .Business.BusinessPhone}}<br>{{.}}{{end}}</p>
+
+<table class="meta">
+<tr><td>Invoice number</td><td>{{.Invoice.InvoiceNumber}}</td></tr>
+<tr><td>Issue date</td><td>{{date .Invoice.IssueDate}}</td></tr>
+<tr><td>Due date</td><td>{{date .Invoice.DueDate}}</td></tr>
+<tr><td>Status</td><td>{{.Invoice.Status}}</td></tr>
+{{with .Invoice.SellerGSTIN}}<tr><td>GSTIN</td><td>{{.}}</td></tr>{{end}}
+{{with .Invoice.BuyerGSTIN}}<tr><td>Buyer GSTIN</td><td>{{.}}</td></tr>{{end}}
+{{with .Invoice.PlaceOfSupply}}<tr><td>Place of supply</td><td>{{.}}</td></tr>{{end}}
+</table>
+
+{{with .Invoice.Client}}<p>Bill to:<br><strong>{{.Name}}</strong>{{with .CompanyName}}<br>{{.}}{{end}}{{with .AddressLine1}}<br>{{.}}{{end}}{{with .AddressLine2}}<br>{{.}}{{end}}</p>{{end}}
+
+<table>
+<tr><th>Description</th><th class="num">Quantity</th><th class="num">Unit price</th><th class="num">Discount</th><th class="num">Tax</th><th class="num">Amount</th></tr>
+{{range .Invoice.Items}}<tr><td>{{.Description}}</td><td class="num">{{qty .Quantity}}</td><td class="num">{{price $.Invoice.Currency .UnitPrice}}</td><td class="num">{{if not .DiscountAmount.IsZero}}-{{money $.Invoice.Currency .DiscountAmount}}{{end}}</td><td class="num">{{qty .TaxRate}}%</td><td class="num">{{money $.Invoice.Currency .Amount}}</td></tr>
+{{end}}</table>
+
+<table class="totals">
+<tr><td>Subtotal</td><td class="num">{{money .Invoice.Currency .Invoice.Subtotal}}</td></tr>
+{{if eq .Invoice.TaxScheme "gst"}}{{if not .Invoice.CGSTAmount.IsZero}}<tr><td>CGST</td><td class="num">{{money .Invoice.Currency .Invoice.CGSTAmount}}</td></tr>
+<tr><td>SGST</td><td class="num">{{money .Invoice.Currency .Invoice.SGSTAmount}}</td></tr>{{end}}
+{{if not .Invoice.IGSTAmount.IsZero}}<tr><td>IGST</td><td class="num">{{money .Invoice.Currency .Invoice.IGSTAmount}}</td></tr>{{end}}
+{{else}}{{range .Invoice.TaxSummary}}{{if not .TaxAmount.IsZero}}<tr><td>Tax ({{qty .Rate}}%)</td><td class="num">{{money $.Invoice.Currency .TaxAmount}}</td></tr>{{end}}
+{{end}}{{end}}
+{{if not .Invoice.DiscountAmount.IsZero}}<tr><td>Discount</td><td class="num">-{{money .Invoice.Currency .Invoice.DiscountAmount}}</td></tr>{{end}}
+<tr><td><strong>Total</strong></td><td class="num"><strong>{{.Invoice.Currency}} {{money .Invoice.Currency .Invoice.TotalAmount}}</strong></td></tr>
+{{if not .Invoice.AmountPaid.IsZero}}<tr><td>Paid</td><td class="num">{{money .Invoice.Currency .Invoice.AmountPaid}}</td></tr>{{end}}
+{{if not .Invoice.AmountCredited.IsZero}}<tr><td>Credited</td><td class="num">-{{money .Invoice.Currency .Invoice.AmountCredited}}</td></tr>{{end}}
+<tr><td><strong>Balance due</strong></td><td class="num"><strong>{{.Invoice.Currency}} {{money .Invoice.Currency .Invoice.BalanceDue}}</strong></td></tr>
+</table>
+
+{{with .Invoice.Notes}}<p><strong>Notes</strong><br>{{.}}</p>{{end}}
+{{with .Invoice.TermsAndConditions}}<p><strong>Terms and conditions</strong><br>{{.}}</p>{{end}}
+
+<p><a href="{{.BasePath}}/pdf">Download PDF</a></p>
+
+{{with .Response}}<div class="notice{{if $.Disputed}} disputed{{end}}">
+{{if $.Disputed}}You disputed this invoice on {{date .OccurredAt}}.{{else}}You acknowledged this invoice on {{date .OccurredAt}}.{{end}}
+{{with .Details}}<br>Note: {{.}}{{end}}
+</div>{{end}}
+
+<form method="post" action="{{.BasePath}}/acknowledge">
+<textarea name="note" maxlength="1000" placeholder="Optional note"></textarea>
+<button type="submit">Acknowledge invoice</button>
+</form>
+<form method="post" action="{{.BasePath}}/dispute">
+<textarea name="note" maxlength="1000" placeholder="What is wrong with this invoice?"></textarea>
+<button type="submit">Dispute invoice</button>
+</form>
+</body>
+</html>
+`))
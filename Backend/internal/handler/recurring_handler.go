@@ -0,0 +1,190 @@
+// recurring schedule handler
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type RecurringHandler struct {
+	recurringService *service.RecurringService
+}
+
+// recurring handler function
+
+func NewRecurringHandler(recurringService *service.RecurringService) *RecurringHandler {
+	return &RecurringHandler{recurringService: recurringService}
+}
+
+// mapping schedule errors to status codes
+
+func writeScheduleError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrScheduleNotFound), errors.Is(err, domain.ErrInvoiceNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrInvalidInput):
+		util.WriteError(w, http.StatusBadRequest, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// creating a recurring schedule
+
+func (h *RecurringHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.CreateRecurringScheduleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	// validating the input
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	schedule, err := h.recurringService.CreateSchedule(claims.UserID, &req)
+
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, schedule, "Recurring schedule created successfully")
+
+}
+
+// listing recurring schedules
+
+func (h *RecurringHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	schedules, err := h.recurringService.ListSchedules(claims.UserID)
+
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, schedules, "Recurring schedules retrieved successfully")
+
+}
+
+// getting one recurring schedule
+
+func (h *RecurringHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid schedule ID"))
+		return
+	}
+
+	schedule, err := h.recurringService.GetSchedule(claims.UserID, scheduleID)
+
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, schedule, "Recurring schedule retrieved successfully")
+
+}
+
+// updating a recurring schedule
+
+func (h *RecurringHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid schedule ID"))
+		return
+	}
+
+	var req domain.UpdateRecurringScheduleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	schedule, err := h.recurringService.UpdateSchedule(claims.UserID, scheduleID, &req)
+
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, schedule, "Recurring schedule updated successfully")
+
+}
+
+// deleting a recurring schedule
+
+func (h *RecurringHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid schedule ID"))
+		return
+	}
+
+	if err := h.recurringService.DeleteSchedule(claims.UserID, scheduleID); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Recurring schedule deleted successfully")
+
+}
@@ -0,0 +1,317 @@
+// reminder handler - templates , the reminder sequence , pausing and the per invoice log
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ReminderHandler struct {
+	reminderService *service.ReminderService
+}
+
+// reminder handler function
+
+func NewReminderHandler(reminderService *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{reminderService: reminderService}
+}
+
+// mapping reminder errors to status codes
+
+func writeReminderError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrReminderTemplateNotFound), errors.Is(err, domain.ErrInvoiceNotFound), errors.Is(err, domain.ErrClientNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrInvalidInput):
+		util.WriteError(w, http.StatusBadRequest, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// listing reminder templates
+
+func (h *ReminderHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	templates, err := h.reminderService.ListTemplates(claims.UserID)
+
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, templates, "Reminder templates retrieved successfully")
+
+}
+
+// creating a reminder template
+
+func (h *ReminderHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.ReminderTemplateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.reminderService.CreateTemplate(claims.UserID, &req)
+
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusCreated, template, "Reminder template created successfully")
+
+}
+
+// updating a reminder template
+
+func (h *ReminderHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid template ID"))
+		return
+	}
+
+	var req domain.ReminderTemplateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.reminderService.UpdateTemplate(claims.UserID, templateID, &req)
+
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, template, "Reminder template updated successfully")
+
+}
+
+// deleting a reminder template
+
+func (h *ReminderHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid template ID"))
+		return
+	}
+
+	if err := h.reminderService.DeleteTemplate(claims.UserID, templateID); err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Reminder template deleted successfully")
+
+}
+
+// getting the reminder sequence
+
+func (h *ReminderHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	rules, err := h.reminderService.GetRules(claims.UserID)
+
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, rules, "Reminder rules retrieved successfully")
+
+}
+
+// replacing the reminder sequence
+
+func (h *ReminderHandler) UpdateRules(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.UpdateReminderRulesRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rules, err := h.reminderService.ReplaceRules(claims.UserID, &req)
+
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, rules, "Reminder rules updated successfully")
+
+}
+
+// pausing or resuming reminders of one invoice
+
+func (h *ReminderHandler) PauseInvoiceReminders(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	var req domain.PauseRemindersRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := h.reminderService.SetInvoicePaused(claims.UserID, invoiceID, req.Paused); err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, req, "Invoice reminders updated successfully")
+
+}
+
+// reminders sent (or tried) for one invoice
+
+func (h *ReminderHandler) ListInvoiceReminders(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	invoiceID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid invoice ID"))
+		return
+	}
+
+	reminders, err := h.reminderService.ListInvoiceReminders(claims.UserID, invoiceID)
+
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, reminders, "Invoice reminders retrieved successfully")
+
+}
+
+// pausing or resuming reminders for all invoices of a client
+
+func (h *ReminderHandler) PauseClientReminders(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	clientID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid client ID"))
+		return
+	}
+
+	var req domain.PauseRemindersRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := h.reminderService.SetClientPaused(claims.UserID, clientID, req.Paused); err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, req, "Client reminders updated successfully")
+
+}
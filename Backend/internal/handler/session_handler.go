@@ -0,0 +1,130 @@
+// session handler - logout , the user's sessions and signing them out
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// session handler function
+
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// logging out the session the token belongs to
+
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	err := h.sessionService.RevokeSession(claims.UserID, claims.SessionID)
+
+	if err != nil && !errors.Is(err, domain.ErrSessionNotFound) {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Logged out successfully")
+
+}
+
+// active sessions of the user , the current one is marked
+
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(claims.UserID, claims.SessionID)
+
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, sessions, "Sessions retrieved successfully")
+
+}
+
+// signing out one session
+
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, errors.New("invalid session ID"))
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(claims.UserID, sessionID); err != nil {
+
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			util.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Session revoked successfully")
+
+}
+
+// log out everywhere , ?keep_current=true signs out only the other sessions
+
+func (h *SessionHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	keep := uuid.Nil
+
+	if r.URL.Query().Get("keep_current") == "true" {
+		keep = claims.SessionID
+	}
+
+	n, err := h.sessionService.RevokeAllSessions(claims.UserID, keep)
+
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, map[string]int{"revoked": n}, "Sessions revoked successfully")
+
+}
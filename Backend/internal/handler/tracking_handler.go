@@ -0,0 +1,63 @@
+// tracking handler - public endpoints hit by email clients , no auth
+
+package handler
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// transparent 1x1 gif
+
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+type TrackingHandler struct {
+	trackingService *service.TrackingService
+}
+
+// tracking handler function
+
+func NewTrackingHandler(trackingService *service.TrackingService) *TrackingHandler {
+	return &TrackingHandler{trackingService: trackingService}
+}
+
+// recording an email open and serving the pixel
+// the pixel is served for bad tokens too , so the response tells nothing about the token
+
+func (h *TrackingHandler) TrackOpen(w http.ResponseWriter, r *http.Request) {
+
+	err := h.trackingService.RecordOpen(chi.URLParam(r, "token"), clientIP(r), r.UserAgent())
+
+	if err != nil && !errors.Is(err, domain.ErrInvalidToken) && !errors.Is(err, domain.ErrInvoiceNotFound) {
+		log.Printf("tracking: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Content-Length", strconv.Itoa(len(trackingPixel)))
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	w.WriteHeader(http.StatusOK)
+	w.Write(trackingPixel)
+
+}
+
+// caller ip , RealIP middleware has already applied the proxy headers to RemoteAddr
+
+func clientIP(r *http.Request) string {
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
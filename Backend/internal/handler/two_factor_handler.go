@@ -0,0 +1,162 @@
+// two-factor handler - setting up the authenticator app , recovery codes and turning it off
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/middleware"
+	"github.com/Suthar345Piyush/invoicego/internal/service"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+}
+
+// two-factor handler function
+
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// mapping two-factor errors to status codes
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, domain.ErrTwoFactorAlreadyEnabled), errors.Is(err, domain.ErrTwoFactorNotEnabled), errors.Is(err, domain.ErrTwoFactorNotSetUp):
+		util.WriteError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrIncorrectPassword):
+		util.WriteError(w, http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrUserNotFound):
+		util.WriteError(w, http.StatusNotFound, err)
+	default:
+		util.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// starting setup , the answer has the secret and the uri for the qr code
+
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(claims.UserID)
+
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, setup, "Two-factor setup started, confirm it with a code from the app")
+
+}
+
+// confirming setup with a code , the recovery codes come back once
+
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.TwoFactorCodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(claims.UserID, req.Code)
+
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, codes, "Two-factor authentication enabled successfully")
+
+}
+
+// turning two-factor off
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.DisableTwoFactorRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(claims.UserID, &req); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, nil, "Two-factor authentication disabled successfully")
+
+}
+
+// new recovery codes , the old ones stop working
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.TwoFactorCodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(claims.UserID, req.Code)
+
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, codes, "Recovery codes regenerated successfully")
+
+}
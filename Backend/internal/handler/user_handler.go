@@ -3,9 +3,11 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
 	"github.com/Suthar345Piyush/invoicego/internal/middleware"
 	"github.com/Suthar345Piyush/invoicego/internal/service"
 	"github.com/Suthar345Piyush/invoicego/internal/util"
@@ -47,3 +49,37 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 	util.WriteSuccess(w, http.StatusOK, user, "User retrieved successfully")
 
 }
+
+// function to update the user's settings
+
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+
+	claims, ok := middleware.GetUserFromContext(r.Context())
+
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	var req domain.UpdateUserRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, domain.ErrInvalidInput)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(claims.UserID, &req)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			util.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		util.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteSuccess(w, http.StatusOK, user, "User updated successfully")
+
+}
@@ -0,0 +1,78 @@
+// file and log backends , for development - nothing leaves the machine
+
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writing every message as an .eml file into a directory
+
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// file mailer function , creating the directory if it's missing
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+
+	if dir == "" {
+		dir = "tmp/mail"
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating mail directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(msg *Message) error {
+
+	if msg.From == "" {
+		msg.From = m.from
+	}
+
+	body, err := msg.Bytes()
+
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), strings.Trim(messageID(msg.From), "<>"))
+
+	return os.WriteFile(filepath.Join(m.dir, name), body, 0o644)
+}
+
+// only logging who the message would have gone to
+
+type LogMailer struct {
+	from string
+}
+
+// log mailer function
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(msg *Message) error {
+
+	if msg.From == "" {
+		msg.From = m.from
+	}
+
+	if _, err := msg.Bytes(); err != nil {
+		return err
+	}
+
+	log.Printf("mail: to=%s subject=%q", strings.Join(msg.recipients(), ","), msg.Subject)
+
+	return nil
+}
@@ -0,0 +1,293 @@
+// mailer - sending emails through a pluggable backend (smtp , file , log)
+
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+)
+
+// one email , body is plain text and HTMLBody an optional html alternative of it
+// Bcc recipients get the message but never appear in its headers
+
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Body        string
+	HTMLBody    string
+	Attachments []*Attachment
+}
+
+// file attached to a message
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// every backend implements this
+
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// creating the mailer selected by MAIL_DRIVER
+
+func New(cfg *config.MailConfig) (Mailer, error) {
+
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	case "file":
+		return NewFileMailer(cfg.Dir, cfg.From)
+	case "log", "":
+		return NewLogMailer(cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", cfg.Driver)
+	}
+}
+
+// all recipients of the message (used for the SMTP envelope)
+
+func (m *Message) recipients() []string {
+
+	all := append([]string{}, m.To...)
+	all = append(all, m.Cc...)
+
+	return append(all, m.Bcc...)
+}
+
+// rendering the message in RFC 5322 format
+
+func (m *Message) Bytes() ([]byte, error) {
+
+	if len(m.To) == 0 {
+		return nil, fmt.Errorf("message has no recipients")
+	}
+
+	for _, addr := range m.recipients() {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", addr, err)
+		}
+	}
+
+	if m.ReplyTo != "" {
+		if _, err := mail.ParseAddress(m.ReplyTo); err != nil {
+			return nil, fmt.Errorf("invalid reply-to %q: %w", m.ReplyTo, err)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	writeHeader(&buf, "From", m.From)
+	writeHeader(&buf, "To", strings.Join(m.To, ", "))
+
+	if len(m.Cc) > 0 {
+		writeHeader(&buf, "Cc", strings.Join(m.Cc, ", "))
+	}
+
+	if m.ReplyTo != "" {
+		writeHeader(&buf, "Reply-To", m.ReplyTo)
+	}
+
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID(m.From))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	contentHeader, content, err := m.content()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// message without attachments is just its content
+
+	if len(m.Attachments) == 0 {
+		writeHeader(&buf, "Content-Type", contentHeader.Get("Content-Type"))
+
+		if cte := contentHeader.Get("Content-Transfer-Encoding"); cte != "" {
+			writeHeader(&buf, "Content-Transfer-Encoding", cte)
+		}
+
+		buf.WriteString("\r\n")
+		buf.Write(content)
+
+		return buf.Bytes(), nil
+	}
+
+	// multipart/mixed - the content first , then every attachment base64 encoded
+
+	mw := multipart.NewWriter(&buf)
+
+	writeHeader(&buf, "Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	contentPart, err := mw.CreatePart(contentHeader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := contentPart.Write(content); err != nil {
+		return nil, err
+	}
+
+	for _, a := range m.Attachments {
+
+		contentType := a.ContentType
+
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		filename := mime.QEncoding.Encode("utf-8", a.Filename)
+
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, filename)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", filename)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// body of the message - plain text , or text and html as multipart/alternative when there is an html body
+
+func (m *Message) content() (textproto.MIMEHeader, []byte, error) {
+
+	var buf bytes.Buffer
+
+	if m.HTMLBody == "" {
+		if err := writeQuotedPrintable(&buf, m.Body); err != nil {
+			return nil, nil, err
+		}
+
+		return textproto.MIMEHeader{
+			"Content-Type":              {"text/plain; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		}, buf.Bytes(), nil
+	}
+
+	alt := multipart.NewWriter(&buf)
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", m.Body},
+		{"text/html; charset=utf-8", m.HTMLBody},
+	}
+
+	for _, p := range parts {
+
+		part, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := writeQuotedPrintable(part, p.body); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := alt.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alt.Boundary()},
+	}, buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+
+	// header values can't carry line breaks
+
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+
+	qp := quotedprintable.NewWriter(w)
+
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+// base64 in 76 character lines , as required for mail bodies
+
+func writeBase64(w io.Writer, data []byte) error {
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+
+		encoded = encoded[76:]
+	}
+
+	_, err := io.WriteString(w, encoded+"\r\n")
+
+	return err
+}
+
+// unique message id on the sender's domain
+
+func messageID(from string) string {
+
+	domain := "localhost"
+
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+
+	b := make([]byte, 12)
+	rand.Read(b)
+
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
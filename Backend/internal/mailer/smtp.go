@@ -0,0 +1,69 @@
+// smtp backend , works with a real provider or a local stand-in like MailHog (see docker-compose.yml)
+
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+)
+
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// smtp mailer function
+
+func NewSMTPMailer(cfg *config.MailConfig) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(cfg.Host, cfg.Port),
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     cfg.From,
+	}
+}
+
+// sending through the smtp server , STARTTLS is used when the server offers it
+// auth is skipped when no username is configured (local stand-ins don't need it)
+
+func (m *SMTPMailer) Send(msg *Message) error {
+
+	if msg.From == "" {
+		msg.From = m.from
+	}
+
+	body, err := msg.Bytes()
+
+	if err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(msg.From)
+
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", msg.From, err)
+	}
+
+	var auth smtp.Auth
+
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	recipients := []string{}
+
+	for _, addr := range msg.recipients() {
+		parsed, _ := mail.ParseAddress(addr)
+		recipients = append(recipients, parsed.Address)
+	}
+
+	return smtp.SendMail(m.addr, auth, sender.Address, recipients, body)
+}
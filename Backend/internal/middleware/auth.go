@@ -9,16 +9,23 @@ import (
 
 	"github.com/Suthar345Piyush/invoicego/internal/domain"
 	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
 )
 
 type contextKey string
 
 const UserContextKey contextKey = "user"
 
+// denylist of signed out sessions , checked in memory on every request
+
+type SessionChecker interface {
+	IsRevoked(sessionID uuid.UUID) bool
+}
+
 // auth middleware function having the jwt secret token
 // same pattern taking handler and returning handler
 
-func Auth(jwtSecret string) func(http.Handler) http.Handler {
+func Auth(jwtSecret string, sessions SessionChecker) func(http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 
@@ -42,13 +49,22 @@ func Auth(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 
+			// only access tokens , a refresh token sent here is rejected
+
 			token := parts[1]
-			claims, err := util.ValidateToken(token, jwtSecret)
+			claims, err := util.ValidateAccessToken(token, jwtSecret)
 			if err != nil {
 				util.WriteError(w, http.StatusUnauthorized, domain.ErrInvalidToken)
 				return
 			}
 
+			// tokens from before sessions have no sid , they need a refresh
+
+			if claims.SessionID == uuid.Nil || sessions.IsRevoked(claims.SessionID) {
+				util.WriteError(w, http.StatusUnauthorized, domain.ErrInvalidToken)
+				return
+			}
+
 			// adding claims into context
 
 			ctx := context.WithValue(r.Context(), UserContextKey, claims)
@@ -0,0 +1,36 @@
+// currencies - how many decimal places (minor units) each currency uses
+
+package money
+
+import "strings"
+
+// iso 4217 currencies without the usual two decimal places
+
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// decimal places of the currency , 2 unless it's listed above
+
+func MinorUnits(currency string) int {
+
+	if places, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return places
+	}
+
+	return 2
+}
+
+// rounding an amount to the currency's minor units
+
+func (d Decimal) RoundTo(currency string, mode RoundingMode) Decimal {
+	return d.Round(MinorUnits(currency), mode)
+}
+
+// whether the amount fits the currency's minor units without rounding
+
+func (d Decimal) FitsCurrency(currency string) bool {
+	return d.Places() <= MinorUnits(currency)
+}
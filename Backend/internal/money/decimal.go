@@ -0,0 +1,364 @@
+// money - exact decimal amounts , quantities and rates
+// a Decimal is a whole number of ten-thousandths , so adding amounts is exact and rounding only happens where it's asked for
+
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimal places a Decimal keeps
+
+const Scale = 4
+
+const one = 10000
+
+type Decimal struct {
+	units int64
+}
+
+var Zero = Decimal{}
+
+// how a value exactly halfway between two results is rounded
+// half up goes away from zero (0.125 -> 0.13 , -0.125 -> -0.13) , half even goes to the even digit (0.125 -> 0.12 , 0.135 -> 0.14)
+
+type RoundingMode string
+
+const (
+	HalfUp   RoundingMode = "half_up"
+	HalfEven RoundingMode = "half_even"
+)
+
+func (m RoundingMode) Valid() bool {
+	return m == HalfUp || m == HalfEven
+}
+
+// whole number
+
+func FromInt(n int64) Decimal {
+	return Decimal{units: n * one}
+}
+
+// parsing "12.5" , "-0.0001" or "1e3" , values with more than Scale decimal places are an error
+
+func Parse(s string) (Decimal, error) {
+
+	s = strings.TrimSpace(s)
+
+	if s == "" || strings.Contains(s, "/") {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+
+	if !ok {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt64(one))
+
+	if !r.IsInt() {
+		return Zero, fmt.Errorf("decimal %q has more than %d decimal places", s, Scale)
+	}
+
+	if !r.Num().IsInt64() || r.Num().CmpAbs(big.NewInt(maxUnits)) > 0 {
+		return Zero, fmt.Errorf("decimal %q is out of range", s)
+	}
+
+	return Decimal{units: r.Num().Int64()}, nil
+}
+
+// values are kept well under the int64 limit , so sums of many of them still fit
+
+const maxUnits = 1e17
+
+// a product or share too big for a Decimal , Parse's limit applies to results too
+
+var ErrOutOfRange = errors.New("decimal out of range")
+
+// whether d is within the range Parse accepts
+// adding two in range values can't wrap , so checking a running sum after every Add keeps it exact
+
+func (d Decimal) InRange() bool {
+	return d.units >= -maxUnits && d.units <= maxUnits
+}
+
+// parsing a value known to be valid , panics otherwise
+
+func MustParse(s string) Decimal {
+
+	d, err := Parse(s)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (d Decimal) Add(o Decimal) Decimal { return Decimal{units: d.units + o.units} }
+func (d Decimal) Sub(o Decimal) Decimal { return Decimal{units: d.units - o.units} }
+func (d Decimal) Neg() Decimal          { return Decimal{units: -d.units} }
+
+// -1 , 0 or 1 as d is less than , equal to or greater than o
+
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.units < o.units:
+		return -1
+	case d.units > o.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (d Decimal) Sign() int                  { return d.Cmp(Zero) }
+func (d Decimal) IsZero() bool               { return d.units == 0 }
+func (d Decimal) LessThan(o Decimal) bool    { return d.units < o.units }
+func (d Decimal) GreaterThan(o Decimal) bool { return d.units > o.units }
+
+// nearest float , only for places that can't take a Decimal (validation tags)
+
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / one
+}
+
+// decimal places actually used , 0 for whole numbers
+
+func (d Decimal) Places() int {
+
+	places := Scale
+
+	for u := d.units; places > 0 && u%10 == 0; u /= 10 {
+		places--
+	}
+
+	return places
+}
+
+// rounding to the given number of decimal places (0 to Scale)
+
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+
+	if places >= Scale {
+		return d
+	}
+
+	factor := pow10(Scale - places)
+
+	return Decimal{units: roundQuo(big.NewInt(d.units), big.NewInt(factor), mode).Int64() * factor}
+}
+
+// d * o , worked out exactly and rounded once to the given places
+// ErrOutOfRange when the result is too big , like for every operation that can grow a value
+
+func (d Decimal) Mul(o Decimal, places int, mode RoundingMode) (Decimal, error) {
+
+	num := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(o.units))
+
+	return fromQuo(num, big.NewInt(one*one), places, mode)
+}
+
+// rate percent of d , worked out exactly and rounded once to the given places
+
+func (d Decimal) Percent(rate Decimal, places int, mode RoundingMode) (Decimal, error) {
+
+	num := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(rate.units))
+
+	return fromQuo(num, big.NewInt(one*one*100), places, mode)
+}
+
+// d * num / den , worked out exactly and rounded once to the given places
+// used for shares , like the part of a line's amount a partial quantity stands for
+
+func (d Decimal) MulDiv(num, den Decimal, places int, mode RoundingMode) (Decimal, error) {
+
+	n := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(num.units))
+
+	return fromQuo(n, new(big.Int).Mul(big.NewInt(den.units), big.NewInt(one)), places, mode)
+}
+
+// d / n rounded to the given places , n is a whole number other than zero so the result is never bigger than d
+
+func (d Decimal) Div(n int64, places int, mode RoundingMode) Decimal {
+	return Decimal{units: quoUnits(big.NewInt(d.units), big.NewInt(n*one), places, mode).Int64()}
+}
+
+// num / den (a plain value , not in units) rounded to places , ErrOutOfRange when it doesn't fit
+
+func fromQuo(num, den *big.Int, places int, mode RoundingMode) (Decimal, error) {
+
+	units := quoUnits(num, den, places, mode)
+
+	if units.CmpAbs(big.NewInt(maxUnits)) > 0 {
+		return Zero, ErrOutOfRange
+	}
+
+	return Decimal{units: units.Int64()}, nil
+}
+
+// num / den rounded to places , in units
+
+func quoUnits(num, den *big.Int, places int, mode RoundingMode) *big.Int {
+
+	if places > Scale {
+		places = Scale
+	}
+
+	num = new(big.Int).Mul(num, big.NewInt(pow10(places)))
+
+	q := roundQuo(num, den, mode)
+
+	return q.Mul(q, big.NewInt(pow10(Scale-places)))
+}
+
+// num / den rounded to a whole number
+
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// comparing the remainder with half the divisor , as 2|r| against |den|
+
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+
+	cmp := twice.Cmp(new(big.Int).Abs(den))
+
+	away := cmp > 0 || (cmp == 0 && (mode != HalfEven || q.Bit(0) == 1))
+
+	if away {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+func pow10(n int) int64 {
+
+	p := int64(1)
+
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
+
+// shortest form , "12.5" , "-3" , "0.0001"
+
+func (d Decimal) String() string {
+	return d.format(d.Places())
+}
+
+// exactly places decimal places , rounded half up when d has more
+
+func (d Decimal) StringFixed(places int) string {
+
+	if places > Scale {
+		places = Scale
+	}
+
+	if places < 0 {
+		places = 0
+	}
+
+	return d.Round(places, HalfUp).format(places)
+}
+
+func (d Decimal) format(places int) string {
+
+	units := d.units
+	sign := ""
+
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	whole := strconv.FormatInt(units/one, 10)
+
+	if places == 0 {
+		return sign + whole
+	}
+
+	frac := fmt.Sprintf("%04d", units%one)
+
+	return sign + whole + "." + frac[:places]
+}
+
+// JSON
+
+// written as a plain json number , like the float64 fields were
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// a json number or a string holding one
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+
+	data = bytes.TrimSpace(data)
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	value, err := Parse(strings.Trim(string(data), `"`))
+
+	if err != nil {
+		return err
+	}
+
+	*d = value
+
+	return nil
+}
+
+// SQL
+
+// reading NUMERIC / DECIMAL columns , which the driver hands over as text
+
+func (d *Decimal) Scan(src interface{}) error {
+
+	var err error
+
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+	case []byte:
+		*d, err = Parse(string(v))
+	case string:
+		*d, err = Parse(v)
+	case int64:
+		*d = FromInt(v)
+	case float64:
+		*d, err = Parse(strconv.FormatFloat(v, 'f', Scale, 64))
+	default:
+		err = fmt.Errorf("can't scan %T into a decimal", src)
+	}
+
+	return err
+}
+
+// sent to the database as text , postgres casts it to the column's numeric type exactly
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
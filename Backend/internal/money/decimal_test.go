@@ -0,0 +1,283 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// random Decimal for testing/quick , small amounts most of the time and anything up to the range limit otherwise
+
+type anyDecimal struct {
+	Decimal
+}
+
+func (anyDecimal) Generate(r *rand.Rand, size int) reflect.Value {
+
+	var units int64
+
+	switch r.Intn(4) {
+	case 0:
+		units = r.Int63n(1000 * one)
+	case 1:
+		units = r.Int63n(maxUnits + 1)
+	case 2:
+		units = r.Int63n(1e12)
+	default:
+		units = r.Int63n(int64(size)*one + 1)
+	}
+
+	if r.Intn(2) == 0 {
+		units = -units
+	}
+
+	return reflect.ValueOf(anyDecimal{Decimal{units: units}})
+}
+
+// rounding modes and places for testing/quick
+
+type anyRounding struct {
+	places int
+	mode   RoundingMode
+}
+
+func (anyRounding) Generate(r *rand.Rand, size int) reflect.Value {
+
+	mode := HalfUp
+
+	if r.Intn(2) == 0 {
+		mode = HalfEven
+	}
+
+	return reflect.ValueOf(anyRounding{places: r.Intn(Scale + 1), mode: mode})
+}
+
+var quickConfig = &quick.Config{MaxCount: 5000}
+
+func (d Decimal) rat() *big.Rat {
+	return big.NewRat(d.units, one)
+}
+
+// result has to be exact within half of the last place it keeps , and use no more places than asked for
+
+func checkRounded(t *testing.T, got Decimal, exact *big.Rat, places int) bool {
+
+	t.Helper()
+
+	if got.Places() > places {
+		t.Logf("%s has more than %d places", got, places)
+		return false
+	}
+
+	diff := new(big.Rat).Sub(got.rat(), exact)
+	diff.Abs(diff)
+
+	half := big.NewRat(1, 2*pow10(places))
+
+	if diff.Cmp(half) > 0 {
+		t.Logf("%s is more than half a place from %s", got, exact.FloatString(Scale+2))
+		return false
+	}
+
+	return true
+}
+
+// ErrOutOfRange is only right for an exact value that rounds past the range , rounding moves it less than a place
+
+func pastRange(exact *big.Rat, places int) bool {
+
+	limit := new(big.Rat).Sub(big.NewRat(maxUnits, one), big.NewRat(1, pow10(places)))
+
+	return new(big.Rat).Abs(exact).Cmp(limit) > 0
+}
+
+func TestParseStringRoundTrip(t *testing.T) {
+
+	property := func(a anyDecimal) bool {
+
+		parsed, err := Parse(a.String())
+
+		return err == nil && parsed == a.Decimal
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddSub(t *testing.T) {
+
+	property := func(a, b, c anyDecimal) bool {
+
+		sum := a.Add(b.Decimal)
+
+		return sum == b.Add(a.Decimal) &&
+			sum.Add(c.Decimal) == a.Add(b.Add(c.Decimal)) &&
+			sum.Sub(b.Decimal) == a.Decimal &&
+			a.Sub(a.Decimal).IsZero() &&
+			a.Neg().Neg() == a.Decimal
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMul(t *testing.T) {
+
+	property := func(a, b anyDecimal, r anyRounding) bool {
+
+		exact := new(big.Rat).Mul(a.rat(), b.rat())
+
+		got, err := a.Mul(b.Decimal, r.places, r.mode)
+
+		if err != nil {
+			return errors.Is(err, ErrOutOfRange) && pastRange(exact, r.places)
+		}
+
+		return got.InRange() && checkRounded(t, got, exact, r.places)
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPercent(t *testing.T) {
+
+	property := func(a, rate anyDecimal, r anyRounding) bool {
+
+		exact := new(big.Rat).Mul(a.rat(), rate.rat())
+		exact.Quo(exact, big.NewRat(100, 1))
+
+		got, err := a.Percent(rate.Decimal, r.places, r.mode)
+
+		if err != nil {
+			return errors.Is(err, ErrOutOfRange) && pastRange(exact, r.places)
+		}
+
+		return got.InRange() && checkRounded(t, got, exact, r.places)
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+// a share is never more than the whole , so it always fits
+
+func TestMulDivShare(t *testing.T) {
+
+	property := func(a, num, den anyDecimal, r anyRounding) bool {
+
+		if den.IsZero() {
+			return true
+		}
+
+		part, whole := num.Decimal, den.Decimal
+
+		if part.units < 0 {
+			part = part.Neg()
+		}
+
+		if whole.units < 0 {
+			whole = whole.Neg()
+		}
+
+		if part.GreaterThan(whole) {
+			part, whole = whole, part
+		}
+
+		exact := new(big.Rat).Mul(a.rat(), part.rat())
+		exact.Quo(exact, whole.rat())
+
+		got, err := a.MulDiv(part, whole, r.places, r.mode)
+
+		return err == nil && checkRounded(t, got, exact, r.places)
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRoundingModes(t *testing.T) {
+
+	tests := []struct {
+		value  string
+		places int
+		mode   RoundingMode
+		want   string
+	}{
+		{"0.125", 2, HalfUp, "0.13"},
+		{"-0.125", 2, HalfUp, "-0.13"},
+		{"0.125", 2, HalfEven, "0.12"},
+		{"0.135", 2, HalfEven, "0.14"},
+		{"-0.135", 2, HalfEven, "-0.14"},
+		{"2.5", 0, HalfEven, "2"},
+		{"3.5", 0, HalfEven, "4"},
+		{"0.1249", 2, HalfUp, "0.12"},
+		{"1.0001", 4, HalfUp, "1.0001"},
+	}
+
+	for _, tt := range tests {
+		if got := MustParse(tt.value).Round(tt.places, tt.mode).String(); got != tt.want {
+			t.Errorf("%s rounded to %d (%s) = %s , want %s", tt.value, tt.places, tt.mode, got, tt.want)
+		}
+	}
+}
+
+// the float drift the type is there to avoid
+
+func TestNoFloatDrift(t *testing.T) {
+
+	tenth := MustParse("0.1")
+
+	got, err := tenth.Mul(FromInt(3), 2, HalfUp)
+
+	if err != nil || got != MustParse("0.3") {
+		t.Errorf("0.1 * 3 = %s (%v) , want 0.3", got, err)
+	}
+
+	sum := Zero
+
+	for range 10 {
+		sum = sum.Add(tenth)
+	}
+
+	if sum != FromInt(1) {
+		t.Errorf("ten times 0.1 = %s , want 1", sum)
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+
+	huge := MustParse("9999999999999")
+
+	if _, err := huge.Mul(huge, 2, HalfUp); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Mul: got %v , want ErrOutOfRange", err)
+	}
+
+	if _, err := huge.Percent(FromInt(1000), 2, HalfUp); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Percent: got %v , want ErrOutOfRange", err)
+	}
+
+	if _, err := huge.MulDiv(FromInt(1000), FromInt(1), 2, HalfUp); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("MulDiv: got %v , want ErrOutOfRange", err)
+	}
+
+	if _, err := Parse("10000000000001"); err == nil {
+		t.Error("Parse accepted a value past the range")
+	}
+
+	if sum := huge.Add(huge); sum.InRange() {
+		t.Errorf("%s reported in range", sum)
+	}
+
+	if !huge.InRange() || !huge.Neg().InRange() {
+		t.Errorf("%s reported out of range", huge)
+	}
+}
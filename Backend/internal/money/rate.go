@@ -0,0 +1,192 @@
+// exchange rates - how many units of one currency buy one unit of another
+// kept to 10 decimal places , as rates like INR -> USD (0.0120192308) need more than amounts do
+
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const RateScale = 10
+
+const rateOne = 10000000000
+
+type Rate struct {
+	units int64
+}
+
+// the rate between a currency and itself
+
+var One = Rate{units: rateOne}
+
+// parsing "83.2" or "0.0120192308" , rates have to be positive and have at most RateScale decimal places
+
+func ParseRate(s string) (Rate, error) {
+
+	s = strings.TrimSpace(s)
+
+	if s == "" || strings.Contains(s, "/") {
+		return Rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt64(rateOne))
+
+	if !r.IsInt() {
+		return Rate{}, fmt.Errorf("rate %q has more than %d decimal places", s, RateScale)
+	}
+
+	if r.Sign() <= 0 || !r.Num().IsInt64() {
+		return Rate{}, fmt.Errorf("rate %q is out of range", s)
+	}
+
+	return Rate{units: r.Num().Int64()}, nil
+}
+
+func (r Rate) IsZero() bool { return r.units == 0 }
+
+// nearest float , only for validation tags
+
+func (r Rate) Float64() float64 {
+	return float64(r.units) / rateOne
+}
+
+func (r Rate) Cmp(o Rate) int {
+	switch {
+	case r.units < o.units:
+		return -1
+	case r.units > o.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// the rate the other way round (1 / r) , rounded half even to RateScale places
+// ErrOutOfRange when r is so small its inverse doesn't fit
+
+func (r Rate) Inverse() (Rate, error) {
+
+	if r.units == 0 {
+		return r, nil
+	}
+
+	num := new(big.Int).Mul(big.NewInt(rateOne), big.NewInt(rateOne))
+
+	return rateFromUnits(roundQuo(num, big.NewInt(r.units), HalfEven))
+}
+
+// r followed by o , as in EUR -> USD then USD -> INR , rounded half even to RateScale places
+// ErrOutOfRange when the product doesn't fit , or rounds away to nothing
+
+func (r Rate) Times(o Rate) (Rate, error) {
+
+	num := new(big.Int).Mul(big.NewInt(r.units), big.NewInt(o.units))
+
+	return rateFromUnits(roundQuo(num, big.NewInt(rateOne), HalfEven))
+}
+
+// rate with the given units , which have to fit in an int64 and stay positive like a parsed rate
+
+func rateFromUnits(units *big.Int) (Rate, error) {
+
+	if units.Sign() <= 0 || !units.IsInt64() {
+		return Rate{}, ErrOutOfRange
+	}
+
+	return Rate{units: units.Int64()}, nil
+}
+
+// amount converted at the rate , worked out exactly and rounded once to the given places
+// ErrOutOfRange when the converted amount is too big
+
+func (r Rate) Convert(amount Decimal, places int, mode RoundingMode) (Decimal, error) {
+
+	num := new(big.Int).Mul(big.NewInt(amount.units), big.NewInt(r.units))
+
+	return fromQuo(num, new(big.Int).Mul(big.NewInt(one), big.NewInt(rateOne)), places, mode)
+}
+
+// shortest form , "83.2"
+
+func (r Rate) String() string {
+
+	units := r.units
+	places := RateScale
+
+	for places > 0 && units%10 == 0 {
+		units /= 10
+		places--
+	}
+
+	s := strconv.FormatInt(r.units/rateOne, 10)
+
+	if places == 0 {
+		return s
+	}
+
+	frac := fmt.Sprintf("%010d", r.units%rateOne)
+
+	return s + "." + frac[:places]
+}
+
+// JSON , a plain number like Decimal
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+
+	data = bytes.TrimSpace(data)
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	value, err := ParseRate(strings.Trim(string(data), `"`))
+
+	if err != nil {
+		return err
+	}
+
+	*r = value
+
+	return nil
+}
+
+// SQL
+
+func (r *Rate) Scan(src interface{}) error {
+
+	var err error
+
+	switch v := src.(type) {
+	case []byte:
+		*r, err = ParseRate(string(v))
+	case string:
+		*r, err = ParseRate(v)
+	case int64:
+		*r, err = ParseRate(strconv.FormatInt(v, 10))
+	case float64:
+		*r, err = ParseRate(strconv.FormatFloat(v, 'f', RateScale, 64))
+	default:
+		err = fmt.Errorf("can't scan %T into a rate", src)
+	}
+
+	return err
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
@@ -0,0 +1,139 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// random Rate for testing/quick , ordinary rates most of the time and the smallest and biggest ones otherwise
+
+type anyRate struct {
+	Rate
+}
+
+func (anyRate) Generate(r *rand.Rand, size int) reflect.Value {
+
+	var units int64
+
+	switch r.Intn(5) {
+	case 0:
+		units = r.Int63n(1000*rateOne) + 1
+	case 1:
+		units = r.Int63n(100) + 1
+	case 2:
+		units = math.MaxInt64 - r.Int63n(1000)
+	case 3:
+		units = r.Int63n(math.MaxInt64) + 1
+	default:
+		units = rateOne + r.Int63n(int64(size)+1) - int64(size)/2
+	}
+
+	return reflect.ValueOf(anyRate{Rate{units: units}})
+}
+
+func (r Rate) rat() *big.Rat {
+	return big.NewRat(r.units, rateOne)
+}
+
+// a rate result has to be within half of the last place , or ErrOutOfRange when the rounded value doesn't fit
+
+func checkRate(t *testing.T, got Rate, err error, exact *big.Rat) bool {
+
+	t.Helper()
+
+	units := new(big.Rat).Mul(exact, new(big.Rat).SetInt64(rateOne))
+	rounded := roundQuo(units.Num(), units.Denom(), HalfEven)
+
+	if err != nil {
+		return errors.Is(err, ErrOutOfRange) && (rounded.Sign() <= 0 || !rounded.IsInt64())
+	}
+
+	diff := new(big.Rat).Sub(got.rat(), exact)
+	half := big.NewRat(1, 2*rateOne)
+
+	if diff.Abs(diff).Cmp(half) > 0 {
+		t.Logf("%s is more than half a place from %s", got, exact.FloatString(RateScale+2))
+		return false
+	}
+
+	return got.units > 0
+}
+
+func TestRateInverse(t *testing.T) {
+
+	property := func(a anyRate) bool {
+
+		got, err := a.Inverse()
+
+		return checkRate(t, got, err, new(big.Rat).Inv(a.rat()))
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRateTimes(t *testing.T) {
+
+	property := func(a, b anyRate) bool {
+
+		got, err := a.Times(b.Rate)
+
+		return checkRate(t, got, err, new(big.Rat).Mul(a.rat(), b.rat()))
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+// the edges , a rate too small to turn round and products that overflow or round to nothing
+
+func TestRateOutOfRange(t *testing.T) {
+
+	smallest := Rate{units: 1}
+	biggest := Rate{units: math.MaxInt64}
+
+	tests := []struct {
+		name string
+		got  func() (Rate, error)
+		want string
+	}{
+		{"1 / 0.0000000001", smallest.Inverse, ""},
+		{"1 / 0.000000001", Rate{units: 10}.Inverse, ""},
+		{"1 / 0.0000000011", Rate{units: 11}.Inverse, "909090909.0909090909"},
+		{"1 / biggest", biggest.Inverse, "0.0000000011"},
+		{"biggest * biggest", func() (Rate, error) { return biggest.Times(biggest) }, ""},
+		{"biggest * 1.0000000001", func() (Rate, error) { return biggest.Times(Rate{units: rateOne + 1}) }, ""},
+		{"biggest * 1", func() (Rate, error) { return biggest.Times(One) }, biggest.String()},
+		{"smallest * smallest", func() (Rate, error) { return smallest.Times(smallest) }, ""},
+		{"smallest * 0.5", func() (Rate, error) { return smallest.Times(Rate{units: rateOne / 2}) }, ""},
+		{"smallest * 1.5", func() (Rate, error) { return smallest.Times(Rate{units: rateOne + rateOne/2}) }, "0.0000000002"},
+	}
+
+	for _, tt := range tests {
+
+		got, err := tt.got()
+
+		if tt.want == "" {
+			if !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%s = %s (%v) , want ErrOutOfRange", tt.name, got, err)
+			}
+
+			continue
+		}
+
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s = %s (%v) , want %s", tt.name, got, err, tt.want)
+		}
+	}
+
+	if got, err := (Rate{}).Inverse(); err != nil || !got.IsZero() {
+		t.Errorf("inverse of no rate = %s (%v) , want 0", got, err)
+	}
+}
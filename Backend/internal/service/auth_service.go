@@ -1,31 +1,48 @@
-// auth_service - creating new auth service , login and Register the user
+// auth_service - creating new auth service , login and Register the user , refreshing tokens
 
 package service
 
 import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
 	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/database"
 	"github.com/Suthar345Piyush/invoicego/internal/domain"
 	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type AuthService struct {
-	userService *UserService
-	jwtConfig   *config.JWTConfig
+	db                  *database.DB
+	userService         *UserService
+	sessionService      *SessionService
+	verificationService *EmailVerificationService
+	twoFactorService    *TwoFactorService
+	jwtConfig           *config.JWTConfig
 }
 
 // function for new auth service and return auth service
 
-func NewAuthService(userService *UserService, jwtConfig *config.JWTConfig) *AuthService {
+func NewAuthService(db *database.DB, userService *UserService, sessionService *SessionService, verificationService *EmailVerificationService, twoFactorService *TwoFactorService, jwtConfig *config.JWTConfig) *AuthService {
 	return &AuthService{
-		userService: userService,
-		jwtConfig:   jwtConfig,
+		db:                  db,
+		userService:         userService,
+		sessionService:      sessionService,
+		verificationService: verificationService,
+		twoFactorService:    twoFactorService,
+		jwtConfig:           jwtConfig,
 	}
 
 }
 
 // user registration , taking register request as input and returns login response as output
+// meta is where the request came from , it's recorded on the session
 
-func (s *AuthService) Register(req *domain.RegisterRequest) (*domain.LoginResponse, error) {
+func (s *AuthService) Register(req *domain.RegisterRequest, meta *domain.SessionMeta) (*domain.LoginResponse, error) {
 
 	// firstly validating the input
 
@@ -40,46 +57,24 @@ func (s *AuthService) Register(req *domain.RegisterRequest) (*domain.LoginRespon
 		return nil, err
 	}
 
-	// generating tokens
-
-	// access token
-
-	accessToken, err := util.GenerateAccessToken(
-		user.ID,
-		user.Email,
-		s.jwtConfig.Secret,
-		s.jwtConfig.AccessExpiry,
-	)
+	// verification email , the account is created even when it can't go out , it can be resent
 
-	if err != nil {
-		return nil, err
+	if err := s.verificationService.SendVerification(user); err != nil {
+		log.Printf("verification email for %s: %v", user.ID, err)
 	}
 
-	// refresh token
-
-	refreshToken, err := util.GenerateRefreshToken(
-		user.ID,
-		s.jwtConfig.Secret,
-		s.jwtConfig.RefreshExpiry,
-	)
-
-	if err != nil {
-		return nil, err
-	}
+	// generating tokens , registering logs the user in
 
-	// at last returning the login response with it's parameters
+	meta.Device = req.Device
 
-	return &domain.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         user,
-	}, nil
+	return s.startSession(user, meta)
 
 }
 
 // login process same taking login request and returning login response
+// with two-factor on it only returns a challenge token , LoginTwoFactor finishes the login
 
-func (s *AuthService) Login(req *domain.LoginRequest) (*domain.LoginResponse, error) {
+func (s *AuthService) Login(req *domain.LoginRequest, meta *domain.SessionMeta) (*domain.LoginResponse, error) {
 
 	// validating input
 
@@ -100,17 +95,214 @@ func (s *AuthService) Login(req *domain.LoginRequest) (*domain.LoginResponse, er
 		return nil, domain.ErrInvalidCredentials
 	}
 
+	if user.TwoFactorEnabled {
+
+		challenge, err := s.twoFactorService.challenge(user.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &domain.LoginResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
+	}
+
 	// updating the last login of user
 
 	_ = s.userService.UpdateLastLogin(user.ID)
 
 	// generating tokens
 
-	// access token generation
+	meta.Device = req.Device
+
+	return s.startSession(user, meta)
+
+}
+
+// second login step , the challenge from Login and a code from the app or a recovery code
+
+func (s *AuthService) LoginTwoFactor(req *domain.TwoFactorLoginRequest, meta *domain.SessionMeta) (*domain.LoginResponse, error) {
+
+	if err := util.ValidateStruct(req); err != nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	userID, err := s.twoFactorService.completeChallenge(req.ChallengeToken, req.Code)
+
+	if errors.Is(err, domain.ErrTwoFactorNotEnabled) || errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.userService.UpdateLastLogin(user.ID)
+
+	meta.Device = req.Device
+
+	return s.startSession(user, meta)
+
+}
+
+// a new session , its id is the family of the refresh tokens it gets
+
+func (s *AuthService) startSession(user *domain.User, meta *domain.SessionMeta) (*domain.LoginResponse, error) {
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	sessionID, err := s.sessionService.createSession(tx, user.ID, meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueTokens(tx, user, sessionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+
+}
+
+// exchanging a refresh token for a new pair , the old refresh token can't be used again
+// a token that was already used means someone kept a copy , so its whole session is revoked
+
+func (s *AuthService) Refresh(req *domain.RefreshTokenRequest, meta *domain.SessionMeta) (*domain.LoginResponse, error) {
+
+	if err := util.ValidateStruct(req); err != nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	claims, err := util.ValidateRefreshToken(req.RefreshToken, s.jwtConfig.Secret)
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, domain.ErrTokenExpired
+	}
+
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// locking the row , two refreshes with the same token can't both pass
+
+	var (
+		tokenID   uuid.UUID
+		userID    uuid.UUID
+		familyID  uuid.UUID
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	query := `
+	       SELECT t.id , t.user_id , t.family_id , t.used_at , s.revoked_at
+				 FROM refresh_tokens t JOIN sessions s ON s.id = t.family_id
+				 WHERE t.token_hash = $1 FOR UPDATE OF t
+	`
+
+	err = tx.QueryRow(query, util.HashToken(req.RefreshToken)).Scan(&tokenID, &userID, &familyID, &usedAt, &revokedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid || userID != claims.UserID {
+		return nil, domain.ErrInvalidToken
+	}
+
+	// reuse , the revocation is committed before the error goes back
+
+	if usedAt.Valid {
+
+		if err := revokeSession(tx, familyID); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+
+		s.sessionService.deny(familyID)
+
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	// deactivated users can't refresh
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = $1 WHERE id = $2`, time.Now(), tokenID); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionService.touchSession(tx, familyID, meta); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueTokens(tx, user, familyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+
+}
+
+// access and refresh token for the user in a session , the refresh token is stored hashed under it
+
+func (s *AuthService) issueTokens(q execer, user *domain.User, sessionID uuid.UUID) (*domain.LoginResponse, error) {
+
+	// access token
 
 	accessToken, err := util.GenerateAccessToken(
 		user.ID,
 		user.Email,
+		sessionID,
 		s.jwtConfig.Secret,
 		s.jwtConfig.AccessExpiry,
 	)
@@ -119,7 +311,7 @@ func (s *AuthService) Login(req *domain.LoginRequest) (*domain.LoginResponse, er
 		return nil, err
 	}
 
-	// refresh token generation
+	// refresh token
 
 	refreshToken, err := util.GenerateRefreshToken(
 		user.ID,
@@ -131,7 +323,16 @@ func (s *AuthService) Login(req *domain.LoginRequest) (*domain.LoginResponse, er
 		return nil, err
 	}
 
-	// at final returning the login response with parameters
+	query := `
+	       INSERT INTO refresh_tokens (user_id , family_id , token_hash , expires_at)
+				 VALUES ($1 , $2 , $3 , $4)
+	`
+
+	if _, err := q.Exec(query, user.ID, sessionID, util.HashToken(refreshToken), time.Now().Add(s.jwtConfig.RefreshExpiry)); err != nil {
+		return nil, err
+	}
+
+	// at last returning the login response with it's parameters
 
 	return &domain.LoginResponse{
 		AccessToken:  accessToken,
@@ -0,0 +1,217 @@
+// branding service - business logo upload and brand settings for generated pdfs
+
+package service
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/storage"
+	"github.com/google/uuid"
+)
+
+// logo limits - file size and the largest side in pixels
+
+const (
+	MaxLogoBytes     = 2 << 20
+	maxLogoDimension = 2000
+)
+
+// where the owner can fetch their logo , stored in users.logo_url
+
+const logoURLPath = "/api/v1/users/me/logo"
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type BrandingService struct {
+	db          *database.DB
+	storage     storage.Storage
+	userService *UserService
+}
+
+// branding service function
+
+func NewBrandingService(db *database.DB, storage storage.Storage, userService *UserService) *BrandingService {
+	return &BrandingService{
+		db:          db,
+		storage:     storage,
+		userService: userService,
+	}
+}
+
+// storing a new logo , png or jpeg only
+// the image is decoded and encoded again , which checks it and drops metadata and formats the pdf library can't read (interlaced png)
+
+func (s *BrandingService) UploadLogo(userID uuid.UUID, data []byte) (*domain.User, error) {
+
+	if len(data) > MaxLogoBytes {
+		return nil, domain.ErrLogoTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+
+	var ext string
+
+	switch contentType {
+	case "image/png":
+		ext = "png"
+	case "image/jpeg":
+		ext = "jpg"
+	default:
+		return nil, fmt.Errorf("logo must be a PNG or JPEG image: %w", domain.ErrInvalidInput)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("logo image can't be read: %w", domain.ErrInvalidInput)
+	}
+
+	if config.Width > maxLogoDimension || config.Height > maxLogoDimension {
+		return nil, fmt.Errorf("logo must be at most %dx%d pixels: %w", maxLogoDimension, maxLogoDimension, domain.ErrInvalidInput)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("logo image can't be read: %w", domain.ErrInvalidInput)
+	}
+
+	var buf bytes.Buffer
+
+	if ext == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// new key for every upload , so a cached old logo is never served for the new one
+
+	key := fmt.Sprintf("logos/%s/%s.%s", userID, uuid.New(), ext)
+
+	if err := s.storage.Put(key, buf.Bytes(), contentType); err != nil {
+		return nil, err
+	}
+
+	var oldKey sql.NullString
+
+	err = s.db.QueryRow(`SELECT logo_key FROM users WHERE id = $1`, userID).Scan(&oldKey)
+
+	if err != nil {
+		s.storage.Delete(key)
+		return nil, err
+	}
+
+	query := `UPDATE users SET logo_key = $1 , logo_url = $2 , updated_at = $3 WHERE id = $4`
+
+	if _, err := s.db.Exec(query, key, logoURLPath, time.Now(), userID); err != nil {
+		s.storage.Delete(key)
+		return nil, err
+	}
+
+	if oldKey.Valid && oldKey.String != "" {
+		if err := s.storage.Delete(oldKey.String); err != nil {
+			log.Printf("branding: deleting old logo %s: %v", oldKey.String, err)
+		}
+	}
+
+	return s.userService.GetUserByID(userID)
+}
+
+// the user's logo and its content type
+
+func (s *BrandingService) GetLogo(userID uuid.UUID) ([]byte, string, error) {
+
+	var key sql.NullString
+
+	if err := s.db.QueryRow(`SELECT logo_key FROM users WHERE id = $1`, userID).Scan(&key); err != nil {
+		return nil, "", err
+	}
+
+	if !key.Valid || key.String == "" {
+		return nil, "", domain.ErrLogoNotFound
+	}
+
+	data, err := s.storage.Get(key.String)
+
+	if err == storage.ErrNotFound {
+		return nil, "", domain.ErrLogoNotFound
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, http.DetectContentType(data), nil
+}
+
+// removing the logo
+
+func (s *BrandingService) DeleteLogo(userID uuid.UUID) error {
+
+	var key sql.NullString
+
+	if err := s.db.QueryRow(`SELECT logo_key FROM users WHERE id = $1`, userID).Scan(&key); err != nil {
+		return err
+	}
+
+	if !key.Valid || key.String == "" {
+		return domain.ErrLogoNotFound
+	}
+
+	if _, err := s.db.Exec(`UPDATE users SET logo_key = NULL , logo_url = NULL , updated_at = $1 WHERE id = $2`, time.Now(), userID); err != nil {
+		return err
+	}
+
+	return s.storage.Delete(key.String)
+}
+
+// updating brand colours and font
+
+func (s *BrandingService) UpdateBranding(userID uuid.UUID, req *domain.UpdateBrandingRequest) (*domain.User, error) {
+
+	for field, color := range map[string]*string{"primary_color": req.PrimaryColor, "accent_color": req.AccentColor} {
+		if color != nil && *color != "" && !hexColorPattern.MatchString(*color) {
+			return nil, fmt.Errorf("%s must look like #1a2b3c: %w", field, domain.ErrInvalidInput)
+		}
+	}
+
+	if req.Font != nil && *req.Font != "" {
+		switch *req.Font {
+		case domain.BrandFontHelvetica, domain.BrandFontTimes, domain.BrandFontCourier:
+		default:
+			return nil, fmt.Errorf("font must be one of helvetica, times, courier: %w", domain.ErrInvalidInput)
+		}
+	}
+
+	// nil keeps the value , "" clears it
+
+	query := `
+		      UPDATE users SET
+					brand_primary_color = CASE WHEN $1::text IS NULL THEN brand_primary_color ELSE NULLIF($1 , '') END ,
+					brand_accent_color = CASE WHEN $2::text IS NULL THEN brand_accent_color ELSE NULLIF($2 , '') END ,
+					brand_font = CASE WHEN $3::text IS NULL THEN brand_font ELSE NULLIF($3 , '') END ,
+					updated_at = $4
+					WHERE id = $5 AND is_active = true
+		    `
+
+	if _, err := s.db.Exec(query, req.PrimaryColor, req.AccentColor, req.Font, time.Now(), userID); err != nil {
+		return nil, err
+	}
+
+	return s.userService.GetUserByID(userID)
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/Suthar345Piyush/invoicego/internal/database"
@@ -61,23 +60,31 @@ func (s *ClientService) CreateClient(userID uuid.UUID, req *domain.CreateClientR
 
 }
 
+// columns of the clients table in the order scanClient expects
+
+const clientColumns = `
+		      id , user_id , name , email , phone , company_name , address_line1 , address_line2 , city , state , postal_code , country , tax_id , notes , is_active , reminders_paused , created_at , updated_at
+		    `
+
+// scanning one client row selected with clientColumns
+
+func scanClient(row rowScanner, client *domain.Client) error {
+	return row.Scan(
+		&client.ID, &client.UserID, &client.Name, &client.Email, &client.Phone, &client.CompanyName, &client.AddressLine1, &client.AddressLine2, &client.City, &client.State, &client.PostalCode, &client.Country, &client.TaxID, &client.Notes, &client.IsActive, &client.RemindersPaused, &client.CreatedAt, &client.UpdatedAt,
+	)
+}
+
 // getting client by  their client id's
 
 func (s *ClientService) GetClientByID(userID, clientID uuid.UUID) (*domain.Client, error) {
 	client := &domain.Client{}
 
-	query :=
-		`
-		  SELECT id , user_id , name , email , phone , company_name , address_line1 , address_line2 , city , state,
-			postal_code , tax_id , notes , is_active , created_at , updated_at FROM clients WHERE id = $1 AND user_id = $2 AND is_active = true
-		 `
+	query := `SELECT ` + clientColumns + ` FROM clients WHERE id = $1 AND user_id = $2 AND is_active = true`
 
-	err := s.db.QueryRow(query, clientID, userID).Scan(
-		&client.ID, &client.UserID, &client.Name, &client.Email, &client.CompanyName, &client.AddressLine1, &client.AddressLine2, &client.City, &client.State, &client.PostalCode, &client.TaxID, &client.Notes, &client.IsActive, &client.CreatedAt, &client.UpdatedAt,
-	)
+	err := scanClient(s.db.QueryRow(query, clientID, userID), client)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("client not found")
+		return nil, domain.ErrClientNotFound
 	}
 
 	if err != nil {
@@ -117,10 +124,7 @@ func (s *ClientService) GetClientsByUserID(userID uuid.UUID, page, pageSize int)
 
 	// query for  getting clients
 
-	query :=
-		` SELECT id , user_id , name , email , phone , company_name , address_line1 , address_line2 ,
-				 city , state , postal_code , country  , tax_id , notes , is_active , created_at , updated_at FROM clients WHERE user_id = $1 AND is_active = true ORDER BY created_at DESC LIMIT $2 offset $3 
-			  `
+	query := `SELECT ` + clientColumns + ` FROM clients WHERE user_id = $1 AND is_active = true ORDER BY created_at DESC LIMIT $2 OFFSET $3`
 
 	rows, err := s.db.Query(query, userID, pageSize, offset)
 
@@ -135,9 +139,7 @@ func (s *ClientService) GetClientsByUserID(userID uuid.UUID, page, pageSize int)
 	for rows.Next() {
 		client := &domain.Client{}
 
-		err := rows.Scan(
-			&client.ID, &client.UserID, &client.Name, &client.Email, &client.Phone, &client.CompanyName, &client.AddressLine1, &client.AddressLine2, &client.City, &client.State, &client.PostalCode, &client.Country, &client.TaxID, &client.Notes, &client.IsActive, &client.CreatedAt, &client.UpdatedAt,
-		)
+		err := scanClient(rows, client)
 
 		if err != nil {
 			return nil, err
@@ -0,0 +1,58 @@
+// small helpers shared between the services
+
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+)
+
+// date format used in all the requests - YYYY-MM-DD
+
+const dateLayout = "2006-01-02"
+
+// queryer is satisfied by both *database.DB and *sql.Tx
+// so read helpers can run inside or outside a transaction
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// execer is the write side of the same , for helpers that only run statements
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// parsing a request date , field is only used in the error message
+
+func parseDate(field, value string) (time.Time, error) {
+
+	date, err := time.Parse(dateLayout, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s format, use YYYY-MM-DD: %w", field, domain.ErrInvalidInput)
+	}
+
+	return date, nil
+}
+
+// calendar date of the instant in the given timezone , as a UTC midnight like DATE columns are scanned
+// the reminders and the overdue status use it , so "today" is the user's own day everywhere
+
+func localDate(now time.Time, timezone string) time.Time {
+
+	loc, err := time.LoadLocation(timezone)
+
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
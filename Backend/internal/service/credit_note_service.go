@@ -0,0 +1,522 @@
+// credit note service - crediting all or part of a sent or paid invoice
+
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+type CreditNoteService struct {
+	db *database.DB
+}
+
+// credit note service function
+
+func NewCreditNoteService(db *database.DB) *CreditNoteService {
+	return &CreditNoteService{db: db}
+}
+
+// what earlier credit notes already took off one invoice line
+
+type creditedLine struct {
+	quantity money.Decimal
+	amount   money.Decimal
+	tax      money.Decimal
+}
+
+// issuing a credit note against an invoice
+// a line credited for all its remaining quantity gets exactly what's left of its amount and tax , a partial quantity
+// gets its share rounded like the invoice , so credit notes never add up to more than the invoice
+// the invoice discount is shared out over the credited lines and the rest of it goes with the last credit note
+
+func (s *CreditNoteService) CreateCreditNote(userID, invoiceID uuid.UUID, req *domain.CreateCreditNoteRequest) (*domain.CreditNote, error) {
+
+	issueDate := time.Now()
+
+	if req.IssueDate != nil {
+		var err error
+
+		if issueDate, err = parseDate("issue_date", *req.IssueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	inv, err := lockLedgerInvoice(tx, userID, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// drafts are edited or deleted instead , canceled invoices have nothing to credit
+
+	switch inv.status {
+	case domain.InvoiceStatusSent, domain.InvoiceStatusPartiallyPaid, domain.InvoiceStatusPaid, domain.InvoiceStatusOverdue:
+	default:
+		return nil, domain.ErrInvoiceNotCreditable
+	}
+
+	// the rest of the invoice the lines are worked out from , its row is already locked
+
+	invoice := &domain.Invoice{ID: invoiceID}
+
+	invoiceQuery := `
+		      SELECT invoice_number , client_id , currency , base_currency , exchange_rate , subtotal , discount_amount , rounding_mode , tax_rounding , tax_scheme , place_of_supply , seller_gstin
+					FROM invoices WHERE id = $1
+		    `
+
+	err = tx.QueryRow(invoiceQuery, invoiceID).Scan(
+		&invoice.InvoiceNumber, &invoice.ClientID, &invoice.Currency, &invoice.BaseCurrency, &invoice.ExchangeRate, &invoice.Subtotal, &invoice.DiscountAmount, &invoice.RoundingMode, &invoice.TaxRounding, &invoice.TaxScheme, &invoice.PlaceOfSupply, &invoice.SellerGSTIN,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getInvoiceItems(tx, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credited, creditedDiscount, err := creditedLines(tx, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	note := &domain.CreditNote{
+		ID:            uuid.New(),
+		UserID:        userID,
+		InvoiceID:     invoiceID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		ClientID:      invoice.ClientID,
+		IssueDate:     issueDate,
+		Reason:        req.Reason,
+		Currency:      invoice.Currency,
+		BaseCurrency:  invoice.BaseCurrency,
+		ExchangeRate:  invoice.ExchangeRate,
+		CreatedAt:     time.Now(),
+	}
+
+	r := invoiceRounding(invoice.Currency, invoice.RoundingMode, invoice.TaxRounding)
+
+	note.Items, err = creditNoteLines(note.ID, items, credited, req.Items, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range note.Items {
+		note.Subtotal = note.Subtotal.Add(line.Amount)
+		note.TaxAmount = note.TaxAmount.Add(line.TaxAmount)
+	}
+
+	// the invoice discount , what's left of it once every line is fully credited and a share of it before that
+
+	remainingDiscount := invoice.DiscountAmount.Sub(creditedDiscount)
+
+	if fullyCredited(items, credited, note.Items) {
+		note.DiscountAmount = remainingDiscount
+	} else if invoice.Subtotal.Sign() > 0 {
+		if note.DiscountAmount, err = invoice.DiscountAmount.MulDiv(note.Subtotal, invoice.Subtotal, r.places, r.mode); err != nil {
+			return nil, err
+		}
+
+		if note.DiscountAmount.GreaterThan(remainingDiscount) {
+			note.DiscountAmount = remainingDiscount
+		}
+	}
+
+	note.TotalAmount = note.Subtotal.Add(note.TaxAmount).Sub(note.DiscountAmount)
+
+	if note.TotalAmount.Sign() <= 0 {
+		return nil, fmt.Errorf("the credited lines add up to nothing: %w", domain.ErrInvalidInput)
+	}
+
+	if note.TotalAmount.GreaterThan(inv.due()) {
+		return nil, fmt.Errorf("credit is more than what's left of the invoice: %w", domain.ErrInvalidInput)
+	}
+
+	// gst is reversed the same way the invoice charged it
+
+	if invoice.TaxScheme == domain.TaxSchemeGST {
+		for _, line := range note.Items {
+			if gstIntraState(invoice) {
+				cgst, sgst := splitGST(line.TaxAmount, invoice.Currency)
+				note.CGSTAmount = note.CGSTAmount.Add(cgst)
+				note.SGSTAmount = note.SGSTAmount.Add(sgst)
+			} else {
+				note.IGSTAmount = note.IGSTAmount.Add(line.TaxAmount)
+			}
+		}
+	}
+
+	// taking the next number of the user's credit note sequence
+
+	var (
+		prefix string
+		number int
+	)
+
+	err = tx.QueryRow(`SELECT credit_note_prefix , next_credit_note_number FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&prefix, &number)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	note.CreditNoteNumber = fmt.Sprintf("%s-%04d", prefix, number)
+
+	if _, err = tx.Exec(`UPDATE users SET next_credit_note_number = next_credit_note_number + 1 , updated_at = $1 WHERE id = $2`, time.Now(), userID); err != nil {
+		return nil, err
+	}
+
+	if err = insertCreditNote(tx, note); err != nil {
+		return nil, err
+	}
+
+	// the invoice follows its balance , fully credited without payments means canceled
+
+	inv.amountCredited = inv.amountCredited.Add(note.TotalAmount)
+
+	next := settlementStatus(inv.amountPaid, inv.due(), inv.dueDate, localDate(time.Now(), inv.timezone))
+
+	if err = applySettlement(tx, userID, invoiceID, inv.status, next, &issueDate, "credit note "+note.CreditNoteNumber+" issued"); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// quantities and amounts earlier credit notes took off each line of the invoice , and the invoice discount they took
+
+func creditedLines(q queryer, invoiceID uuid.UUID) (map[uuid.UUID]*creditedLine, money.Decimal, error) {
+
+	var discount money.Decimal
+
+	err := q.QueryRow(`SELECT COALESCE(SUM(discount_amount) , 0) FROM credit_notes WHERE invoice_id = $1`, invoiceID).Scan(&discount)
+
+	if err != nil {
+		return nil, discount, err
+	}
+
+	query := `
+		      SELECT ci.invoice_item_id , SUM(ci.quantity) , SUM(ci.amount) , SUM(ci.tax_amount)
+					FROM credit_note_items ci
+					JOIN credit_notes c ON c.id = ci.credit_note_id
+					WHERE c.invoice_id = $1
+					GROUP BY ci.invoice_item_id
+		    `
+
+	rows, err := q.Query(query, invoiceID)
+
+	if err != nil {
+		return nil, discount, err
+	}
+
+	defer rows.Close()
+
+	credited := map[uuid.UUID]*creditedLine{}
+
+	for rows.Next() {
+
+		var itemID uuid.UUID
+		line := &creditedLine{}
+
+		if err := rows.Scan(&itemID, &line.quantity, &line.amount, &line.tax); err != nil {
+			return nil, discount, err
+		}
+
+		credited[itemID] = line
+	}
+
+	return credited, discount, rows.Err()
+}
+
+// the lines of a new credit note , every line with something left when none are asked for
+
+func creditNoteLines(noteID uuid.UUID, items []*domain.InvoiceItem, credited map[uuid.UUID]*creditedLine, requested []*domain.CreditNoteItemReq, r rounding) ([]*domain.CreditNoteItem, error) {
+
+	byID := map[uuid.UUID]*domain.InvoiceItem{}
+
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	if len(requested) == 0 {
+		for _, item := range items {
+			if remainingQuantity(item, credited).Sign() > 0 {
+				requested = append(requested, &domain.CreditNoteItemReq{InvoiceItemID: item.ID})
+			}
+		}
+
+		if len(requested) == 0 {
+			return nil, fmt.Errorf("invoice is already fully credited: %w", domain.ErrInvoiceNotCreditable)
+		}
+	}
+
+	lines := make([]*domain.CreditNoteItem, 0, len(requested))
+	seen := map[uuid.UUID]bool{}
+
+	for i, req := range requested {
+
+		item, ok := byID[req.InvoiceItemID]
+
+		if !ok {
+			return nil, fmt.Errorf("item %d is not a line of the invoice: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		if seen[item.ID] {
+			return nil, fmt.Errorf("item %d credits the same line twice: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		seen[item.ID] = true
+
+		remaining := remainingQuantity(item, credited)
+		quantity := remaining
+
+		if req.Quantity != nil {
+			quantity = *req.Quantity
+		}
+
+		if quantity.Sign() <= 0 || quantity.GreaterThan(remaining) {
+			return nil, fmt.Errorf("item %d can credit at most %s of %q: %w", i+1, remaining, item.Description, domain.ErrInvalidInput)
+		}
+
+		line := &domain.CreditNoteItem{
+			ID:            uuid.New(),
+			CreditNoteID:  noteID,
+			InvoiceItemID: item.ID,
+			Description:   item.Description,
+			HSNSAC:        item.HSNSAC,
+			Quantity:      quantity,
+			UnitPrice:     item.UnitPrice,
+			TaxRate:       item.TaxRate,
+			SortOrder:     i,
+			CreatedAt:     time.Now(),
+		}
+
+		if quantity.Cmp(remaining) == 0 {
+			line.Amount, line.TaxAmount = item.Amount, item.TaxAmount
+
+			if done := credited[item.ID]; done != nil {
+				line.Amount, line.TaxAmount = line.Amount.Sub(done.amount), line.TaxAmount.Sub(done.tax)
+			}
+		} else {
+			var err error
+
+			if line.Amount, err = item.Amount.MulDiv(quantity, item.Quantity, r.places, r.mode); err != nil {
+				return nil, err
+			}
+
+			if line.TaxAmount, err = item.TaxAmount.MulDiv(quantity, item.Quantity, r.places, r.mode); err != nil {
+				return nil, err
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// quantity of a line no credit note took yet
+
+func remainingQuantity(item *domain.InvoiceItem, credited map[uuid.UUID]*creditedLine) money.Decimal {
+
+	if done := credited[item.ID]; done != nil {
+		return item.Quantity.Sub(done.quantity)
+	}
+
+	return item.Quantity
+}
+
+// whether the invoice has nothing left to credit once the new lines are added
+
+func fullyCredited(items []*domain.InvoiceItem, credited map[uuid.UUID]*creditedLine, lines []*domain.CreditNoteItem) bool {
+
+	now := map[uuid.UUID]money.Decimal{}
+
+	for _, line := range lines {
+		now[line.InvoiceItemID] = line.Quantity
+	}
+
+	for _, item := range items {
+		if remainingQuantity(item, credited).Sub(now[item.ID]).Sign() > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func insertCreditNote(tx *sql.Tx, note *domain.CreditNote) error {
+
+	noteQuery := `
+		      INSERT INTO credit_notes (
+					   id , user_id , invoice_id , client_id , credit_note_number , issue_date , reason , currency , base_currency , exchange_rate ,
+						 subtotal , tax_amount , cgst_amount , sgst_amount , igst_amount , discount_amount , total_amount , created_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $13 , $14 , $15 , $16 , $17 , $18)
+		    `
+
+	_, err := tx.Exec(
+		noteQuery,
+		note.ID, note.UserID, note.InvoiceID, note.ClientID, note.CreditNoteNumber, note.IssueDate, note.Reason, note.Currency, note.BaseCurrency, note.ExchangeRate,
+		note.Subtotal, note.TaxAmount, note.CGSTAmount, note.SGSTAmount, note.IGSTAmount, note.DiscountAmount, note.TotalAmount, note.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		      INSERT INTO credit_note_items (
+					   id , credit_note_id , invoice_item_id , description , hsn_sac , quantity , unit_price , amount , tax_rate , tax_amount , sort_order , created_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12)
+		    `
+
+	for _, line := range note.Items {
+
+		_, err := tx.Exec(
+			itemQuery,
+			line.ID, line.CreditNoteID, line.InvoiceItemID, line.Description, line.HSNSAC, line.Quantity, line.UnitPrice, line.Amount, line.TaxRate, line.TaxAmount, line.SortOrder, line.CreatedAt,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// columns of the credit_notes table (as c , joined with the invoice as i) in the order scanCreditNote expects
+
+const creditNoteColumns = `
+		      c.id , c.user_id , c.invoice_id , i.invoice_number , c.client_id , c.credit_note_number , c.issue_date , c.reason , c.currency , c.base_currency , c.exchange_rate ,
+					c.subtotal , c.tax_amount , c.cgst_amount , c.sgst_amount , c.igst_amount , c.discount_amount , c.total_amount , c.created_at
+		    `
+
+func scanCreditNote(row rowScanner, note *domain.CreditNote) error {
+	return row.Scan(
+		&note.ID, &note.UserID, &note.InvoiceID, &note.InvoiceNumber, &note.ClientID, &note.CreditNoteNumber, &note.IssueDate, &note.Reason, &note.Currency, &note.BaseCurrency, &note.ExchangeRate,
+		&note.Subtotal, &note.TaxAmount, &note.CGSTAmount, &note.SGSTAmount, &note.IGSTAmount, &note.DiscountAmount, &note.TotalAmount, &note.CreatedAt,
+	)
+}
+
+// one credit note with its lines
+
+func (s *CreditNoteService) GetCreditNote(userID, creditNoteID uuid.UUID) (*domain.CreditNote, error) {
+
+	note := &domain.CreditNote{}
+
+	query := `SELECT ` + creditNoteColumns + ` FROM credit_notes c JOIN invoices i ON i.id = c.invoice_id WHERE c.id = $1 AND c.user_id = $2`
+
+	err := scanCreditNote(s.db.QueryRow(query, creditNoteID, userID), note)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCreditNoteNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	itemQuery := `
+		      SELECT id , credit_note_id , invoice_item_id , description , hsn_sac , quantity , unit_price , amount , tax_rate , tax_amount , sort_order , created_at
+					FROM credit_note_items WHERE credit_note_id = $1 ORDER BY sort_order
+		    `
+
+	rows, err := s.db.Query(itemQuery, creditNoteID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	note.Items = []*domain.CreditNoteItem{}
+
+	for rows.Next() {
+		line := &domain.CreditNoteItem{}
+
+		err := rows.Scan(&line.ID, &line.CreditNoteID, &line.InvoiceItemID, &line.Description, &line.HSNSAC, &line.Quantity, &line.UnitPrice, &line.Amount, &line.TaxRate, &line.TaxAmount, &line.SortOrder, &line.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		note.Items = append(note.Items, line)
+	}
+
+	return note, rows.Err()
+}
+
+// credit notes of the user , newest first , only those of one invoice when invoiceID isn't nil
+
+func (s *CreditNoteService) ListCreditNotes(userID uuid.UUID, invoiceID *uuid.UUID) ([]*domain.CreditNote, error) {
+
+	query := `SELECT ` + creditNoteColumns + ` FROM credit_notes c JOIN invoices i ON i.id = c.invoice_id WHERE c.user_id = $1`
+	args := []interface{}{userID}
+
+	if invoiceID != nil {
+
+		var exists bool
+
+		err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM invoices WHERE id = $1 AND user_id = $2)`, *invoiceID, userID).Scan(&exists)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, domain.ErrInvoiceNotFound
+		}
+
+		query += ` AND c.invoice_id = $2`
+		args = append(args, *invoiceID)
+	}
+
+	rows, err := s.db.Query(query+` ORDER BY c.issue_date DESC , c.created_at DESC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	notes := []*domain.CreditNote{}
+
+	for rows.Next() {
+		note := &domain.CreditNote{}
+
+		if err := scanCreditNote(rows, note); err != nil {
+			return nil, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
@@ -0,0 +1,117 @@
+// email verification service - emailing the verification link and verifying the address
+// invoices can't be emailed to clients until the account's address is verified
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type EmailVerificationService struct {
+	db          *database.DB
+	userService *UserService
+	mailer      mailer.Mailer
+	jwtConfig   *config.JWTConfig
+	baseURL     string
+}
+
+// email verification service function , baseURL is the public address the link points at
+
+func NewEmailVerificationService(db *database.DB, userService *UserService, mailer mailer.Mailer, jwtConfig *config.JWTConfig, baseURL string) *EmailVerificationService {
+	return &EmailVerificationService{
+		db:          db,
+		userService: userService,
+		mailer:      mailer,
+		jwtConfig:   jwtConfig,
+		baseURL:     baseURL,
+	}
+}
+
+// emailing a new verification link to the user
+
+func (s *EmailVerificationService) SendVerification(user *domain.User) error {
+
+	token, err := util.GenerateEmailVerificationToken(user.ID, user.Email, s.jwtConfig.Secret, s.jwtConfig.VerifyExpiry)
+
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/api/v1/auth/verify-email?token=" + url.QueryEscape(token)
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nPlease confirm the email address of your account by opening this link:\n\n%s\n\nThe link expires on %s. If you didn't create an account, you can ignore this email.",
+		user.FullName, link, time.Now().Add(s.jwtConfig.VerifyExpiry).UTC().Format("January 2, 2006 15:04 MST"),
+	)
+
+	err = s.mailer.Send(&mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your email address",
+		Body:    body,
+	})
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+	}
+
+	return nil
+}
+
+// resending the link , the handler rate limits this
+
+func (s *EmailVerificationService) ResendVerification(userID uuid.UUID) error {
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	return s.SendVerification(user)
+}
+
+// verifying the address from the token in the link , verifying twice is fine
+
+func (s *EmailVerificationService) VerifyEmail(token string) error {
+
+	claims, err := util.ValidateEmailVerificationToken(token, s.jwtConfig.Secret)
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return domain.ErrTokenExpired
+	}
+
+	if err != nil {
+		return domain.ErrInvalidToken
+	}
+
+	// the address has to still be the one the link was sent to
+
+	query := `UPDATE users SET email_verified = true , updated_at = $1 WHERE id = $2 AND email = $3 AND is_active = true`
+
+	result, err := s.db.Exec(query, time.Now(), claims.UserID, claims.Email)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrInvalidToken
+	}
+
+	return nil
+}
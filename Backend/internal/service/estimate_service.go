@@ -0,0 +1,903 @@
+// estimate service - quotes with the invoice lines and taxes , the client's answer through a public link
+// and turning an accepted estimate into an invoice
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// purpose the estimate link tokens are signed for
+
+const estimateLinkPurpose = "estimate"
+
+type EstimateService struct {
+	db             *database.DB
+	invoiceService *InvoiceService
+	userService    *UserService
+	pdfService     *PDFService
+	clock          util.Clock
+	baseURL        string
+	secret         string
+}
+
+// estimate service function , baseURL and secret make the client links like the share links
+
+func NewEstimateService(db *database.DB, invoiceService *InvoiceService, userService *UserService, pdfService *PDFService, clock util.Clock, baseURL, secret string) *EstimateService {
+	return &EstimateService{
+		db:             db,
+		invoiceService: invoiceService,
+		userService:    userService,
+		pdfService:     pdfService,
+		clock:          clock,
+		baseURL:        baseURL,
+		secret:         secret,
+	}
+}
+
+const estimateColumns = `
+		      id , user_id , client_id , estimate_number , status , issue_date , expiry_date , currency , subtotal , tax_rate , tax_amount ,
+					tax_scheme , rounding_mode , tax_rounding , place_of_supply , seller_gstin , buyer_gstin , cgst_amount , sgst_amount , igst_amount , discount_amount , total_amount ,
+					template_id , notes , terms_and_conditions , sent_at , responded_at , client_note , invoice_id , converted_at , created_at , updated_at
+		    `
+
+// scanning one estimate row , the client link is only handed out once the estimate was sent
+
+func (s *EstimateService) scanEstimate(row rowScanner, estimate *domain.Estimate) error {
+
+	err := row.Scan(
+		&estimate.ID, &estimate.UserID, &estimate.ClientID, &estimate.EstimateNumber, &estimate.Status, &estimate.IssueDate, &estimate.ExpiryDate, &estimate.Currency, &estimate.Subtotal, &estimate.TaxRate, &estimate.TaxAmount,
+		&estimate.TaxScheme, &estimate.RoundingMode, &estimate.TaxRounding, &estimate.PlaceOfSupply, &estimate.SellerGSTIN, &estimate.BuyerGSTIN, &estimate.CGSTAmount, &estimate.SGSTAmount, &estimate.IGSTAmount, &estimate.DiscountAmount, &estimate.TotalAmount,
+		&estimate.TemplateID, &estimate.Notes, &estimate.TermsAndConditions, &estimate.SentAt, &estimate.RespondedAt, &estimate.ClientNote, &estimate.InvoiceID, &estimate.ConvertedAt, &estimate.CreatedAt, &estimate.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if estimate.Status != domain.EstimateStatusDraft {
+		url := s.baseURL + "/api/v1/public/estimates/" + util.SignID(estimate.ID, estimateLinkPurpose, s.secret)
+		estimate.PublicURL = &url
+	}
+
+	return nil
+}
+
+// lines of an estimate , stored like invoice lines
+
+func getEstimateItems(q queryer, estimateID uuid.UUID) ([]*domain.InvoiceItem, error) {
+
+	items, err := getLineItems(q, "estimate_items", "estimate_id", estimateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		item.InvoiceID = uuid.Nil
+	}
+
+	return items, nil
+}
+
+// client of the estimate has to be one of the user's active clients
+
+func checkClient(q queryer, userID, clientID uuid.UUID) error {
+
+	var exists bool
+
+	err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM clients WHERE id = $1 AND user_id = $2 AND is_active = true)`, clientID, userID).Scan(&exists)
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return domain.ErrClientNotFound
+	}
+
+	return nil
+}
+
+// creating a draft estimate , totals are worked out the same way as an invoice's
+
+func (s *EstimateService) CreateEstimate(userID uuid.UUID, req *domain.CreateEstimateRequest) (*domain.Estimate, error) {
+
+	issueDate, err := parseDate("issue_date", req.IssueDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expiryDate, err := parseDate("expiry_date", req.ExpiryDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expiryDate.Before(issueDate) {
+		return nil, fmt.Errorf("expiry_date cannot be before issue_date: %w", domain.ErrInvalidInput)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// locking the user row to take the next estimate number
+
+	var (
+		prefix string
+		number int
+	)
+
+	err = tx.QueryRow(`SELECT estimate_prefix , next_estimate_number FROM users WHERE id = $1 AND is_active = true FOR UPDATE`, userID).Scan(&prefix, &number)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkClient(tx, userID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	estimate := &domain.Estimate{
+		ID:                 uuid.New(),
+		UserID:             userID,
+		ClientID:           req.ClientID,
+		EstimateNumber:     fmt.Sprintf("%s-%04d", prefix, number),
+		Status:             domain.EstimateStatusDraft,
+		IssueDate:          issueDate,
+		ExpiryDate:         expiryDate,
+		Currency:           req.Currency,
+		TaxRate:            req.TaxRate,
+		TaxScheme:          req.TaxScheme,
+		RoundingMode:       req.RoundingMode,
+		TaxRounding:        req.TaxRounding,
+		PlaceOfSupply:      req.PlaceOfSupply,
+		DiscountAmount:     req.DiscountAmount,
+		TemplateID:         req.TemplateID,
+		Notes:              req.Notes,
+		TermsAndConditions: req.TermsAndConditions,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if estimate.TaxScheme == "" {
+		estimate.TaxScheme = domain.TaxSchemeFlat
+	}
+
+	if estimate.RoundingMode == "" {
+		estimate.RoundingMode = string(money.HalfUp)
+	}
+
+	if estimate.TaxRounding == "" {
+		estimate.TaxRounding = domain.TaxRoundingLine
+	}
+
+	if estimate.TemplateID == "" {
+		estimate.TemplateID = domain.TemplateDefault
+	}
+
+	amounts, err := s.applyAmounts(tx, estimate, req.Items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		      INSERT INTO estimates (
+					   id , user_id , client_id , estimate_number , status , issue_date , expiry_date , currency , subtotal , tax_rate , tax_amount ,
+						 tax_scheme , rounding_mode , tax_rounding , place_of_supply , seller_gstin , buyer_gstin , cgst_amount , sgst_amount , igst_amount , discount_amount , total_amount ,
+						 template_id , notes , terms_and_conditions , created_at , updated_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $13 , $14 , $15 , $16 , $17 , $18 , $19 , $20 , $21 , $22 , $23 , $24 , $25 , $26 , $27)
+		    `
+
+	_, err = tx.Exec(
+		query,
+		estimate.ID, estimate.UserID, estimate.ClientID, estimate.EstimateNumber, estimate.Status, estimate.IssueDate, estimate.ExpiryDate, estimate.Currency, estimate.Subtotal, estimate.TaxRate, estimate.TaxAmount,
+		estimate.TaxScheme, estimate.RoundingMode, estimate.TaxRounding, estimate.PlaceOfSupply, estimate.SellerGSTIN, estimate.BuyerGSTIN, estimate.CGSTAmount, estimate.SGSTAmount, estimate.IGSTAmount, estimate.DiscountAmount, estimate.TotalAmount,
+		estimate.TemplateID, estimate.Notes, estimate.TermsAndConditions, estimate.CreatedAt, estimate.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = insertLineItems(tx, "estimate_items", "estimate_id", estimate.ID, req.Items, amounts.lines); err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Exec(`UPDATE users SET next_estimate_number = next_estimate_number + 1 , updated_at = $1 WHERE id = $2`, time.Now(), userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetEstimate(userID, estimate.ID)
+}
+
+// working out the totals of an estimate from its lines with the invoice tax rules , gst fields included
+
+func (s *EstimateService) applyAmounts(q queryer, estimate *domain.Estimate, items []*domain.CreateInvoiceItemReq) (*invoiceAmounts, error) {
+
+	amounts, err := s.invoiceService.invoiceTax(q, estimate.UserID, estimate.ClientID, estimate.TaxScheme, estimate.Currency, estimate.RoundingMode, estimate.TaxRounding, estimate.TaxRate, estimate.DiscountAmount, estimate.PlaceOfSupply, items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	estimate.Subtotal, estimate.TaxAmount, estimate.TotalAmount = amounts.subtotal, amounts.taxAmount, amounts.totalAmount
+
+	estimate.PlaceOfSupply, estimate.SellerGSTIN, estimate.BuyerGSTIN = nil, nil, nil
+	estimate.CGSTAmount, estimate.SGSTAmount, estimate.IGSTAmount = money.Zero, money.Zero, money.Zero
+
+	if gst := amounts.gst; gst != nil {
+		estimate.PlaceOfSupply = &gst.placeOfSupply
+		estimate.SellerGSTIN = &gst.sellerGSTIN
+		estimate.BuyerGSTIN = gst.buyerGSTIN
+		estimate.CGSTAmount, estimate.SGSTAmount, estimate.IGSTAmount = gst.cgst, gst.sgst, gst.igst
+	}
+
+	return amounts, nil
+}
+
+// editing a draft estimate , only the given fields change and the lines are always written again
+
+func (s *EstimateService) UpdateEstimate(userID, estimateID uuid.UUID, req *domain.UpdateEstimateRequest) (*domain.Estimate, error) {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	current := &domain.Estimate{}
+
+	err = s.scanEstimate(tx.QueryRow(`SELECT `+estimateColumns+` FROM estimates WHERE id = $1 AND user_id = $2 FOR UPDATE`, estimateID, userID), current)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrEstimateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Status != domain.EstimateStatusDraft {
+		return nil, domain.ErrEstimateNotEditable
+	}
+
+	if req.ClientID != nil {
+
+		if err = checkClient(tx, userID, *req.ClientID); err != nil {
+			return nil, err
+		}
+
+		// a derived place of supply belongs to the old client
+
+		if *req.ClientID != current.ClientID && req.PlaceOfSupply == nil {
+			current.PlaceOfSupply = nil
+		}
+
+		current.ClientID = *req.ClientID
+	}
+
+	if req.IssueDate != nil {
+		if current.IssueDate, err = parseDate("issue_date", *req.IssueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.ExpiryDate != nil {
+		if current.ExpiryDate, err = parseDate("expiry_date", *req.ExpiryDate); err != nil {
+			return nil, err
+		}
+	}
+
+	if current.ExpiryDate.Before(current.IssueDate) {
+		return nil, fmt.Errorf("expiry_date cannot be before issue_date: %w", domain.ErrInvalidInput)
+	}
+
+	if req.Currency != nil {
+		current.Currency = *req.Currency
+	}
+
+	if req.RoundingMode != nil && *req.RoundingMode != "" {
+		current.RoundingMode = *req.RoundingMode
+	}
+
+	if req.TaxRounding != nil && *req.TaxRounding != "" {
+		current.TaxRounding = *req.TaxRounding
+	}
+
+	previousRate := current.TaxRate
+
+	if req.TaxRate != nil {
+		current.TaxRate = *req.TaxRate
+	}
+
+	if req.DiscountAmount != nil {
+		current.DiscountAmount = *req.DiscountAmount
+	}
+
+	if req.TaxScheme != nil && *req.TaxScheme != "" {
+		current.TaxScheme = *req.TaxScheme
+	}
+
+	if req.PlaceOfSupply != nil {
+		current.PlaceOfSupply = req.PlaceOfSupply
+	}
+
+	if req.TemplateID != nil {
+		current.TemplateID = *req.TemplateID
+
+		if current.TemplateID == "" {
+			current.TemplateID = domain.TemplateDefault
+		}
+	}
+
+	if req.Notes != nil {
+		current.Notes = req.Notes
+	}
+
+	if req.TermsAndConditions != nil {
+		current.TermsAndConditions = req.TermsAndConditions
+	}
+
+	// stored lines taxed at the old estimate rate move to the new one , like on invoices
+
+	items := req.Items
+
+	if items == nil {
+
+		stored, err := getEstimateItems(tx, estimateID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = itemRequests(stored)
+
+		for _, item := range items {
+			if item.TaxRate.Cmp(previousRate) == 0 {
+				item.TaxRate = &current.TaxRate
+			}
+		}
+	}
+
+	amounts, err := s.applyAmounts(tx, current, items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM estimate_items WHERE estimate_id = $1`, estimateID); err != nil {
+		return nil, err
+	}
+
+	if err = insertLineItems(tx, "estimate_items", "estimate_id", estimateID, items, amounts.lines); err != nil {
+		return nil, err
+	}
+
+	query := `
+		      UPDATE estimates SET
+					   client_id = $1 , issue_date = $2 , expiry_date = $3 , currency = $4 , subtotal = $5 , tax_rate = $6 , tax_amount = $7 ,
+						 tax_scheme = $8 , rounding_mode = $9 , tax_rounding = $10 , place_of_supply = $11 , seller_gstin = $12 , buyer_gstin = $13 ,
+						 cgst_amount = $14 , sgst_amount = $15 , igst_amount = $16 , discount_amount = $17 , total_amount = $18 ,
+						 template_id = $19 , notes = $20 , terms_and_conditions = $21 , updated_at = $22
+					WHERE id = $23 AND user_id = $24
+		    `
+
+	_, err = tx.Exec(
+		query,
+		current.ClientID, current.IssueDate, current.ExpiryDate, current.Currency, current.Subtotal, current.TaxRate, current.TaxAmount,
+		current.TaxScheme, current.RoundingMode, current.TaxRounding, current.PlaceOfSupply, current.SellerGSTIN, current.BuyerGSTIN,
+		current.CGSTAmount, current.SGSTAmount, current.IGSTAmount, current.DiscountAmount, current.TotalAmount,
+		current.TemplateID, current.Notes, current.TermsAndConditions, time.Now(), estimateID, userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetEstimate(userID, estimateID)
+}
+
+// full estimate with its lines , client and tax summary
+
+func (s *EstimateService) GetEstimate(userID, estimateID uuid.UUID) (*domain.Estimate, error) {
+
+	estimate := &domain.Estimate{}
+
+	err := s.scanEstimate(s.db.QueryRow(`SELECT `+estimateColumns+` FROM estimates WHERE id = $1 AND user_id = $2`, estimateID, userID), estimate)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrEstimateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if estimate.Items, err = getEstimateItems(s.db, estimateID); err != nil {
+		return nil, err
+	}
+
+	client := &domain.Client{}
+
+	if err = scanClient(s.db.QueryRow(`SELECT `+clientColumns+` FROM clients WHERE id = $1`, estimate.ClientID), client); err == nil {
+		estimate.Client = client
+	}
+
+	estimate.TaxSummary = taxSummary(estimateDocument(estimate))
+
+	return estimate, nil
+}
+
+// estimates of the user , newest first , status filters when it isn't empty
+
+func (s *EstimateService) ListEstimates(userID uuid.UUID, status string) ([]*domain.Estimate, error) {
+
+	query := `SELECT ` + estimateColumns + ` FROM estimates WHERE user_id = $1`
+	args := []interface{}{userID}
+
+	if status != "" {
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := s.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	estimates := []*domain.Estimate{}
+
+	for rows.Next() {
+		estimate := &domain.Estimate{}
+
+		if err := s.scanEstimate(rows, estimate); err != nil {
+			return nil, err
+		}
+
+		estimates = append(estimates, estimate)
+	}
+
+	return estimates, rows.Err()
+}
+
+// changing the status by hand , sending hands out the client link
+// an estimate can't be sent once its expiry date has passed
+
+func (s *EstimateService) UpdateEstimateStatus(userID, estimateID uuid.UUID, req *domain.UpdateEstimateStatusRequest) (*domain.Estimate, error) {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var (
+		status  string
+		expired bool
+	)
+
+	query := `
+		      SELECT e.status , e.expiry_date < ($3::timestamptz AT TIME ZONE u.timezone)::date FROM estimates e
+					JOIN users u ON u.id = e.user_id
+					WHERE e.id = $1 AND e.user_id = $2 FOR UPDATE OF e
+		    `
+
+	now := s.clock.Now()
+
+	err = tx.QueryRow(query, estimateID, userID, now).Scan(&status, &expired)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrEstimateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = domain.ValidateEstimateTransition(status, req.Status); err != nil {
+		return nil, err
+	}
+
+	if req.Status == domain.EstimateStatusSent && expired {
+		return nil, fmt.Errorf("expiry_date has passed , move it before sending: %w", domain.ErrInvalidInput)
+	}
+
+	update := `UPDATE estimates SET status = $1 , updated_at = $2`
+
+	switch req.Status {
+	case domain.EstimateStatusSent:
+		update += ` , sent_at = $2`
+	case domain.EstimateStatusAccepted, domain.EstimateStatusDeclined:
+		update += ` , responded_at = $2`
+	}
+
+	if _, err = tx.Exec(update+` WHERE id = $3`, req.Status, now, estimateID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetEstimate(userID, estimateID)
+}
+
+// deleting an estimate , converted ones stay as the record of where their invoice came from
+
+func (s *EstimateService) DeleteEstimate(userID, estimateID uuid.UUID) error {
+
+	estimate, err := s.GetEstimate(userID, estimateID)
+
+	if err != nil {
+		return err
+	}
+
+	if estimate.InvoiceID != nil {
+		return domain.ErrEstimateConverted
+	}
+
+	_, err = s.db.Exec(`DELETE FROM estimates WHERE id = $1 AND user_id = $2 AND invoice_id IS NULL`, estimateID, userID)
+
+	return err
+}
+
+// turning an accepted estimate into a draft invoice with the same client , lines and taxes
+// the estimate row stays locked while the invoice is created , so one estimate never makes two invoices
+
+func (s *EstimateService) ConvertToInvoice(userID, estimateID uuid.UUID, req *domain.ConvertEstimateRequest) (*domain.Invoice, error) {
+
+	issueDate := s.clock.Now()
+
+	if req.IssueDate != nil {
+		var err error
+
+		if issueDate, err = parseDate("issue_date", *req.IssueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	dueDate := issueDate.AddDate(0, 0, 30)
+
+	if req.DueDate != nil {
+		var err error
+
+		if dueDate, err = parseDate("due_date", *req.DueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var (
+		status    string
+		invoiceID *uuid.UUID
+	)
+
+	err = tx.QueryRow(`SELECT status , invoice_id FROM estimates WHERE id = $1 AND user_id = $2 FOR UPDATE`, estimateID, userID).Scan(&status, &invoiceID)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrEstimateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if invoiceID != nil {
+		return nil, domain.ErrEstimateConverted
+	}
+
+	if status != domain.EstimateStatusAccepted {
+		return nil, domain.ErrEstimateNotConvertible
+	}
+
+	estimate, err := s.GetEstimate(userID, estimateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.invoiceService.CreateInvoice(userID, invoiceRequest(estimate, issueDate, dueDate))
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	_, err = tx.Exec(`UPDATE estimates SET invoice_id = $1 , converted_at = $2 , updated_at = $2 WHERE id = $3`, invoice.ID, now, estimateID)
+
+	if err == nil {
+		err = tx.Commit()
+	}
+
+	// the link couldn't be kept , the new draft goes again so the estimate can be converted later
+
+	if err != nil {
+		if delErr := s.invoiceService.DeleteInvoice(userID, invoice.ID); delErr != nil {
+			log.Printf("estimate %s: removing invoice %s after a failed conversion: %v", estimateID, invoice.ID, delErr)
+		}
+
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// create request for the invoice of an estimate
+
+func invoiceRequest(estimate *domain.Estimate, issueDate, dueDate time.Time) *domain.CreateInvoiceRequest {
+
+	return &domain.CreateInvoiceRequest{
+		ClientID:           estimate.ClientID,
+		IssueDate:          issueDate.Format(dateLayout),
+		DueDate:            dueDate.Format(dateLayout),
+		Currency:           estimate.Currency,
+		TaxRate:            estimate.TaxRate,
+		TaxScheme:          estimate.TaxScheme,
+		RoundingMode:       estimate.RoundingMode,
+		TaxRounding:        estimate.TaxRounding,
+		PlaceOfSupply:      estimate.PlaceOfSupply,
+		DiscountAmount:     estimate.DiscountAmount,
+		TemplateID:         estimate.TemplateID,
+		Notes:              estimate.Notes,
+		TermsAndConditions: estimate.TermsAndConditions,
+		Items:              itemRequests(estimate.Items),
+	}
+}
+
+// pdf of an estimate of the user , with the user for the letterhead
+
+func (s *EstimateService) GetEstimatePDF(userID, estimateID uuid.UUID) ([]byte, *domain.Estimate, error) {
+
+	estimate, err := s.GetEstimate(userID, estimateID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdfBytes, err := s.pdfService.GenerateEstimatePDF(estimate, user)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pdfBytes, estimate, nil
+}
+
+// CLIENT SIDE
+
+// estimate behind a client link , drafts are never shown
+
+func (s *EstimateService) resolve(token string) (*domain.PublicEstimate, error) {
+
+	estimateID, err := util.VerifySignedID(token, estimateLinkPurpose, s.secret)
+
+	if err != nil {
+		return nil, domain.ErrEstimateNotFound
+	}
+
+	var userID uuid.UUID
+
+	err = s.db.QueryRow(`SELECT user_id FROM estimates WHERE id = $1 AND status <> $2`, estimateID, domain.EstimateStatusDraft).Scan(&userID)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrEstimateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	estimate, err := s.GetEstimate(userID, estimateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PublicEstimate{Estimate: estimate, Business: user}, nil
+}
+
+// estimate for the client's page
+
+func (s *EstimateService) ViewEstimate(token string) (*domain.PublicEstimate, error) {
+	return s.resolve(token)
+}
+
+// estimate pdf through the client link , returns the pdf and the estimate number for the file name
+
+func (s *EstimateService) DownloadEstimatePDF(token string) ([]byte, string, error) {
+
+	public, err := s.resolve(token)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdfBytes, err := s.pdfService.GenerateEstimatePDF(public.Estimate, public.Business)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pdfBytes, public.Estimate.EstimateNumber, nil
+}
+
+// client accepting or declining a sent estimate with an optional note
+// an estimate past its expiry date is expired on the spot instead
+
+func (s *EstimateService) Respond(token string, accept bool, note string) error {
+
+	estimateID, err := util.VerifySignedID(token, estimateLinkPurpose, s.secret)
+
+	if err != nil {
+		return domain.ErrEstimateNotFound
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var (
+		status  string
+		expired bool
+	)
+
+	query := `
+		      SELECT e.status , e.expiry_date < ($2::timestamptz AT TIME ZONE u.timezone)::date FROM estimates e
+					JOIN users u ON u.id = e.user_id
+					WHERE e.id = $1 FOR UPDATE OF e
+		    `
+
+	now := s.clock.Now()
+
+	err = tx.QueryRow(query, estimateID, now).Scan(&status, &expired)
+
+	if err == sql.ErrNoRows || status == domain.EstimateStatusDraft {
+		return domain.ErrEstimateNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status != domain.EstimateStatusSent {
+		return domain.ErrEstimateClosed
+	}
+
+	if expired {
+		if _, err = tx.Exec(`UPDATE estimates SET status = $1 , updated_at = $2 WHERE id = $3`, domain.EstimateStatusExpired, now, estimateID); err != nil {
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return domain.ErrEstimateExpired
+	}
+
+	next := domain.EstimateStatusDeclined
+
+	if accept {
+		next = domain.EstimateStatusAccepted
+	}
+
+	var notePtr *string
+
+	if note != "" {
+		notePtr = &note
+	}
+
+	_, err = tx.Exec(`UPDATE estimates SET status = $1 , responded_at = $2 , client_note = $3 , updated_at = $2 WHERE id = $4`, next, now, notePtr, estimateID)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EXPIRY
+
+// background sweeper , same ticker pattern as the overdue sweep
+
+func (s *EstimateService) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.ExpireEstimates(); err != nil {
+			log.Printf("estimate expiry: %v", err)
+		} else if n > 0 {
+			log.Printf("estimate expiry: %d estimates expired", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expiring every sent estimate whose expiry date is before today in the user's timezone , returns how many were changed
+
+func (s *EstimateService) ExpireEstimates() (int, error) {
+
+	query := `
+		      UPDATE estimates e SET status = $1 , updated_at = $2
+					FROM users u
+					WHERE u.id = e.user_id AND e.status = $3 AND e.expiry_date < ($4::timestamptz AT TIME ZONE u.timezone)::date
+		    `
+
+	now := s.clock.Now()
+
+	result, err := s.db.Exec(query, domain.EstimateStatusExpired, now, domain.EstimateStatusSent, now)
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, _ := result.RowsAffected()
+
+	return int(n), nil
+}
@@ -0,0 +1,182 @@
+// exchange rate service - the manual rate table , and the rates invoices and payments are converted at
+
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/fx"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// the user's own rates , it's also the manual fx.Provider
+
+type ExchangeRateService struct {
+	db *database.DB
+}
+
+// exchange rate service function
+
+func NewExchangeRateService(db *database.DB) *ExchangeRateService {
+	return &ExchangeRateService{db: db}
+}
+
+const exchangeRateColumns = `id , user_id , from_currency , to_currency , rate , effective_date , created_at`
+
+func scanExchangeRate(row rowScanner, rate *domain.ExchangeRate) error {
+	return row.Scan(&rate.ID, &rate.UserID, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &rate.EffectiveDate, &rate.CreatedAt)
+}
+
+// all rates of the user , newest first within each pair
+
+func (s *ExchangeRateService) ListRates(userID uuid.UUID) ([]*domain.ExchangeRate, error) {
+
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates WHERE user_id = $1 ORDER BY from_currency , to_currency , effective_date DESC`
+
+	rows, err := s.db.Query(query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rates := []*domain.ExchangeRate{}
+
+	for rows.Next() {
+		rate := &domain.ExchangeRate{}
+
+		if err := scanExchangeRate(rows, rate); err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// adding a rate , or replacing the one the pair already has on that day
+
+func (s *ExchangeRateService) SetRate(userID uuid.UUID, req *domain.SetExchangeRateRequest) (*domain.ExchangeRate, error) {
+
+	from, to := strings.ToUpper(req.FromCurrency), strings.ToUpper(req.ToCurrency)
+
+	if from == to {
+		return nil, fmt.Errorf("from_currency and to_currency must differ: %w", domain.ErrInvalidInput)
+	}
+
+	effectiveDate, err := parseDate("effective_date", req.EffectiveDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		      INSERT INTO exchange_rates (id , user_id , from_currency , to_currency , rate , effective_date , created_at)
+					VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7)
+					ON CONFLICT (user_id , from_currency , to_currency , effective_date) DO UPDATE SET rate = EXCLUDED.rate
+					RETURNING ` + exchangeRateColumns
+
+	rate := &domain.ExchangeRate{}
+
+	err = scanExchangeRate(s.db.QueryRow(query, uuid.New(), userID, from, to, req.Rate, effectiveDate, time.Now()), rate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (s *ExchangeRateService) DeleteRate(userID, rateID uuid.UUID) error {
+
+	result, err := s.db.Exec(`DELETE FROM exchange_rates WHERE id = $1 AND user_id = $2`, rateID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrExchangeRateNotFound
+	}
+
+	return nil
+}
+
+// fx.Provider , the user's latest rate for the pair on or before the day , or the inverse of the opposite pair
+
+func (s *ExchangeRateService) Rate(userID uuid.UUID, from, to string, on time.Time) (money.Rate, error) {
+
+	query := `
+		      SELECT rate , from_currency = $2 FROM exchange_rates
+					WHERE user_id = $1 AND ((from_currency = $2 AND to_currency = $3) OR (from_currency = $3 AND to_currency = $2)) AND effective_date <= $4
+					ORDER BY effective_date DESC , from_currency = $2 DESC
+					LIMIT 1
+		    `
+
+	var (
+		rate   money.Rate
+		direct bool
+	)
+
+	err := s.db.QueryRow(query, userID, from, to, on.Format(dateLayout)).Scan(&rate, &direct)
+
+	if err == sql.ErrNoRows {
+		return money.Rate{}, fx.ErrRateNotFound
+	}
+
+	if err != nil {
+		return money.Rate{}, err
+	}
+
+	if !direct {
+		return rate.Inverse()
+	}
+
+	return rate, nil
+}
+
+// rate a document in currency is converted to base at , the given one or the provider's for the day
+// a document in the base currency itself is always at 1
+
+func exchangeRate(rates fx.Provider, userID uuid.UUID, currency, base string, on time.Time, given *money.Rate) (money.Rate, error) {
+
+	if strings.EqualFold(currency, base) {
+		return money.One, nil
+	}
+
+	if given != nil && !given.IsZero() {
+		return *given, nil
+	}
+
+	rate, err := rates.Rate(userID, currency, base, on)
+
+	if errors.Is(err, fx.ErrRateNotFound) {
+		return money.Rate{}, fmt.Errorf("no %s to %s rate on %s , add one or pass exchange_rate: %w", currency, base, on.Format(dateLayout), domain.ErrExchangeRateNotFound)
+	}
+
+	return rate, err
+}
+
+// the user's base currency , read inside the caller's transaction
+
+func baseCurrency(q queryer, userID uuid.UUID) (string, error) {
+
+	var base string
+
+	err := q.QueryRow(`SELECT base_currency FROM users WHERE id = $1`, userID).Scan(&base)
+
+	if err == sql.ErrNoRows {
+		return "", domain.ErrUserNotFound
+	}
+
+	return base, err
+}
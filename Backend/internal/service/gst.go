@@ -0,0 +1,283 @@
+// gst - indian goods and services tax for invoices with tax_scheme "gst"
+// every line carries an hsn / sac code and a gst rate , the tax is split into cgst + sgst when the place of supply
+// is the seller's own state and charged as igst otherwise
+
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// gst state codes , the first two characters of a gstin and the codes used for place of supply
+// 96 is for exports , 97 for other territories
+
+var gstStates = map[string]string{
+	"01": "Jammu and Kashmir",
+	"02": "Himachal Pradesh",
+	"03": "Punjab",
+	"04": "Chandigarh",
+	"05": "Uttarakhand",
+	"06": "Haryana",
+	"07": "Delhi",
+	"08": "Rajasthan",
+	"09": "Uttar Pradesh",
+	"10": "Bihar",
+	"11": "Sikkim",
+	"12": "Arunachal Pradesh",
+	"13": "Nagaland",
+	"14": "Manipur",
+	"15": "Mizoram",
+	"16": "Tripura",
+	"17": "Meghalaya",
+	"18": "Assam",
+	"19": "West Bengal",
+	"20": "Jharkhand",
+	"21": "Odisha",
+	"22": "Chhattisgarh",
+	"23": "Madhya Pradesh",
+	"24": "Gujarat",
+	"25": "Daman and Diu",
+	"26": "DNH and DD",
+	"27": "Maharashtra",
+	"29": "Karnataka",
+	"30": "Goa",
+	"31": "Lakshadweep",
+	"32": "Kerala",
+	"33": "Tamil Nadu",
+	"34": "Puducherry",
+	"35": "Andaman and Nicobar Islands",
+	"36": "Telangana",
+	"37": "Andhra Pradesh",
+	"38": "Ladakh",
+	"96": "Other Country",
+	"97": "Other Territory",
+}
+
+// other spellings of state names clients are saved with
+
+var gstStateAliases = map[string]string{
+	"dadra and nagar haveli and daman and diu": "26",
+	"dadra and nagar haveli":                   "26",
+	"new delhi":                                "07",
+	"nct of delhi":                             "07",
+	"orissa":                                   "21",
+	"pondicherry":                              "34",
+	"j&k":                                      "01",
+	"andaman":                                  "35",
+}
+
+const gstExportState = "96"
+
+// gst slabs a line can be taxed at , in percent
+
+var gstRates = []string{"0", "0.1", "0.25", "1", "1.5", "3", "5", "6", "7.5", "12", "18", "28", "40"}
+
+var (
+	gstinPattern  = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+	hsnSACPattern = regexp.MustCompile(`^[0-9]{4}([0-9]{2}){0,2}$`)
+)
+
+const gstinChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// checking a gstin's format , state code and the check character at the end
+
+func validGSTIN(gstin string) bool {
+
+	if !gstinPattern.MatchString(gstin) {
+		return false
+	}
+
+	if _, ok := gstStates[gstin[:2]]; !ok {
+		return false
+	}
+
+	sum := 0
+
+	for i := 0; i < 14; i++ {
+		product := strings.IndexByte(gstinChars, gstin[i]) * (i%2 + 1)
+		sum += product/36 + product%36
+	}
+
+	return gstin[14] == gstinChars[(36-sum%36)%36]
+}
+
+// state code for a place of supply given as a code ("29") or a state name ("Karnataka") , "" when it's neither
+
+func gstStateCode(value string) string {
+
+	value = strings.TrimSpace(value)
+
+	if len(value) == 1 {
+		value = "0" + value
+	}
+
+	if _, ok := gstStates[value]; ok {
+		return value
+	}
+
+	name := strings.ToLower(value)
+
+	if code, ok := gstStateAliases[name]; ok {
+		return code
+	}
+
+	for code, state := range gstStates {
+		if strings.ToLower(state) == name {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// "Karnataka (29)" as printed on invoices
+
+func gstStateLabel(code string) string {
+	return fmt.Sprintf("%s (%s)", gstStates[code], code)
+}
+
+func validGSTRate(rate money.Decimal) bool {
+
+	for _, r := range gstRates {
+		if money.MustParse(r).Cmp(rate) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitting a line's tax into cgst and sgst , an odd paisa goes to cgst whatever the rounding mode
+
+func splitGST(tax money.Decimal, currency string) (cgst, sgst money.Decimal) {
+
+	cgst = tax.Div(2, money.MinorUnits(currency), money.HalfUp)
+
+	return cgst, tax.Sub(cgst)
+}
+
+// what the gst engine worked out for an invoice being saved
+
+type gstInvoice struct {
+	placeOfSupply string
+	sellerGSTIN   string
+	buyerGSTIN    *string
+	cgst          money.Decimal
+	sgst          money.Decimal
+	igst          money.Decimal
+}
+
+func (g *gstInvoice) taxAmount() money.Decimal {
+	return g.cgst.Add(g.sgst).Add(g.igst)
+}
+
+// applying gst to an invoice being created or edited , inside the caller's transaction
+// the seller's gstin is the user's tax id and the buyer's the client's (when it is one) , both are copied onto the invoice
+// place of supply is the given one , else the state of the buyer's gstin , else the client's state (or 96 for clients abroad)
+
+func calculateGST(q queryer, userID, clientID uuid.UUID, currency string, discountAmount money.Decimal, placeOfSupply *string, items []*domain.CreateInvoiceItemReq, lines []lineTotals) (*gstInvoice, error) {
+
+	if currency != "INR" {
+		return nil, fmt.Errorf("gst invoices must be in INR: %w", domain.ErrInvalidInput)
+	}
+
+	// an invoice level discount would change every line's taxable value , line discounts are used instead
+
+	if discountAmount.Sign() > 0 {
+		return nil, fmt.Errorf("gst invoices can't have a discount_amount , discount the lines instead: %w", domain.ErrInvalidInput)
+	}
+
+	var sellerTaxID sql.NullString
+
+	if err := q.QueryRow(`SELECT tax_id FROM users WHERE id = $1`, userID).Scan(&sellerTaxID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	g := &gstInvoice{sellerGSTIN: strings.ToUpper(strings.TrimSpace(sellerTaxID.String))}
+
+	if !validGSTIN(g.sellerGSTIN) {
+		return nil, fmt.Errorf("your tax_id must be a valid GSTIN to issue gst invoices: %w", domain.ErrInvalidInput)
+	}
+
+	var buyerTaxID, state, country sql.NullString
+
+	err := q.QueryRow(`SELECT tax_id , state , country FROM clients WHERE id = $1 AND user_id = $2`, clientID, userID).Scan(&buyerTaxID, &state, &country)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrClientNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a tax id shaped like a gstin has to be a valid one , anything else (a foreign vat number) means an unregistered buyer
+
+	if buyer := strings.ToUpper(strings.TrimSpace(buyerTaxID.String)); buyer != "" && gstinPattern.MatchString(buyer) {
+		if !validGSTIN(buyer) {
+			return nil, fmt.Errorf("client tax_id %s is not a valid GSTIN: %w", buyer, domain.ErrInvalidInput)
+		}
+
+		g.buyerGSTIN = &buyer
+	}
+
+	switch {
+	case placeOfSupply != nil && *placeOfSupply != "":
+		if g.placeOfSupply = gstStateCode(*placeOfSupply); g.placeOfSupply == "" {
+			return nil, fmt.Errorf("place_of_supply %q is not a gst state code or state name: %w", *placeOfSupply, domain.ErrInvalidInput)
+		}
+
+	case g.buyerGSTIN != nil:
+		g.placeOfSupply = (*g.buyerGSTIN)[:2]
+
+	case country.Valid && country.String != "" && !strings.EqualFold(strings.TrimSpace(country.String), "india") && !strings.EqualFold(strings.TrimSpace(country.String), "in"):
+		g.placeOfSupply = gstExportState
+
+	case state.Valid && gstStateCode(state.String) != "":
+		g.placeOfSupply = gstStateCode(state.String)
+
+	default:
+		return nil, fmt.Errorf("place_of_supply is required , the client has no GSTIN or known state: %w", domain.ErrInvalidInput)
+	}
+
+	intraState := g.placeOfSupply == g.sellerGSTIN[:2]
+
+	for i, item := range items {
+
+		if item.HSNSAC == nil || !hsnSACPattern.MatchString(strings.TrimSpace(*item.HSNSAC)) {
+			return nil, fmt.Errorf("item %d needs an hsn_sac code of 4 , 6 or 8 digits: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		if !validGSTRate(lines[i].taxRate) {
+			return nil, fmt.Errorf("item %d tax_rate %s is not a gst rate: %w", i+1, lines[i].taxRate, domain.ErrInvalidInput)
+		}
+
+		tax := lines[i].tax
+
+		if intraState {
+			cgst, sgst := splitGST(tax, currency)
+			g.cgst = g.cgst.Add(cgst)
+			g.sgst = g.sgst.Add(sgst)
+		} else {
+			g.igst = g.igst.Add(tax)
+		}
+	}
+
+	return g, nil
+}
+
+// whether a stored gst invoice is taxed as cgst + sgst
+
+func gstIntraState(invoice *domain.Invoice) bool {
+	return invoice.SellerGSTIN != nil && invoice.PlaceOfSupply != nil && len(*invoice.SellerGSTIN) >= 2 && (*invoice.SellerGSTIN)[:2] == *invoice.PlaceOfSupply
+}
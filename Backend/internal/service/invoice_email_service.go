@@ -0,0 +1,236 @@
+// invoice email service - sending an invoice to its client with the pdf attached
+
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
+	"github.com/google/uuid"
+)
+
+type InvoiceEmailService struct {
+	db             *database.DB
+	invoiceService *InvoiceService
+	userService    *UserService
+	pdfService     *PDFService
+	tracking       *TrackingService
+	mailer         mailer.Mailer
+}
+
+// invoice email service function
+
+func NewInvoiceEmailService(db *database.DB, invoiceService *InvoiceService, userService *UserService, pdfService *PDFService, tracking *TrackingService, mailer mailer.Mailer) *InvoiceEmailService {
+	return &InvoiceEmailService{
+		db:             db,
+		invoiceService: invoiceService,
+		userService:    userService,
+		pdfService:     pdfService,
+		tracking:       tracking,
+		mailer:         mailer,
+	}
+}
+
+// emailing the invoice pdf to the client's address
+// replies go to the user's reply-to address , or their account email when it isn't set
+// a draft moves to sent once the email is out , other statuses are left as they are
+// the html version of the email carries a tracking pixel for this one email
+// the attached pdf is archived before sending , so there's always a copy of exactly what the client got
+// the account's own email has to be verified first
+
+func (s *InvoiceEmailService) SendInvoice(userID, invoiceID uuid.UUID, req *domain.SendInvoiceRequest) (*domain.Invoice, error) {
+
+	invoice, err := s.invoiceService.GetInvoiceByID(userID, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice.Status == domain.InvoiceStatusCanceled {
+		return nil, domain.ErrInvoiceNotSendable
+	}
+
+	if invoice.Client == nil || invoice.Client.Email == nil || *invoice.Client.Email == "" {
+		return nil, domain.ErrClientEmailMissing
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.EmailVerified {
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	// the pdf shows the status the invoice has once the email is out , a draft isn't printed as a draft
+	// the fingerprint doesn't cover updated_at , so later downloads of the sent invoice get this same cached file
+
+	sending := *invoice
+
+	if sending.Status == domain.InvoiceStatusDraft {
+		sending.Status = domain.InvoiceStatusSent
+	}
+
+	pdfBytes, _, err := s.pdfService.GetInvoicePDF(&sending, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	subject, body := defaultInvoiceEmail(invoice, user)
+
+	if req.Subject != nil && *req.Subject != "" {
+		subject = *req.Subject
+	}
+
+	if req.Body != nil && *req.Body != "" {
+		body = *req.Body
+	}
+
+	replyTo := user.Email
+
+	if user.ReplyToEmail != nil && *user.ReplyToEmail != "" {
+		replyTo = *user.ReplyToEmail
+	}
+
+	// id of the email_sent event , known up front so the pixel can point at it
+
+	emailEventID := uuid.New()
+
+	archive, err := s.pdfService.storeArchive(invoice, emailEventID, pdfBytes)
+
+	if err != nil {
+		return nil, fmt.Errorf("archiving invoice pdf: %w", err)
+	}
+
+	msg := &mailer.Message{
+		To:       []string{*invoice.Client.Email},
+		Cc:       req.Cc,
+		Bcc:      req.Bcc,
+		ReplyTo:  replyTo,
+		Subject:  subject,
+		Body:     body,
+		HTMLBody: htmlEmailBody(body, s.tracking.PixelURL(emailEventID)),
+		Attachments: []*mailer.Attachment{
+			{
+				Filename:    invoice.InvoiceNumber + ".pdf",
+				ContentType: "application/pdf",
+				Data:        pdfBytes,
+			},
+		},
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		s.pdfService.storage.Delete(archive.StorageKey)
+		return nil, fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+	}
+
+	if err := s.markSent(userID, invoiceID, emailEventID, archive, msg); err != nil {
+		return nil, err
+	}
+
+	return s.invoiceService.GetInvoiceByID(userID, invoiceID)
+}
+
+// recording the email and its archived pdf on the invoice and its timeline , moving a draft to sent
+
+func (s *InvoiceEmailService) markSent(userID, invoiceID, emailEventID uuid.UUID, archive *domain.InvoicePDFArchive, msg *mailer.Message) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	// status is read again under the lock , it may have changed while the email was going out
+
+	var status string
+
+	err = tx.QueryRow(`SELECT status FROM invoices WHERE id = $1 AND user_id = $2 FOR UPDATE`, invoiceID, userID).Scan(&status)
+
+	if err == sql.ErrNoRows {
+		return domain.ErrInvoiceNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if _, err = tx.Exec(`UPDATE invoices SET email_sent = true , email_sent_at = $1 , updated_at = $1 WHERE id = $2`, now, invoiceID); err != nil {
+		return err
+	}
+
+	details := "to: " + strings.Join(msg.To, ", ")
+
+	if len(msg.Cc) > 0 {
+		details += " ; cc: " + strings.Join(msg.Cc, ", ")
+	}
+
+	event := &domain.InvoiceEvent{
+		ID:         emailEventID,
+		InvoiceID:  invoiceID,
+		Type:       domain.InvoiceEventEmailSent,
+		RelatedID:  &archive.ID,
+		Details:    &details,
+		OccurredAt: now,
+	}
+
+	if err = recordInvoiceEvent(tx, event); err != nil {
+		return err
+	}
+
+	if err = recordPDFArchive(tx, archive); err != nil {
+		return err
+	}
+
+	if status == domain.InvoiceStatusDraft {
+
+		if _, err = tx.Exec(`UPDATE invoices SET status = $1 WHERE id = $2`, domain.InvoiceStatusSent, invoiceID); err != nil {
+			return err
+		}
+
+		reason := "sent by email"
+
+		if err = recordStatusChange(tx, invoiceID, &userID, status, domain.InvoiceStatusSent, &reason); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// subject and body used when the request doesn't give its own
+
+func defaultInvoiceEmail(invoice *domain.Invoice, user *domain.User) (string, string) {
+
+	business := user.DisplayName()
+
+	subject := fmt.Sprintf("Invoice %s from %s", invoice.InvoiceNumber, business)
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nPlease find attached invoice %s for %s %s, due on %s.\n\nThanks,\n%s",
+		invoice.Client.Name, invoice.InvoiceNumber, invoice.Currency, formatAmount(invoice.Currency, invoice.BalanceDue), invoice.DueDate.Format("January 2, 2006"), business,
+	)
+
+	return subject, body
+}
+
+// html version of a plain text body , with the tracking pixel at the end
+
+func htmlEmailBody(body, pixelURL string) string {
+
+	escaped := strings.ReplaceAll(html.EscapeString(body), "\n", "<br>\n")
+
+	return "<html><body>\n" + escaped + "\n<img src=\"" + html.EscapeString(pixelURL) + "\" width=\"1\" height=\"1\" alt=\"\" style=\"display:none\">\n</body></html>"
+}
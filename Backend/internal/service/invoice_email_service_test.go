@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
+	"github.com/Suthar345Piyush/invoicego/internal/storage"
+)
+
+// storage kept in memory
+
+type memoryStorage map[string][]byte
+
+func (m memoryStorage) Put(key string, data []byte, contentType string) error {
+	m[key] = data
+	return nil
+}
+
+func (m memoryStorage) Get(key string) ([]byte, error) {
+
+	data, ok := m[key]
+
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return data, nil
+}
+
+func (m memoryStorage) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+// mailer that keeps what it was asked to send
+
+type sentMail []*mailer.Message
+
+func (m *sentMail) Send(msg *mailer.Message) error {
+	*m = append(*m, msg)
+	return nil
+}
+
+// the archived copy of a sent draft is byte for byte the attachment , and both show the invoice as sent
+
+func TestSendInvoiceArchivesTheSentPDF(t *testing.T) {
+
+	db := newTestDB(t)
+
+	userID := insertTestUser(t, db, "UTC")
+	invoiceID := insertTestInvoice(t, db, userID, domain.InvoiceStatusDraft, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC))
+
+	if _, err := db.Exec(`UPDATE users SET email_verified = true WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	pdfService := newTestPDFService(t)
+	pdfService.db, pdfService.storage = db, memoryStorage{}
+
+	userService := NewUserService(db)
+	mail := &sentMail{}
+
+	sender := NewInvoiceEmailService(db, NewInvoiceService(db, userService, nil), userService, pdfService, NewTrackingService(db, "http://localhost", "secret"), mail)
+
+	invoice, err := sender.SendInvoice(userID, invoiceID, &domain.SendInvoiceRequest{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if invoice.Status != domain.InvoiceStatusSent {
+		t.Errorf("invoice is %s after sending , want sent", invoice.Status)
+	}
+
+	if len(*mail) != 1 || len((*mail)[0].Attachments) != 1 {
+		t.Fatalf("sent %d emails , want 1 with the pdf attached", len(*mail))
+	}
+
+	attached := (*mail)[0].Attachments[0].Data
+
+	archives, err := pdfService.ListArchives(userID, invoiceID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archives) != 1 {
+		t.Fatalf("%d archives , want 1", len(archives))
+	}
+
+	_, archived, err := pdfService.GetArchive(userID, invoiceID, archives[0].ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(archived, attached) {
+		t.Error("archived pdf differs from the emailed one")
+	}
+
+	text := pdfPlainText(t, archived)
+
+	if !strings.Contains(text, domain.InvoiceStatusSent) || strings.Contains(text, domain.InvoiceStatusDraft) {
+		t.Errorf("archived pdf doesn't show the invoice as sent:\n%s", text)
+	}
+
+	// downloading the invoice afterwards serves that same render from the cache
+
+	user, err := userService.GetUserByID(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downloaded, _, err := pdfService.GetInvoicePDF(invoice, user)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, attached) {
+		t.Error("downloaded pdf differs from the emailed one")
+	}
+}
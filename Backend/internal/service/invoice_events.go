@@ -0,0 +1,112 @@
+// invoice events - writing events and reading the merged invoice timeline
+
+package service
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/google/uuid"
+)
+
+// writing one event inside the caller's transaction , id and time are filled when empty
+
+func recordInvoiceEvent(tx *sql.Tx, event *domain.InvoiceEvent) error {
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	query := `
+		      INSERT INTO invoice_events (
+					   id , invoice_id , event_type , related_id , ip_address , user_agent , details , occurred_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8)
+		    `
+
+	_, err := tx.Exec(query, event.ID, event.InvoiceID, event.Type, event.RelatedID, event.IPAddress, event.UserAgent, event.Details, event.OccurredAt)
+
+	return err
+}
+
+// getting the events of an invoice , oldest first
+
+func getInvoiceEvents(q queryer, invoiceID uuid.UUID) ([]*domain.InvoiceEvent, error) {
+
+	query := `
+		     SELECT id , invoice_id , event_type , related_id , ip_address , user_agent , details , occurred_at
+				 FROM invoice_events WHERE invoice_id = $1 ORDER BY occurred_at , id
+		   `
+
+	rows, err := q.Query(query, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := []*domain.InvoiceEvent{}
+
+	for rows.Next() {
+		event := &domain.InvoiceEvent{}
+
+		err := rows.Scan(&event.ID, &event.InvoiceID, &event.Type, &event.RelatedID, &event.IPAddress, &event.UserAgent, &event.Details, &event.OccurredAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// status changes and events of an invoice merged in time order
+
+func (s *InvoiceService) GetInvoiceTimeline(userID, invoiceID uuid.UUID) ([]*domain.TimelineEntry, error) {
+
+	// also checks that the invoice belongs to the user
+
+	history, err := s.GetInvoiceStatusHistory(userID, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := getInvoiceEvents(s.db, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]*domain.TimelineEntry, 0, len(history)+len(events))
+
+	for _, change := range history {
+		timeline = append(timeline, &domain.TimelineEntry{
+			Kind:         domain.TimelineKindStatusChange,
+			OccurredAt:   change.ChangedAt,
+			StatusChange: change,
+		})
+	}
+
+	for _, event := range events {
+		timeline = append(timeline, &domain.TimelineEntry{
+			Kind:       domain.TimelineKindEvent,
+			OccurredAt: event.OccurredAt,
+			Event:      event,
+		})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].OccurredAt.Before(timeline[j].OccurredAt)
+	})
+
+	return timeline, nil
+}
@@ -0,0 +1,83 @@
+// invoice status history - writing and reading the status timeline
+
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/google/uuid"
+)
+
+// writing one status change inside the caller's transaction
+// empty from means the invoice was just created , nil changedBy means the system made the change
+
+func recordStatusChange(tx *sql.Tx, invoiceID uuid.UUID, changedBy *uuid.UUID, from, to string, reason *string) error {
+
+	var fromStatus *string
+
+	if from != "" {
+		fromStatus = &from
+	}
+
+	query := `
+		      INSERT INTO invoice_status_history (
+					   id , invoice_id , changed_by , from_status , to_status , reason , changed_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7)
+		    `
+
+	_, err := tx.Exec(query, uuid.New(), invoiceID, changedBy, fromStatus, to, reason, time.Now())
+
+	return err
+}
+
+// getting the status timeline of an invoice , oldest change first
+
+func (s *InvoiceService) GetInvoiceStatusHistory(userID, invoiceID uuid.UUID) ([]*domain.InvoiceStatusChange, error) {
+
+	// invoice must belong to the user
+
+	var exists bool
+
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM invoices WHERE id = $1 AND user_id = $2)`, invoiceID, userID).Scan(&exists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	query := `
+		     SELECT id , invoice_id , changed_by , from_status , to_status , reason , changed_at
+				 FROM invoice_status_history WHERE invoice_id = $1 ORDER BY changed_at , id
+		   `
+
+	rows, err := s.db.Query(query, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := []*domain.InvoiceStatusChange{}
+
+	for rows.Next() {
+		change := &domain.InvoiceStatusChange{}
+
+		err := rows.Scan(
+			&change.ID, &change.InvoiceID, &change.ChangedBy, &change.FromStatus, &change.ToStatus, &change.Reason, &change.ChangedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
@@ -5,24 +5,30 @@ package service
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Suthar345Piyush/invoicego/internal/database"
 	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/fx"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
 	"github.com/google/uuid"
 )
 
 type InvoiceService struct {
 	db          *database.DB
 	userService *UserService
+	rates       fx.Provider
 }
 
 // invoice service function
 
-func NewInvoiceService(db *database.DB, userService *UserService) *InvoiceService {
+func NewInvoiceService(db *database.DB, userService *UserService, rates fx.Provider) *InvoiceService {
 	return &InvoiceService{
 		db:          db,
 		userService: userService,
+		rates:       rates,
 	}
 }
 
@@ -30,47 +36,126 @@ func NewInvoiceService(db *database.DB, userService *UserService) *InvoiceServic
 
 func (s *InvoiceService) CreateInvoice(userID uuid.UUID, req *domain.CreateInvoiceRequest) (*domain.Invoice, error) {
 
-	// getting user to checking his subscription limits
+	// starting initial transaction
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// aborting the transaction before , complete function execution
+
+	defer tx.Rollback()
 
-	user, err := s.userService.GetUserByID(userID)
+	invoice, err := s.createInvoiceTx(tx, userID, req)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// commiting transaction
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	//  getting full invoice with the items and client
+
+	return s.GetInvoiceByID(userID, invoice.ID)
+
+}
+
+// creating the invoice inside the caller's transaction
+// used by CreateInvoice and by the recurring generator , which claims its period in the same transaction
+
+func (s *InvoiceService) createInvoiceTx(tx *sql.Tx, userID uuid.UUID, req *domain.CreateInvoiceRequest) (*domain.Invoice, error) {
+
+	// locking the user row to check his subscription limits and to take the next invoice number
+
+	var (
+		subscriptionTier    string
+		monthlyInvoiceCount int
+		monthlyInvoiceLimit int
+		invoiceNumberPrefix string
+		nextInvoiceNumber   int
+		baseCurrency        string
+		countPeriod         sql.NullTime
+	)
+
+	userQuery := `SELECT subscription_tier , monthly_invoice_count , monthly_invoice_period , monthly_invoice_limit , invoice_number_prefix , next_invoice_number , base_currency FROM users WHERE id = $1 AND is_active = true FOR UPDATE`
+
+	err := tx.QueryRow(userQuery, userID).Scan(&subscriptionTier, &monthlyInvoiceCount, &countPeriod, &monthlyInvoiceLimit, &invoiceNumberPrefix, &nextInvoiceNumber, &baseCurrency)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the count belongs to the month in monthly_invoice_period , it starts again from zero in a new month
+
+	period := invoiceCountPeriod(time.Now())
+
+	if !countPeriod.Valid || !invoiceCountPeriod(countPeriod.Time).Equal(period) {
+		monthlyInvoiceCount = 0
+	}
+
 	// initially free tier limit check
 
-	if user.SubscriptionTier == "free" && user.MonthlyInvoiceCount >= user.MonthlyInvoiceLimit {
+	if subscriptionTier == "free" && monthlyInvoiceCount >= monthlyInvoiceLimit {
 		return nil, domain.ErrInvoiceLimitExceeded
 	}
 
 	// parsing dates
 
-	issueDate, err := time.Parse("2026-02-20", req.IssueDate)
+	issueDate, err := parseDate("issue_date", req.IssueDate)
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid issue_date format, use YYYY-MM-DD")
+		return nil, err
 	}
 
-	dueDate, err := time.Parse("2026-02-20", req.DueDate)
+	dueDate, err := parseDate("due_date", req.DueDate)
 	if err != nil {
-		return nil, fmt.Errorf("invalid due_date format , user YYYY-MM-DD")
+		return nil, err
+	}
+
+	// rate to the base currency on the issue date , kept with the invoice so reports don't move when rates do
+
+	exchangeRate, err := exchangeRate(s.rates, userID, req.Currency, baseCurrency, issueDate, req.ExchangeRate)
+
+	if err != nil {
+		return nil, err
 	}
 
 	// calculatin of amounts
 
-	subtotal := 0.0
+	taxScheme := req.TaxScheme
 
-	for _, item := range req.Items {
-		subtotal += item.Quantity * item.UnitPrice
+	if taxScheme == "" {
+		taxScheme = domain.TaxSchemeFlat
 	}
 
-	taxAmount := (subtotal * req.TaxRate) / 100
-	totalAmount := subtotal + taxAmount - req.DiscountAmount
+	roundingMode, taxRounding := req.RoundingMode, req.TaxRounding
+
+	if roundingMode == "" {
+		roundingMode = string(money.HalfUp)
+	}
+
+	if taxRounding == "" {
+		taxRounding = domain.TaxRoundingLine
+	}
+
+	amounts, err := s.invoiceTax(tx, userID, req.ClientID, taxScheme, req.Currency, roundingMode, taxRounding, req.TaxRate, req.DiscountAmount, req.PlaceOfSupply, req.Items)
+
+	if err != nil {
+		return nil, err
+	}
 
 	// generating invoice number
 
-	invoiceNumber := fmt.Sprintf("%s-%04d", user.InvoiceNumberPrefix, user.NextInvoiceNumber)
+	invoiceNumber := fmt.Sprintf("%s-%04d", invoiceNumberPrefix, nextInvoiceNumber)
 
 	// setting defualt template
 	templateID := req.TemplateID
@@ -91,11 +176,16 @@ func (s *InvoiceService) CreateInvoice(userID uuid.UUID, req *domain.CreateInvoi
 		IssueDate:          issueDate,
 		DueDate:            dueDate,
 		Currency:           req.Currency,
-		Subtotal:           subtotal,
+		BaseCurrency:       baseCurrency,
+		ExchangeRate:       exchangeRate,
+		Subtotal:           amounts.subtotal,
 		TaxRate:            req.TaxRate,
-		TaxAmount:          taxAmount,
+		TaxAmount:          amounts.taxAmount,
+		TaxScheme:          taxScheme,
+		RoundingMode:       roundingMode,
+		TaxRounding:        taxRounding,
 		DiscountAmount:     req.DiscountAmount,
-		TotalAmount:        totalAmount,
+		TotalAmount:        amounts.totalAmount,
 		TemplateID:         templateID,
 		Notes:              req.Notes,
 		TermsAndConditions: req.TermsAndConditions,
@@ -105,28 +195,26 @@ func (s *InvoiceService) CreateInvoice(userID uuid.UUID, req *domain.CreateInvoi
 		UpdatedAt:          time.Now(),
 	}
 
-	// starting initial transaction
-
-	tx, err := s.db.Begin()
-	if err != nil {
-		return nil, err
+	if gst := amounts.gst; gst != nil {
+		invoice.PlaceOfSupply = &gst.placeOfSupply
+		invoice.SellerGSTIN = &gst.sellerGSTIN
+		invoice.BuyerGSTIN = gst.buyerGSTIN
+		invoice.CGSTAmount, invoice.SGSTAmount, invoice.IGSTAmount = gst.cgst, gst.sgst, gst.igst
 	}
 
-	// aborting the transaction before , complete function execution
-
-	defer tx.Rollback()
-
 	// insert into invoices table
 
 	invoiceQuery :=
 
 		`INSERT INTO invoices (
-			   id , user_id , client_id , invoice_number , status , issue_date , due_date , currency , subtotal , tax_rate , tax_amount , discount_amount , total_amount , template_id , notes , terms_and_conditions , email_sent , email_opened , created_at , updated_at   
-		 ) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $12 , $13 , $14 , $15 , $16 , $17 , $18 , $19 , $20)`
+			   id , user_id , client_id , invoice_number , status , issue_date , due_date , currency , subtotal , tax_rate , tax_amount , discount_amount , total_amount , template_id , notes , terms_and_conditions , email_sent , email_opened , created_at , updated_at ,
+				 tax_scheme , place_of_supply , seller_gstin , buyer_gstin , cgst_amount , sgst_amount , igst_amount , rounding_mode , tax_rounding , base_currency , exchange_rate
+		 ) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $13 , $14 , $15 , $16 , $17 , $18 , $19 , $20 , $21 , $22 , $23 , $24 , $25 , $26 , $27 , $28 , $29 , $30 , $31)`
 
 	_, err = tx.Exec(
 		invoiceQuery,
 		invoice.ID, invoice.UserID, invoice.ClientID, invoice.InvoiceNumber, invoice.Status, invoice.IssueDate, invoice.DueDate, invoice.Currency, invoice.Subtotal, invoice.TaxRate, invoice.TaxAmount, invoice.DiscountAmount, invoice.TotalAmount, invoice.TemplateID, invoice.Notes, invoice.TermsAndConditions, invoice.EmailSent, invoice.EmailOpened, invoice.CreatedAt, invoice.UpdatedAt,
+		invoice.TaxScheme, invoice.PlaceOfSupply, invoice.SellerGSTIN, invoice.BuyerGSTIN, invoice.CGSTAmount, invoice.SGSTAmount, invoice.IGSTAmount, invoice.RoundingMode, invoice.TaxRounding, invoice.BaseCurrency, invoice.ExchangeRate,
 	)
 
 	if err != nil {
@@ -135,55 +223,295 @@ func (s *InvoiceService) CreateInvoice(userID uuid.UUID, req *domain.CreateInvoi
 
 	// inserting invoice items into table
 
-	itemQuery := `
-		      INSERT INTO invoice_items (
-					  	 id , invoice_id , description , quantity , unit_price , amount , sort_order , created_at , updated_at
-					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9)
+	if err = insertInvoiceItems(tx, invoice.ID, req.Items, amounts.lines); err != nil {
+		return nil, err
+	}
+
+	// first row of the status timeline
+
+	if err = recordStatusChange(tx, invoice.ID, &userID, "", invoice.Status, nil); err != nil {
+		return nil, err
+	}
+
+	// updating users table  next invoice number and  monthly count  , both by one
+
+	updateUserQuery :=
+
+		` UPDATE users SET 
+					  next_invoice_number  = next_invoice_number + 1,
+						monthly_invoice_count = $1,
+						monthly_invoice_period = $2,
+						updated_at = $3 WHERE id = $4
+				  `
+
+	_, err = tx.Exec(updateUserQuery, monthlyInvoiceCount+1, period, time.Now(), userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+
+}
+
+// first day of the month the free tier count is kept for , months are taken in UTC
+
+func invoiceCountPeriod(t time.Time) time.Time {
+
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// function for editing a draft invoice
+// only the fields present in the request are changed , items are replaced as a whole when given
+// totals are always recomputed the same way as in CreateInvoice
+
+func (s *InvoiceService) UpdateInvoice(userID, invoiceID uuid.UUID, req *domain.UpdateInvoiceRequest) (*domain.Invoice, error) {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// locking the invoice row , so two edits can't interleave
+
+	current := &domain.Invoice{}
+
+	lockQuery := `
+		      SELECT status , client_id , issue_date , due_date , currency , tax_rate , discount_amount , template_id , notes , terms_and_conditions , tax_scheme , place_of_supply , rounding_mode , tax_rounding , base_currency , exchange_rate
+					FROM invoices WHERE id = $1 AND user_id = $2 FOR UPDATE
 		    `
 
-	// creating item id and  amount = item(quantity * unitPrice)
+	err = tx.QueryRow(lockQuery, invoiceID, userID).Scan(
+		&current.Status, &current.ClientID, &current.IssueDate, &current.DueDate, &current.Currency, &current.TaxRate, &current.DiscountAmount, &current.TemplateID, &current.Notes, &current.TermsAndConditions, &current.TaxScheme, &current.PlaceOfSupply, &current.RoundingMode, &current.TaxRounding, &current.BaseCurrency, &current.ExchangeRate,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
 
-	for i, item := range req.Items {
+	// editing is only allowed while the invoice is still a draft
 
-		itemID := uuid.New()
-		amount := item.Quantity * item.UnitPrice
+	if current.Status != domain.InvoiceStatusDraft {
+		return nil, domain.ErrInvoiceNotEditable
+	}
 
-		_, err = tx.Exec(
-			itemQuery,
-			itemID, invoice.ID, item.Description, item.Quantity, item.UnitPrice, amount, i, time.Now(), time.Now(),
-		)
+	// applying header fields
+
+	if req.ClientID != nil {
+
+		// new client must belong to the same user
+
+		var exists bool
+
+		err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM clients WHERE id = $1 AND user_id = $2 AND is_active = true)`, *req.ClientID, userID).Scan(&exists)
 
 		if err != nil {
 			return nil, err
 		}
 
+		if !exists {
+			return nil, domain.ErrClientNotFound
+		}
+
+		// a derived place of supply belongs to the old client , it's worked out again below
+
+		if *req.ClientID != current.ClientID && req.PlaceOfSupply == nil {
+			current.PlaceOfSupply = nil
+		}
+
+		current.ClientID = *req.ClientID
 	}
 
-	// updating users table  next invoice number and  monthly count  , both by one
+	if req.IssueDate != nil {
+		if current.IssueDate, err = parseDate("issue_date", *req.IssueDate); err != nil {
+			return nil, err
+		}
+	}
 
-	updateUserQuery :=
+	if req.DueDate != nil {
+		if current.DueDate, err = parseDate("due_date", *req.DueDate); err != nil {
+			return nil, err
+		}
+	}
 
-		` UPDATE users SET 
-					  next_invoice_number  = next_invoice_number + 1,
-						monthly_invoice_count = monthly_invoice_count + 1,
-						updated_at = $1 WHERE id = $2
-				  `
+	if current.DueDate.Before(current.IssueDate) {
+		return nil, fmt.Errorf("due_date cannot be before issue_date: %w", domain.ErrInvalidInput)
+	}
+
+	// the rate is taken again for a new currency or issue date , against the base currency the invoice was made in
+
+	if req.ExchangeRate != nil || (req.Currency != nil && *req.Currency != current.Currency) || req.IssueDate != nil {
+
+		currency := current.Currency
+
+		if req.Currency != nil {
+			currency = *req.Currency
+		}
+
+		if current.ExchangeRate, err = exchangeRate(s.rates, userID, currency, current.BaseCurrency, current.IssueDate, req.ExchangeRate); err != nil {
+			return nil, err
+		}
+	}
+
+	// stored lines are worked out again when anything their amounts depend on changes
+
+	linesChanged := false
+
+	if req.Currency != nil {
+		linesChanged = linesChanged || *req.Currency != current.Currency
+		current.Currency = *req.Currency
+	}
+
+	if req.RoundingMode != nil && *req.RoundingMode != "" {
+		linesChanged = linesChanged || *req.RoundingMode != current.RoundingMode
+		current.RoundingMode = *req.RoundingMode
+	}
+
+	if req.TaxRounding != nil && *req.TaxRounding != "" {
+		linesChanged = linesChanged || *req.TaxRounding != current.TaxRounding
+		current.TaxRounding = *req.TaxRounding
+	}
+
+	previousRate := current.TaxRate
+
+	if req.TaxRate != nil {
+		current.TaxRate = *req.TaxRate
+	}
+
+	if req.DiscountAmount != nil {
+		current.DiscountAmount = *req.DiscountAmount
+	}
+
+	if req.TaxScheme != nil && *req.TaxScheme != "" {
+		current.TaxScheme = *req.TaxScheme
+	}
+
+	// "" goes back to working the place of supply out from the client
 
-	_, err = tx.Exec(updateUserQuery, time.Now(), userID)
+	if req.PlaceOfSupply != nil {
+		current.PlaceOfSupply = req.PlaceOfSupply
+	}
+
+	if req.TemplateID != nil {
+		current.TemplateID = *req.TemplateID
+
+		if current.TemplateID == "" {
+			current.TemplateID = domain.TemplateDefault
+		}
+	}
+
+	if req.Notes != nil {
+		current.Notes = req.Notes
+	}
+
+	if req.TermsAndConditions != nil {
+		current.TermsAndConditions = req.TermsAndConditions
+	}
+
+	// replacing items when new ones are given , otherwise keeping the stored ones for totals
+	// (written again when the invoice rate , currency or rounding changed , as their amounts follow them)
+
+	items := req.Items
+
+	if items == nil {
+
+		stored, err := getInvoiceItems(tx, invoiceID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = itemRequests(stored)
+
+		// stored lines taxed at the old invoice rate move to the new one
+
+		for _, item := range items {
+			if item.TaxRate.Cmp(previousRate) == 0 {
+				item.TaxRate = &current.TaxRate
+			}
+		}
+	}
+
+	// recomputing the amounts , before the items are replaced so a rejected edit leaves them alone
+
+	amounts, err := s.invoiceTax(tx, userID, current.ClientID, current.TaxScheme, current.Currency, current.RoundingMode, current.TaxRounding, current.TaxRate, current.DiscountAmount, current.PlaceOfSupply, items)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// commiting transaction
+	if req.Items != nil || linesChanged || current.TaxRate.Cmp(previousRate) != 0 {
 
-	if err = tx.Commit(); err != nil {
+		if _, err = tx.Exec(`DELETE FROM invoice_items WHERE invoice_id = $1`, invoiceID); err != nil {
+			return nil, err
+		}
+
+		if err = insertInvoiceItems(tx, invoiceID, items, amounts.lines); err != nil {
+			return nil, err
+		}
+	}
+
+	current.PlaceOfSupply, current.SellerGSTIN, current.BuyerGSTIN = nil, nil, nil
+	current.CGSTAmount, current.SGSTAmount, current.IGSTAmount = money.Zero, money.Zero, money.Zero
+
+	if gst := amounts.gst; gst != nil {
+		current.PlaceOfSupply = &gst.placeOfSupply
+		current.SellerGSTIN = &gst.sellerGSTIN
+		current.BuyerGSTIN = gst.buyerGSTIN
+		current.CGSTAmount, current.SGSTAmount, current.IGSTAmount = gst.cgst, gst.sgst, gst.igst
+	}
+
+	updateQuery := `
+		      UPDATE invoices SET
+					   client_id = $1,
+						 issue_date = $2,
+						 due_date = $3,
+						 currency = $4,
+						 subtotal = $5,
+						 tax_rate = $6,
+						 tax_amount = $7,
+						 discount_amount = $8,
+						 total_amount = $9,
+						 template_id = $10,
+						 notes = $11,
+						 terms_and_conditions = $12,
+						 updated_at = $13,
+						 tax_scheme = $16,
+						 place_of_supply = $17,
+						 seller_gstin = $18,
+						 buyer_gstin = $19,
+						 cgst_amount = $20,
+						 sgst_amount = $21,
+						 igst_amount = $22,
+						 rounding_mode = $23,
+						 tax_rounding = $24,
+						 exchange_rate = $25
+					WHERE id = $14 AND user_id = $15
+		    `
+
+	_, err = tx.Exec(
+		updateQuery,
+		current.ClientID, current.IssueDate, current.DueDate, current.Currency, amounts.subtotal, current.TaxRate, amounts.taxAmount, current.DiscountAmount, amounts.totalAmount, current.TemplateID, current.Notes, current.TermsAndConditions, time.Now(), invoiceID, userID,
+		current.TaxScheme, current.PlaceOfSupply, current.SellerGSTIN, current.BuyerGSTIN, current.CGSTAmount, current.SGSTAmount, current.IGSTAmount, current.RoundingMode, current.TaxRounding, current.ExchangeRate,
+	)
+
+	if err != nil {
 		return nil, err
 	}
 
-	//  getting full invoice with the items and client
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
 
-	return s.GetInvoiceByID(userID, invoice.ID)
+	return s.GetInvoiceByID(userID, invoiceID)
 
 }
 
@@ -195,15 +523,12 @@ func (s *InvoiceService) GetInvoiceByID(userID, invoiceID uuid.UUID) (*domain.In
 
 	// query on invoices table  with id and user id
 
-	query :=
-		`SELECT id , user_id , client_id , invoice_number , status , issue_date , due_date , currency , subtotal , tax_rate , tax_amount , dicount_amount , total_amount , template_id , notes , terms_and_conditions , pdf_url , pdf_generated_at , email_sent , email_sent_at , email_opened , email_opened_at , created_at , updated_at  FROM invoices WHERE id = $1 AND user_id = $2`
+	query := `SELECT ` + invoiceColumns + ` FROM invoices WHERE id = $1 AND user_id = $2`
 
-	err := s.db.QueryRow(query, invoiceID, userID).Scan(
-		&invoice.ID, &invoice.UserID, &invoice.ClientID, &invoice.InvoiceNumber, &invoice.Status, &invoice.IssueDate, &invoice.DueDate, &invoice.Currency, &invoice.Subtotal, &invoice.TaxRate, &invoice.TaxAmount, &invoice.DiscountAmount, &invoice.TotalAmount, &invoice.TemplateID, &invoice.Notes, &invoice.TermsAndConditions, &invoice.PDFURL, &invoice.PDFGeneratedAt, &invoice.EmailSent, &invoice.EmailSentAt, &invoice.EmailOpened, &invoice.EmailOpenedAt, &invoice.CreatedAt, &invoice.UpdatedAt,
-	)
+	err := scanInvoice(s.db.QueryRow(query, invoiceID, userID), invoice)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("invoice not found")
+		return nil, domain.ErrInvoiceNotFound
 	}
 
 	if err != nil {
@@ -212,7 +537,7 @@ func (s *InvoiceService) GetInvoiceByID(userID, invoiceID uuid.UUID) (*domain.In
 
 	// getting invoice items
 
-	items, err := s.getInvoiceItems(invoiceID)
+	items, err := getInvoiceItems(s.db, invoiceID)
 
 	if err != nil {
 		return nil, err
@@ -220,20 +545,17 @@ func (s *InvoiceService) GetInvoiceByID(userID, invoiceID uuid.UUID) (*domain.In
 
 	invoice.Items = items
 
+	invoice.TaxSummary = taxSummary(invoice)
+
 	// getting client information
 
-	clientQuery :=
-		`
-			     SELECT id , user_id , name , email , phone , comapny_name , address_line1 , address_line2 , city , state , postal_code , country , tax_id , notes , is_active , created_at , updated_at FROM clients WHERE id = $1 
-			   `
+	clientQuery := `SELECT ` + clientColumns + ` FROM clients WHERE id = $1`
 
 	// getting client
 
 	client := &domain.Client{}
 
-	err = s.db.QueryRow(clientQuery, invoice.ClientID).Scan(
-		&client.ID, &client.UserID, &client.Name, &client.Email, &client.Phone, &client.CompanyName, &client.AddressLine1, &client.AddressLine2, &client.City, &client.State, &client.PostalCode, &client.Country, &client.TaxID, &client.Notes, &client.IsActive, &client.CreatedAt, &client.UpdatedAt,
-	)
+	err = scanClient(s.db.QueryRow(clientQuery, invoice.ClientID), client)
 
 	if err == nil {
 		invoice.Client = client
@@ -243,17 +565,62 @@ func (s *InvoiceService) GetInvoiceByID(userID, invoiceID uuid.UUID) (*domain.In
 
 }
 
+// columns of the invoices table in the order scanInvoice expects
+// amount_paid is derived from the payments ledger and amount_credited from the credit notes
+
+const invoiceColumns = `
+		      id , user_id , client_id , invoice_number , status , issue_date , due_date , paid_date , currency , subtotal , tax_rate , tax_amount ,
+					tax_scheme , place_of_supply , seller_gstin , buyer_gstin , cgst_amount , sgst_amount , igst_amount , discount_amount , total_amount , rounding_mode , tax_rounding , base_currency , exchange_rate ,
+					COALESCE((SELECT SUM(p.amount) FROM payments p WHERE p.invoice_id = invoices.id), 0) AS amount_paid ,
+					COALESCE((SELECT SUM(c.total_amount) FROM credit_notes c WHERE c.invoice_id = invoices.id), 0) AS amount_credited ,
+					template_id , notes , terms_and_conditions , pdf_url , pdf_generated_at , pdf_key , email_sent , email_sent_at , email_opened , email_opened_at , reminders_paused , created_at , updated_at
+		    `
+
+// row is either *sql.Row or *sql.Rows
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanning one invoice row selected with invoiceColumns , balance due is filled from the ledger and credit notes
+// it goes below zero when a paid invoice is credited , that's a refund owed to the client
+
+func scanInvoice(row rowScanner, invoice *domain.Invoice) error {
+
+	err := row.Scan(
+		&invoice.ID, &invoice.UserID, &invoice.ClientID, &invoice.InvoiceNumber, &invoice.Status, &invoice.IssueDate, &invoice.DueDate, &invoice.PaidDate, &invoice.Currency, &invoice.Subtotal, &invoice.TaxRate, &invoice.TaxAmount,
+		&invoice.TaxScheme, &invoice.PlaceOfSupply, &invoice.SellerGSTIN, &invoice.BuyerGSTIN, &invoice.CGSTAmount, &invoice.SGSTAmount, &invoice.IGSTAmount, &invoice.DiscountAmount, &invoice.TotalAmount, &invoice.RoundingMode, &invoice.TaxRounding, &invoice.BaseCurrency, &invoice.ExchangeRate,
+		&invoice.AmountPaid, &invoice.AmountCredited,
+		&invoice.TemplateID, &invoice.Notes, &invoice.TermsAndConditions, &invoice.PDFURL, &invoice.PDFGeneratedAt, &invoice.PDFKey, &invoice.EmailSent, &invoice.EmailSentAt, &invoice.EmailOpened, &invoice.EmailOpenedAt, &invoice.RemindersPaused, &invoice.CreatedAt, &invoice.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	invoice.BalanceDue = invoice.TotalAmount.Sub(invoice.AmountPaid).Sub(invoice.AmountCredited)
+
+	return nil
+}
+
 // function for getting invoice items
 // returns an array of invoice items
+// works on both the db and an open transaction
+
+func getInvoiceItems(q queryer, invoiceID uuid.UUID) ([]*domain.InvoiceItem, error) {
+	return getLineItems(q, "invoice_items", "invoice_id", invoiceID)
+}
 
-func (s *InvoiceService) getInvoiceItems(invoiceID uuid.UUID) ([]*domain.InvoiceItem, error) {
+// lines of an invoice or an estimate , table and parent are the line table and its column pointing at the document
+
+func getLineItems(q queryer, table, parent string, parentID uuid.UUID) ([]*domain.InvoiceItem, error) {
 
 	query :=
 		`
-		     SELECT id , invoice_id , description , quantity , unit_price , amount , sort_order , created_at , updated_at FROM invoice_items WHERE invoice_id = $1 ORDER BY sort_order 
+		     SELECT id , ` + parent + ` , description , quantity , unit_price , amount , discount_type , discount_value , discount_amount , hsn_sac , tax_rate , tax_amount , sort_order , created_at , updated_at FROM ` + table + ` WHERE ` + parent + ` = $1 ORDER BY sort_order 
 		   `
 
-	rows, err := s.db.Query(query, invoiceID)
+	rows, err := q.Query(query, parentID)
 
 	if err != nil {
 		return nil, err
@@ -267,7 +634,7 @@ func (s *InvoiceService) getInvoiceItems(invoiceID uuid.UUID) ([]*domain.Invoice
 		item := &domain.InvoiceItem{}
 
 		err := rows.Scan(
-			&item.ID, &item.InvoiceID, &item.Description, &item.Quantity, &item.UnitPrice, &item.Amount, &item.SortOrder, &item.CreatedAt, &item.UpdatedAt,
+			&item.ID, &item.InvoiceID, &item.Description, &item.Quantity, &item.UnitPrice, &item.Amount, &item.DiscountType, &item.DiscountValue, &item.DiscountAmount, &item.HSNSAC, &item.TaxRate, &item.TaxAmount, &item.SortOrder, &item.CreatedAt, &item.UpdatedAt,
 		)
 
 		if err != nil {
@@ -280,10 +647,57 @@ func (s *InvoiceService) getInvoiceItems(invoiceID uuid.UUID) ([]*domain.Invoice
 	return items, rows.Err()
 }
 
+// inserting invoice items inside a transaction , sort order follows the request order
+// lines are the worked out amounts of the items , from invoiceTax
+
+func insertInvoiceItems(tx *sql.Tx, invoiceID uuid.UUID, items []*domain.CreateInvoiceItemReq, lines []lineTotals) error {
+	return insertLineItems(tx, "invoice_items", "invoice_id", invoiceID, items, lines)
+}
+
+// inserting the lines of an invoice or an estimate , like getLineItems
+
+func insertLineItems(tx *sql.Tx, table, parent string, parentID uuid.UUID, items []*domain.CreateInvoiceItemReq, lines []lineTotals) error {
+
+	itemQuery := `
+		      INSERT INTO ` + table + ` (
+					  	 id , ` + parent + ` , description , quantity , unit_price , amount , discount_type , discount_value , discount_amount , hsn_sac , tax_rate , tax_amount , sort_order , created_at , updated_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $13 , $14 , $15)
+		    `
+
+	for i, item := range items {
+
+		itemID := uuid.New()
+		line := lines[i]
+
+		var discountType, hsnSAC *string
+
+		if item.DiscountType != "" {
+			discountType = &item.DiscountType
+		}
+
+		if item.HSNSAC != nil && strings.TrimSpace(*item.HSNSAC) != "" {
+			code := strings.TrimSpace(*item.HSNSAC)
+			hsnSAC = &code
+		}
+
+		_, err := tx.Exec(
+			itemQuery,
+			itemID, parentID, item.Description, item.Quantity, item.UnitPrice, line.amount, discountType, item.DiscountValue, line.discount, hsnSAC, line.taxRate, line.tax, i, time.Now(), time.Now(),
+		)
+
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
 // function for  getting invoices by the user id
 // list of invoices are returned
 
-func (s *InvoiceService) GetInvoiceByUserID(userID uuid.UUID, page, pageSize int, status string) (*domain.InvoiceListResponse, error) {
+func (s *InvoiceService) GetInvoiceByUserID(userID uuid.UUID, page, pageSize int, status string, openedUnpaid bool) (*domain.InvoiceListResponse, error) {
 
 	if page < 1 {
 		page = 1
@@ -295,20 +709,26 @@ func (s *InvoiceService) GetInvoiceByUserID(userID uuid.UUID, page, pageSize int
 
 	offset := (page - 1) * pageSize
 
-	// building query with optional status filter
+	// building the filters shared by the count and the list query
 
-	countQuery := `SELECT COUNT(*) FROM invoices WHERE user_id = $1`
+	conditions := ` WHERE user_id = $1`
 
 	args := []interface{}{userID}
 
 	if status != "" {
-		countQuery += ` AND status = %2`
 		args = append(args, status)
+		conditions += ` AND status = $` + fmt.Sprintf("%d", len(args))
+	}
+
+	// opened by the client , still waiting for (the rest of) the money
+
+	if openedUnpaid {
+		conditions += ` AND email_opened = true AND status IN ('sent' , 'partially_paid' , 'overdue')`
 	}
 
 	var total int
 
-	err := s.db.QueryRow(countQuery, args...).Scan(&total)
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM invoices`+conditions, args...).Scan(&total)
 
 	if err != nil {
 		return nil, err
@@ -316,21 +736,11 @@ func (s *InvoiceService) GetInvoiceByUserID(userID uuid.UUID, page, pageSize int
 
 	// query to get invoices
 
-	query :=
-		`
-		      SELECT id , user_id , client_id , invoice_number , status , issue_date , due_date , currency , subtotal , tax_rate , tax_amount , dicount_amount , total_amount , template_id , notes , terms_and_conditions , pdf_url , pdf_generated_at , email_sent , email_sent_at , email_opened , email_opened_at , created_at , updated_at FROM invoices WHERE user_id = $1
-		   `
-
-	queryArgs := []interface{}{userID}
-
-	if status != "" {
-		query += ` AND status = $2`
-		queryArgs = append(queryArgs, status)
-	}
+	query := `SELECT ` + invoiceColumns + ` FROM invoices` + conditions
 
-	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", len(queryArgs)+1) + `OFFSET $` + fmt.Sprintf("%d", len(queryArgs)+2)
+	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2)
 
-	queryArgs = append(queryArgs, pageSize, offset)
+	queryArgs := append(args, pageSize, offset)
 
 	rows, err := s.db.Query(query, queryArgs...)
 
@@ -348,9 +758,7 @@ func (s *InvoiceService) GetInvoiceByUserID(userID uuid.UUID, page, pageSize int
 
 		invoice := &domain.Invoice{}
 
-		err := rows.Scan(
-			&invoice.ID, &invoice.UserID, &invoice.ClientID, &invoice.InvoiceNumber, &invoice.Status, &invoice.IssueDate, &invoice.DueDate, &invoice.Currency, &invoice.Subtotal, &invoice.TaxRate, &invoice.TaxAmount, &invoice.DiscountAmount, &invoice.TotalAmount, &invoice.TemplateID, &invoice.Notes, &invoice.TermsAndConditions, &invoice.PDFURL, &invoice.PDFGeneratedAt, &invoice.EmailSent, &invoice.EmailSentAt, &invoice.EmailOpened, &invoice.EmailOpenedAt, &invoice.CreatedAt, &invoice.UpdatedAt,
-		)
+		err := scanInvoice(rows, invoice)
 
 		if err != nil {
 			return nil, err
@@ -379,65 +787,56 @@ func (s *InvoiceService) GetInvoiceByUserID(userID uuid.UUID, page, pageSize int
 }
 
 // function for updating invoice status
+// the change has to be allowed by the transition table , and every change is written to the status history
 
 func (s *InvoiceService) UpdateInvoiceStatus(userID, invoiceID uuid.UUID, req *domain.UpdateInvoiceStatusRequest) (*domain.Invoice, error) {
 
-	// getting that invoice to confirm , that user exists
-
-	invoice, err := s.GetInvoiceByID(userID, invoiceID)
-
+	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 
-	//check - don't allow status update if invoice is already paid or canceled
-
-	if invoice.Status == domain.InvoiceStatusPaid || invoice.Status == domain.InvoiceStatusCanceled {
-		return nil, fmt.Errorf("cannot update status of %s invoice", invoice.Status)
-	}
-
-	query := `UPDATE invoices SET status = $1 , updated_at = $2`
-
-	args := []interface{}{req.Status, time.Now()}
-
-	argCount := 2
-
-	// if invoice is paid , then setting paid_date
-
-	if req.Status == domain.InvoiceStatusPaid {
-		if req.PaidDate != nil {
-			paidDate, err := time.Parse("2026-02-20", *req.PaidDate)
+	defer tx.Rollback()
 
-			if err != nil {
-				return nil, fmt.Errorf("invalid paid_date format , use YYYY-MM-DD")
-			}
+	// locking the invoice , confirming that it belongs to the user
 
-			argCount++
+	var currentStatus string
 
-			query += fmt.Sprintf(`, paid_date = $%d`, argCount)
+	err = tx.QueryRow(`SELECT status FROM invoices WHERE id = $1 AND user_id = $2 FOR UPDATE`, invoiceID, userID).Scan(&currentStatus)
 
-			args = append(args, paidDate)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvoiceNotFound
+	}
 
-		} else {
-			argCount++
+	if err != nil {
+		return nil, err
+	}
 
-			query += fmt.Sprintf(`, paid_date = $%d`, argCount)
-			args = append(args, time.Now())
+	// paid and partially paid only come from the payments ledger
 
-		}
+	if req.Status == domain.InvoiceStatusPaid || req.Status == domain.InvoiceStatusPartiallyPaid {
+		return nil, domain.ErrStatusSetByPayments
 	}
 
-	argCount++
+	// check - status change must be in the transition table
 
-	query += fmt.Sprintf(` WHERE id = $%d AND user_id = $%d`, argCount, argCount+1)
+	if err := domain.ValidateStatusTransition(currentStatus, req.Status); err != nil {
+		return nil, err
+	}
 
-	args = append(args, invoiceID, userID)
+	query := `UPDATE invoices SET status = $1 , updated_at = $2 WHERE id = $3 AND user_id = $4`
 
 	// executing the query and arguments
 
-	_, err = s.db.Exec(query, args...)
+	if _, err = tx.Exec(query, req.Status, time.Now(), invoiceID, userID); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	if err = recordStatusChange(tx, invoiceID, &userID, currentStatus, req.Status, req.Reason); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
 
@@ -475,43 +874,261 @@ func (s *InvoiceService) DeleteInvoice(userID, invoiceID uuid.UUID) error {
 
 // function for invoices stats
 // how much invoices are in which-which status (draft , paid , sent , total revenue , pending , overdue)
+// revenue figures are converted to the user's base currency at each invoice's and payment's own rate , by_currency keeps them unconverted
 
 func (s *InvoiceService) GetInvoiceStats(userID uuid.UUID) (*domain.InvoiceStats, error) {
 
-	stats := &domain.InvoiceStats{}
+	base, err := baseCurrency(s.db, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.InvoiceStats{BaseCurrency: base, ByCurrency: []*domain.CurrencyStats{}}
 
-	// query to get stats of the invoices for specific user
-	// COALESCE - returns first non-null value
-	// if SUM gives response in NULL , then COALESCE will choose first non null value which is zero (0) , as total_revenue , pending_revenue and overdue_revenue
 	// using COALESCE for avoiding null values , using COALESCE only with SUM , AVG , not with COUNT bcoz count already returns 0
 
-	query := `
+	countQuery := `
 		     SELECT 
 				    COUNT(*) as total_invoices,
 						COUNT(CASE WHEN status = 'draft' THEN 1 END) as draft_invoices,
 						COUNT(CASE WHEN status = 'sent' THEN 1 END) as sent_invoices,
+						COUNT(CASE WHEN status = 'partially_paid' THEN 1 END) as partially_paid_invoices,
 						COUNT(CASE WHEN status = 'paid' THEN 1 END) as paid_invoices,
-						COUNT(CASE WHEN status = 'overdue' THEN 1 END) as overdue_invoices,
-						COALESCE(SUM(CASE WHEN status = 'paid' THEN total_amount ELSE 0 END) , 0) as total_revenue,
-						COALESCE(SUM(CASE WHEN status = 'sent' THEN total_amount ELSE 0 END) , 0) as pending_revenue,
-						COALESCE(SUM(CASE WHEN status = 'overdue' THEN total_amount ELSE 0 END) , 0) as overdue_revenue
-
-					FROM invoices
-					WHERE user_id = $1
+						COUNT(CASE WHEN status = 'overdue' THEN 1 END) as overdue_invoices
+					FROM invoices WHERE user_id = $1
 		  `
 
-	err := s.db.QueryRow(query, userID).Scan(
-		&stats.TotalInvoices, &stats.DraftInvoices, &stats.SentInvoices, &stats.PaidInvoices, &stats.OverdueInvoices, &stats.TotalRevenue, &stats.PendingRevenue, &stats.OverdueRevenue,
+	err = s.db.QueryRow(countQuery, userID).Scan(
+		&stats.TotalInvoices, &stats.DraftInvoices, &stats.SentInvoices, &stats.PartiallyPaidInvoices, &stats.PaidInvoices, &stats.OverdueInvoices,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// sums are grouped by currency and the rate they were converted at , so every group is converted exactly once
+
+	totals := newStatsTotals(s.rates, userID, base)
+
+	// outstanding balances come from the invoices joined with their payment and credit note sums
+
+	invoiceQuery := `
+		     SELECT i.currency , i.base_currency , i.exchange_rate , COUNT(*) ,
+						COALESCE(SUM(CASE WHEN i.status IN ('sent' , 'partially_paid') THEN i.total_amount - COALESCE(p.paid , 0) - COALESCE(c.credited , 0) ELSE 0 END) , 0) as pending_revenue,
+						COALESCE(SUM(CASE WHEN i.status = 'overdue' THEN i.total_amount - COALESCE(p.paid , 0) - COALESCE(c.credited , 0) ELSE 0 END) , 0) as overdue_revenue
+					FROM invoices i
+					LEFT JOIN (SELECT invoice_id , SUM(amount) as paid FROM payments GROUP BY invoice_id) p ON p.invoice_id = i.id
+					LEFT JOIN (SELECT invoice_id , SUM(total_amount) as credited FROM credit_notes GROUP BY invoice_id) c ON c.invoice_id = i.id
+					WHERE i.user_id = $1
+					GROUP BY i.currency , i.base_currency , i.exchange_rate
+		  `
+
+	rows, err := s.db.Query(invoiceQuery, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			group            statsGroup
+			count            int
+			pending, overdue money.Decimal
+		)
+
+		if err := rows.Scan(&group.currency, &group.baseCurrency, &group.rate, &count, &pending, &overdue); err != nil {
+			return nil, err
+		}
+
+		row := totals.currency(group.currency)
+		row.TotalInvoices += count
+		row.PendingRevenue = row.PendingRevenue.Add(pending)
+		row.OverdueRevenue = row.OverdueRevenue.Add(overdue)
+
+		if err := totals.add(&stats.PendingRevenue, group, pending); err != nil {
+			return nil, err
+		}
+
+		if err := totals.add(&stats.OverdueRevenue, group, overdue); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// revenue is what was actually received , so it's summed straight from the payments ledger
+
+	paymentQuery := `
+		     SELECT currency , base_currency , exchange_rate , SUM(amount)
+					FROM payments WHERE user_id = $1
+					GROUP BY currency , base_currency , exchange_rate
+		  `
+
+	err = s.groupSums(paymentQuery, userID, func(group statsGroup, amount money.Decimal) error {
+
+		row := totals.currency(group.currency)
+		row.TotalRevenue = row.TotalRevenue.Add(amount)
+
+		return totals.add(&stats.TotalRevenue, group, amount)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// credit notes , already taken off the balances above
+
+	creditQuery := `
+		     SELECT currency , base_currency , exchange_rate , SUM(total_amount)
+					FROM credit_notes WHERE user_id = $1
+					GROUP BY currency , base_currency , exchange_rate
+		  `
+
+	err = s.groupSums(creditQuery, userID, func(group statsGroup, amount money.Decimal) error {
+
+		row := totals.currency(group.currency)
+		row.TotalCredited = row.TotalCredited.Add(amount)
+
+		return totals.add(&stats.TotalCredited, group, amount)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats.ByCurrency = totals.list()
+
 	return stats, nil
 
 }
 
+// running fn over the rows of a query summing amounts by currency , base currency and rate
+
+func (s *InvoiceService) groupSums(query string, userID uuid.UUID, fn func(group statsGroup, amount money.Decimal) error) error {
+
+	rows, err := s.db.Query(query, userID)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			group  statsGroup
+			amount money.Decimal
+		)
+
+		if err := rows.Scan(&group.currency, &group.baseCurrency, &group.rate, &amount); err != nil {
+			return err
+		}
+
+		if err := fn(group, amount); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// documents sharing a currency and the rate they were converted at
+
+type statsGroup struct {
+	currency     string
+	baseCurrency string
+	rate         money.Rate
+}
+
+// running stats totals , per currency and converted to the base currency
+
+type statsTotals struct {
+	rates      fx.Provider
+	userID     uuid.UUID
+	base       string
+	places     int
+	byCurrency map[string]*domain.CurrencyStats
+}
+
+func newStatsTotals(rates fx.Provider, userID uuid.UUID, base string) *statsTotals {
+	return &statsTotals{
+		rates:      rates,
+		userID:     userID,
+		base:       base,
+		places:     money.MinorUnits(base),
+		byCurrency: map[string]*domain.CurrencyStats{},
+	}
+}
+
+func (t *statsTotals) currency(code string) *domain.CurrencyStats {
+
+	row, ok := t.byCurrency[code]
+
+	if !ok {
+		row = &domain.CurrencyStats{Currency: code}
+		t.byCurrency[code] = row
+	}
+
+	return row
+}
+
+// adding amount of the group to total , converted to the base currency
+// documents made before the user changed base currency are converted on from their old base at today's rate
+
+func (t *statsTotals) add(total *money.Decimal, group statsGroup, amount money.Decimal) error {
+
+	if amount.IsZero() {
+		return nil
+	}
+
+	rate := group.rate
+
+	if group.baseCurrency != t.base {
+
+		today, err := exchangeRate(t.rates, t.userID, group.baseCurrency, t.base, time.Now(), nil)
+
+		if err != nil {
+			return err
+		}
+
+		if rate, err = rate.Times(today); err != nil {
+			return err
+		}
+	}
+
+	converted, err := rate.Convert(amount, t.places, money.HalfUp)
+
+	if err != nil {
+		return err
+	}
+
+	*total = total.Add(converted)
+
+	return nil
+}
+
+// currencies sorted by code
+
+func (t *statsTotals) list() []*domain.CurrencyStats {
+
+	list := make([]*domain.CurrencyStats, 0, len(t.byCurrency))
+
+	for _, row := range t.byCurrency {
+		list = append(list, row)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+
+	return list
+}
+
 // function to create duplicate (copy) invoices
 
 func (s *InvoiceService) DuplicateInvoice(userID, invoiceID uuid.UUID) (*domain.Invoice, error) {
@@ -524,33 +1141,58 @@ func (s *InvoiceService) DuplicateInvoice(userID, invoiceID uuid.UUID) (*domain.
 		return nil, err
 	}
 
-	// creating request from original invoice
+	// creating request from original invoice , new invoice is issued today and due in 30 days
 
-	items := []*domain.CreateInvoiceItemReq{}
+	req := copyInvoiceRequest(originalInvoice, time.Now(), time.Now().AddDate(0, 0, 30))
 
-	for _, item := range originalInvoice.Items {
-		items = append(items, &domain.CreateInvoiceItemReq{
-			Description: item.Description,
-			Quantity:    item.Quantity,
-			UnitPrice:   item.UnitPrice,
-		})
-	}
+	return s.CreateInvoice(userID, req)
 
-	// creating invoice
+}
 
-	req := &domain.CreateInvoiceRequest{
-		ClientID:           originalInvoice.ClientID,
-		IssueDate:          time.Now().Format("2026-02-20"),
-		DueDate:            time.Now().AddDate(0, 0, 30).Format("2026-02-20"),
-		Currency:           originalInvoice.Currency,
-		TaxRate:            originalInvoice.TaxRate,
-		DiscountAmount:     originalInvoice.DiscountAmount,
-		TemplateID:         originalInvoice.TemplateID,
-		Notes:              originalInvoice.Notes,
-		TermsAndConditions: originalInvoice.TermsAndConditions,
-		Items:              items,
+// building a create request that copies an existing invoice with new dates
+
+func copyInvoiceRequest(original *domain.Invoice, issueDate, dueDate time.Time) *domain.CreateInvoiceRequest {
+
+	return &domain.CreateInvoiceRequest{
+		ClientID:           original.ClientID,
+		IssueDate:          issueDate.Format(dateLayout),
+		DueDate:            dueDate.Format(dateLayout),
+		Currency:           original.Currency,
+		TaxRate:            original.TaxRate,
+		TaxScheme:          original.TaxScheme,
+		RoundingMode:       original.RoundingMode,
+		TaxRounding:        original.TaxRounding,
+		PlaceOfSupply:      original.PlaceOfSupply,
+		DiscountAmount:     original.DiscountAmount,
+		TemplateID:         original.TemplateID,
+		Notes:              original.Notes,
+		TermsAndConditions: original.TermsAndConditions,
+		Items:              itemRequests(original.Items),
 	}
+}
 
-	return s.CreateInvoice(userID, req)
+// stored items back into item requests
+
+func itemRequests(items []*domain.InvoiceItem) []*domain.CreateInvoiceItemReq {
+
+	reqs := []*domain.CreateInvoiceItemReq{}
+
+	for _, item := range items {
+		req := &domain.CreateInvoiceItemReq{
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			TaxRate:       &item.TaxRate,
+			DiscountValue: item.DiscountValue,
+			HSNSAC:        item.HSNSAC,
+		}
+
+		if item.DiscountType != nil {
+			req.DiscountType = *item.DiscountType
+		}
+
+		reqs = append(reqs, req)
+	}
 
+	return reqs
 }
@@ -0,0 +1,263 @@
+// invoice tax - line discounts , line tax rates and the invoice totals built from them
+// amounts are exact decimals rounded to the currency's minor units with the invoice's rounding mode
+
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+// money of one line , amount is quantity * unit price less the discount and tax is charged on it
+
+type lineTotals struct {
+	discount money.Decimal
+	amount   money.Decimal
+	taxRate  money.Decimal
+	tax      money.Decimal
+}
+
+// how an invoice's amounts are rounded , places are the currency's minor units
+
+type rounding struct {
+	places     int
+	mode       money.RoundingMode
+	perInvoice bool
+}
+
+// rounding of an invoice , empty settings are half up and per line
+
+func invoiceRounding(currency, roundingMode, taxRounding string) rounding {
+
+	r := rounding{places: money.MinorUnits(currency), mode: money.RoundingMode(roundingMode), perInvoice: taxRounding == domain.TaxRoundingInvoice}
+
+	if !r.mode.Valid() {
+		r.mode = money.HalfUp
+	}
+
+	return r
+}
+
+// working out a line , lines without a tax rate of their own use the invoice's
+// quantity * unit price and the discount are rounded to the currency , the tax is charged on what's left
+// money.ErrOutOfRange when the line is too big to work out
+
+func itemTotals(item *domain.CreateInvoiceItemReq, defaultRate money.Decimal, r rounding) (lineTotals, error) {
+
+	line := lineTotals{taxRate: defaultRate}
+
+	gross, err := item.Quantity.Mul(item.UnitPrice, r.places, r.mode)
+
+	if err != nil {
+		return line, err
+	}
+
+	if item.TaxRate != nil {
+		line.taxRate = *item.TaxRate
+	}
+
+	switch item.DiscountType {
+	case domain.DiscountPercentage:
+		if line.discount, err = gross.Percent(item.DiscountValue, r.places, r.mode); err != nil {
+			return line, err
+		}
+	case domain.DiscountFixed:
+		line.discount = item.DiscountValue
+	}
+
+	line.amount = gross.Sub(line.discount)
+
+	if line.tax, err = line.amount.Percent(line.taxRate, r.places, r.mode); err != nil {
+		return line, err
+	}
+
+	return line, nil
+}
+
+// every line of an invoice being saved , discounts have to stay within the line
+
+func invoiceLines(items []*domain.CreateInvoiceItemReq, defaultRate money.Decimal, r rounding) ([]lineTotals, error) {
+
+	lines := make([]lineTotals, len(items))
+
+	for i, item := range items {
+
+		if item.DiscountType == "" && !item.DiscountValue.IsZero() {
+			return nil, fmt.Errorf("item %d has a discount_value without a discount_type: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		if item.DiscountType == domain.DiscountPercentage && item.DiscountValue.GreaterThan(money.FromInt(100)) {
+			return nil, fmt.Errorf("item %d discount can't be more than 100%%: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		if item.DiscountType == domain.DiscountFixed && item.DiscountValue.Places() > r.places {
+			return nil, fmt.Errorf("item %d discount_value has more decimal places than the currency: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		line, err := itemTotals(item, defaultRate, r)
+
+		if err != nil || !line.amount.InRange() || !line.tax.InRange() {
+			return nil, fmt.Errorf("item %d amount is too large: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		if line.amount.Sign() < 0 {
+			return nil, fmt.Errorf("item %d discount is more than the line amount: %w", i+1, domain.ErrInvalidInput)
+		}
+
+		lines[i] = line
+	}
+
+	if r.perInvoice {
+		if err := roundTaxPerRate(lines, r); err != nil {
+			return nil, err
+		}
+	}
+
+	return lines, nil
+}
+
+// per invoice rounding , the tax of each rate is worked out once on the summed lines
+// the lines keep their own rounded tax and the difference goes onto the biggest line of the rate , so lines still add up
+
+func roundTaxPerRate(lines []lineTotals, r rounding) error {
+
+	byRate := map[string][]int{}
+
+	for i, line := range lines {
+		byRate[line.taxRate.String()] = append(byRate[line.taxRate.String()], i)
+	}
+
+	for _, group := range byRate {
+
+		var taxable, taxed money.Decimal
+		biggest := group[0]
+
+		for _, i := range group {
+			taxable = taxable.Add(lines[i].amount)
+			taxed = taxed.Add(lines[i].tax)
+
+			if !taxable.InRange() || !taxed.InRange() {
+				return errInvoiceTooLarge
+			}
+
+			if lines[i].amount.GreaterThan(lines[biggest].amount) {
+				biggest = i
+			}
+		}
+
+		tax, err := taxable.Percent(lines[biggest].taxRate, r.places, r.mode)
+
+		if err != nil {
+			return errInvoiceTooLarge
+		}
+
+		lines[biggest].tax = lines[biggest].tax.Add(tax.Sub(taxed))
+	}
+
+	return nil
+}
+
+// totals past what a Decimal keeps , the lines are fine on their own but not added up
+
+var errInvoiceTooLarge = fmt.Errorf("the invoice total is too large: %w", domain.ErrInvalidInput)
+
+// the money of an invoice being saved
+// subtotal is the sum of the line amounts and tax the sum of the line taxes , the invoice discount comes off the total
+// gst is nil unless the invoice uses the gst scheme
+
+type invoiceAmounts struct {
+	lines       []lineTotals
+	subtotal    money.Decimal
+	taxAmount   money.Decimal
+	totalAmount money.Decimal
+	gst         *gstInvoice
+}
+
+// amounts of an invoice being created or edited , gst invoices are checked and split by calculateGST
+
+func (s *InvoiceService) invoiceTax(q queryer, userID, clientID uuid.UUID, taxScheme, currency, roundingMode, taxRounding string, taxRate, discountAmount money.Decimal, placeOfSupply *string, items []*domain.CreateInvoiceItemReq) (*invoiceAmounts, error) {
+
+	r := invoiceRounding(currency, roundingMode, taxRounding)
+
+	if discountAmount.Places() > r.places {
+		return nil, fmt.Errorf("discount_amount has more decimal places than %s allows: %w", currency, domain.ErrInvalidInput)
+	}
+
+	lines, err := invoiceLines(items, taxRate, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := &invoiceAmounts{lines: lines}
+
+	for _, line := range lines {
+		amounts.subtotal = amounts.subtotal.Add(line.amount)
+		amounts.taxAmount = amounts.taxAmount.Add(line.tax)
+
+		if !amounts.subtotal.InRange() || !amounts.taxAmount.InRange() {
+			return nil, errInvoiceTooLarge
+		}
+	}
+
+	if taxScheme == domain.TaxSchemeGST {
+		if amounts.gst, err = calculateGST(q, userID, clientID, currency, discountAmount, placeOfSupply, items, lines); err != nil {
+			return nil, err
+		}
+	}
+
+	amounts.totalAmount = amounts.subtotal.Add(amounts.taxAmount).Sub(discountAmount)
+
+	if !amounts.totalAmount.InRange() {
+		return nil, errInvoiceTooLarge
+	}
+
+	if amounts.totalAmount.Sign() < 0 {
+		return nil, fmt.Errorf("discount_amount is more than the invoice total: %w", domain.ErrInvalidInput)
+	}
+
+	return amounts, nil
+}
+
+// tax of a loaded invoice grouped by rate , lowest rate first
+// gst lines are split the same way calculateGST split them , so the groups add up to the invoice's cgst / sgst / igst
+
+func taxSummary(invoice *domain.Invoice) []*domain.TaxRateSummary {
+
+	gst := invoice.TaxScheme == domain.TaxSchemeGST
+	intraState := gstIntraState(invoice)
+
+	byRate := map[string]*domain.TaxRateSummary{}
+	summary := []*domain.TaxRateSummary{}
+
+	for _, item := range invoice.Items {
+
+		group, ok := byRate[item.TaxRate.String()]
+
+		if !ok {
+			group = &domain.TaxRateSummary{Rate: item.TaxRate}
+			byRate[item.TaxRate.String()] = group
+			summary = append(summary, group)
+		}
+
+		group.TaxableAmount = group.TaxableAmount.Add(item.Amount)
+		group.TaxAmount = group.TaxAmount.Add(item.TaxAmount)
+
+		switch {
+		case gst && intraState:
+			cgst, sgst := splitGST(item.TaxAmount, invoice.Currency)
+			group.CGSTAmount = group.CGSTAmount.Add(cgst)
+			group.SGSTAmount = group.SGSTAmount.Add(sgst)
+		case gst:
+			group.IGSTAmount = group.IGSTAmount.Add(item.TaxAmount)
+		}
+	}
+
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Rate.LessThan(summary[j].Rate) })
+
+	return summary
+}
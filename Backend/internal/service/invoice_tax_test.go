@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+func testItem(quantity, unitPrice string) *domain.CreateInvoiceItemReq {
+	return &domain.CreateInvoiceItemReq{
+		Description: "Item",
+		Quantity:    money.MustParse(quantity),
+		UnitPrice:   money.MustParse(unitPrice),
+	}
+}
+
+// flat invoice amounts , the queryer is only needed for gst
+
+func flatInvoiceTax(taxRate string, items ...*domain.CreateInvoiceItemReq) (*invoiceAmounts, error) {
+	return (&InvoiceService{}).invoiceTax(nil, uuid.Nil, uuid.Nil, domain.TaxSchemeFlat, "INR", string(money.HalfUp), domain.TaxRoundingLine, money.MustParse(taxRate), money.Zero, nil, items)
+}
+
+func TestInvoiceTaxTotals(t *testing.T) {
+
+	amounts, err := flatInvoiceTax("18", testItem("3", "0.1"), testItem("2.5", "19.99"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.30 + 49.975 -> 49.98 , tax 0.054 -> 0.05 and 8.9964 -> 9.00
+
+	want := map[string]money.Decimal{
+		"subtotal": money.MustParse("50.28"),
+		"tax":      money.MustParse("9.05"),
+		"total":    money.MustParse("59.33"),
+	}
+
+	got := map[string]money.Decimal{
+		"subtotal": amounts.subtotal,
+		"tax":      amounts.taxAmount,
+		"total":    amounts.totalAmount,
+	}
+
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %s , want %s", name, got[name], value)
+		}
+	}
+}
+
+// a line whose quantity * unit price doesn't fit is an input error , not a wrapped number
+
+func TestInvoiceTaxRejectsLineOverflow(t *testing.T) {
+
+	_, err := flatInvoiceTax("0", testItem("1", "10"), testItem("99999999", "9999999999999"))
+
+	if !errors.Is(err, domain.ErrInvalidInput) {
+		t.Fatalf("got %v , want ErrInvalidInput", err)
+	}
+}
+
+// lines that fit on their own but not added up
+
+func TestInvoiceTaxRejectsTotalOverflow(t *testing.T) {
+
+	items := []*domain.CreateInvoiceItemReq{}
+
+	for range 20 {
+		items = append(items, testItem("1", "9999999999999"))
+	}
+
+	_, err := flatInvoiceTax("18", items...)
+
+	if !errors.Is(err, domain.ErrInvalidInput) {
+		t.Fatalf("got %v , want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,164 @@
+// overdue service - moving sent invoices past their due date to overdue
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// invoices updated per transaction
+
+const overdueBatchSize = 200
+
+type OverdueService struct {
+	db    *database.DB
+	clock util.Clock
+}
+
+// overdue service function , clock decides what "today" is
+
+func NewOverdueService(db *database.DB, clock util.Clock) *OverdueService {
+	return &OverdueService{
+		db:    db,
+		clock: clock,
+	}
+}
+
+// background sweeper , same ticker pattern as the recurring generator
+
+func (s *OverdueService) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.Sweep(nil); err != nil {
+			log.Printf("overdue sweep: %v", err)
+		} else if n > 0 {
+			log.Printf("overdue sweep: %d invoices marked overdue", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// marking every sent or partially paid invoice whose due date is before today as overdue
+// "today" is taken in each user's own timezone , so an invoice due on the 10th turns overdue at midnight of the 11th for that user
+// userID limits the sweep to one user (manual trigger) , nil sweeps everyone
+// returns how many invoices were changed
+
+func (s *OverdueService) Sweep(userID *uuid.UUID) (int, error) {
+
+	now := s.clock.Now()
+	total := 0
+
+	for {
+		n, err := s.sweepBatch(now, userID)
+
+		total += n
+
+		if err != nil {
+			return total, err
+		}
+
+		if n < overdueBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// one batch in its own transaction , rows locked by another sweeper are skipped
+
+func (s *OverdueService) sweepBatch(now time.Time, userID *uuid.UUID) (int, error) {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		     SELECT i.id , i.status FROM invoices i
+				 JOIN users u ON u.id = i.user_id
+				 WHERE i.status IN ('sent' , 'partially_paid')
+				   AND i.due_date < ($1::timestamptz AT TIME ZONE u.timezone)::date
+		   `
+
+	args := []interface{}{now}
+
+	if userID != nil {
+		query += ` AND i.user_id = $2`
+		args = append(args, *userID)
+	}
+
+	query += fmt.Sprintf(` ORDER BY i.due_date LIMIT %d FOR UPDATE OF i SKIP LOCKED`, overdueBatchSize)
+
+	rows, err := tx.Query(query, args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	type dueInvoice struct {
+		id     uuid.UUID
+		status string
+	}
+
+	due := []dueInvoice{}
+
+	for rows.Next() {
+		var inv dueInvoice
+
+		if err := rows.Scan(&inv.id, &inv.status); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		due = append(due, inv)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	reason := "due date passed"
+
+	for _, inv := range due {
+
+		if err := domain.ValidateStatusTransition(inv.status, domain.InvoiceStatusOverdue); err != nil {
+			return 0, err
+		}
+
+		if _, err := tx.Exec(`UPDATE invoices SET status = $1 , updated_at = $2 WHERE id = $3`, domain.InvoiceStatusOverdue, now, inv.id); err != nil {
+			return 0, err
+		}
+
+		// system change , no acting user
+
+		if err := recordStatusChange(tx, inv.id, nil, inv.status, domain.InvoiceStatusOverdue, &reason); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(due), nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/google/uuid"
+)
+
+// three users with an invoice due on march 10 , the sweep runs at instants around midnight of the 11th in each timezone
+
+func TestOverdueSweepUsesEachUsersDay(t *testing.T) {
+
+	db := newTestDB(t)
+	clock := &fakeClock{}
+	sweeper := NewOverdueService(db, clock)
+
+	dueDate := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	invoices := map[string]uuid.UUID{}
+
+	for _, tz := range []string{"Asia/Kolkata", "UTC", "America/Los_Angeles"} {
+		invoices[tz] = insertTestInvoice(t, db, insertTestUser(t, db, tz), domain.InvoiceStatusSent, dueDate)
+	}
+
+	steps := []struct {
+		now     time.Time
+		overdue []string
+	}{
+		// 23:59:59 in kolkata
+
+		{time.Date(2026, 3, 10, 18, 29, 59, 0, time.UTC), nil},
+
+		// midnight in kolkata
+
+		{time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC), []string{"Asia/Kolkata"}},
+		{time.Date(2026, 3, 10, 23, 59, 59, 0, time.UTC), []string{"Asia/Kolkata"}},
+		{time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), []string{"Asia/Kolkata", "UTC"}},
+
+		// los angeles is on daylight time (UTC-7) since march 8
+
+		{time.Date(2026, 3, 11, 6, 59, 59, 0, time.UTC), []string{"Asia/Kolkata", "UTC"}},
+		{time.Date(2026, 3, 11, 7, 0, 0, 0, time.UTC), []string{"Asia/Kolkata", "UTC", "America/Los_Angeles"}},
+	}
+
+	for _, step := range steps {
+
+		clock.now = step.now
+
+		if _, err := sweeper.Sweep(nil); err != nil {
+			t.Fatalf("sweep at %s: %v", step.now, err)
+		}
+
+		overdue := map[string]bool{}
+
+		for _, tz := range step.overdue {
+			overdue[tz] = true
+		}
+
+		for tz, id := range invoices {
+
+			want := domain.InvoiceStatusSent
+
+			if overdue[tz] {
+				want = domain.InvoiceStatusOverdue
+			}
+
+			if got := invoiceStatus(t, db, id); got != want {
+				t.Errorf("at %s , invoice of %s user is %s , want %s", step.now.Format(time.RFC3339), tz, got, want)
+			}
+		}
+	}
+}
+
+// a manual sweep of one user leaves the others alone , drafts and paid invoices are never touched
+
+func TestOverdueSweepOneUser(t *testing.T) {
+
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)}
+	sweeper := NewOverdueService(db, clock)
+
+	dueDate := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	user := insertTestUser(t, db, "UTC")
+	other := insertTestUser(t, db, "UTC")
+
+	partial := insertTestInvoice(t, db, user, domain.InvoiceStatusPartiallyPaid, dueDate)
+	draft := insertTestInvoice(t, db, user, domain.InvoiceStatusDraft, dueDate)
+	paid := insertTestInvoice(t, db, user, domain.InvoiceStatusPaid, dueDate)
+	othersInvoice := insertTestInvoice(t, db, other, domain.InvoiceStatusSent, dueDate)
+
+	n, err := sweeper.Sweep(&user)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("swept %d invoices , want 1", n)
+	}
+
+	want := map[uuid.UUID]string{
+		partial:       domain.InvoiceStatusOverdue,
+		draft:         domain.InvoiceStatusDraft,
+		paid:          domain.InvoiceStatusPaid,
+		othersInvoice: domain.InvoiceStatusSent,
+	}
+
+	for id, status := range want {
+		if got := invoiceStatus(t, db, id); got != status {
+			t.Errorf("invoice %s is %s , want %s", id, got, status)
+		}
+	}
+
+	// the status change is on the timeline without an acting user
+
+	var changedBy *uuid.UUID
+
+	if err := db.QueryRow(`SELECT changed_by FROM invoice_status_history WHERE invoice_id = $1 AND to_status = $2`, partial, domain.InvoiceStatusOverdue).Scan(&changedBy); err != nil {
+		t.Fatal(err)
+	}
+
+	if changedBy != nil {
+		t.Errorf("overdue change recorded as made by %s , want the system", changedBy)
+	}
+}
+
+func TestLocalDate(t *testing.T) {
+
+	now := time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		timezone string
+		want     time.Time
+	}{
+		{"UTC", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"Asia/Kolkata", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"America/Los_Angeles", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"Pacific/Kiritimati", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+
+		// an unknown zone falls back to UTC
+
+		{"Nowhere/Special", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := localDate(now, tt.timezone); !got.Equal(tt.want) {
+			t.Errorf("localDate(%s) = %s , want %s", tt.timezone, got.Format(dateLayout), tt.want.Format(dateLayout))
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// password service - forgot password , reset through the emailed link and changing the password
+// a new password signs out every session of the user , so their refresh tokens stop working too
+
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+type PasswordService struct {
+	db             *database.DB
+	userService    *UserService
+	sessionService *SessionService
+	mailer         mailer.Mailer
+	clock          util.Clock
+	jwtConfig      *config.JWTConfig
+	appURL         string
+}
+
+// password service function , appURL is the frontend the reset link opens
+// the clock decides when reset links expire
+
+func NewPasswordService(db *database.DB, userService *UserService, sessionService *SessionService, mailer mailer.Mailer, clock util.Clock, jwtConfig *config.JWTConfig, appURL string) *PasswordService {
+	return &PasswordService{
+		db:             db,
+		userService:    userService,
+		sessionService: sessionService,
+		mailer:         mailer,
+		clock:          clock,
+		jwtConfig:      jwtConfig,
+		appURL:         appURL,
+	}
+}
+
+// starting a reset , nothing tells the caller whether the address has an account
+// the lookup is the only work done before answering , the link is written and emailed in the background
+// so a known address takes the same time as an unknown one
+
+func (s *PasswordService) ForgotPassword(req *domain.ForgotPasswordRequest) error {
+
+	if err := util.ValidateStruct(req); err != nil {
+		return domain.ErrInvalidInput
+	}
+
+	user, err := s.userService.GetUserByEmail(req.Email)
+
+	if err == domain.ErrUserNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.startReset(user); err != nil {
+			log.Printf("password reset for %s: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// new reset link for the user , replacing any earlier one , and the email with it
+
+func (s *PasswordService) startReset(user *domain.User) error {
+
+	token, err := util.RandomToken()
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	now := s.clock.Now()
+
+	// only the newest link works
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`, now, user.ID); err != nil {
+		return err
+	}
+
+	query := `
+	       INSERT INTO password_reset_tokens (user_id , token_hash , expires_at , created_at)
+				 VALUES ($1 , $2 , $3 , $4)
+	`
+
+	if _, err := tx.Exec(query, user.ID, util.HashToken(token), now.Add(s.jwtConfig.ResetExpiry), now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return s.sendResetEmail(user, token)
+}
+
+func (s *PasswordService) sendResetEmail(user *domain.User, token string) error {
+
+	link := s.appURL + "/reset-password?token=" + url.QueryEscape(token)
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open this link:\n\n%s\n\nThe link works once and expires on %s. If it wasn't you, you can ignore this email, your password stays the same.",
+		user.FullName, link, s.clock.Now().Add(s.jwtConfig.ResetExpiry).UTC().Format("January 2, 2006 15:04 MST"),
+	)
+
+	err := s.mailer.Send(&mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Body:    body,
+	})
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+	}
+
+	return nil
+}
+
+// setting a new password with the token from the email , the token is used up
+
+func (s *PasswordService) ResetPassword(req *domain.ResetPasswordRequest) error {
+
+	if err := util.ValidateStruct(req); err != nil {
+		return domain.ErrInvalidInput
+	}
+
+	hash, err := util.HashPassword(req.NewPassword)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var (
+		tokenID   uuid.UUID
+		userID    uuid.UUID
+		expiresAt time.Time
+		usedAt    sql.NullTime
+	)
+
+	query := `SELECT id , user_id , expires_at , used_at FROM password_reset_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	err = tx.QueryRow(query, util.HashToken(req.Token)).Scan(&tokenID, &userID, &expiresAt, &usedAt)
+
+	if err == sql.ErrNoRows || usedAt.Valid {
+		return domain.ErrInvalidToken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+
+	if !now.Before(expiresAt) {
+		return domain.ErrTokenExpired
+	}
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2`, now, tokenID); err != nil {
+		return err
+	}
+
+	// a deactivated account can't be reset
+
+	if err := setPassword(tx, userID, hash, now); err == domain.ErrUserNotFound {
+		return domain.ErrInvalidToken
+	} else if err != nil {
+		return err
+	}
+
+	return s.commitSignOut(tx, userID)
+}
+
+// changing the password of a logged in user , the current one has to match
+
+func (s *PasswordService) ChangePassword(userID uuid.UUID, req *domain.ChangePasswordRequest) error {
+
+	if err := util.ValidateStruct(req); err != nil {
+		return domain.ErrInvalidInput
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return err
+	}
+
+	if !util.CheckPassword(req.CurrentPassword, user.PasswordHash) {
+		return domain.ErrIncorrectPassword
+	}
+
+	hash, err := util.HashPassword(req.NewPassword)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := setPassword(tx, userID, hash, s.clock.Now()); err != nil {
+		return err
+	}
+
+	return s.commitSignOut(tx, userID)
+}
+
+// signing out every session with the password change , both are committed together
+
+func (s *PasswordService) commitSignOut(tx *sql.Tx, userID uuid.UUID) error {
+
+	sessionIDs, err := revokeAllSessions(tx, userID, uuid.Nil)
+
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.sessionService.deny(sessionIDs...)
+
+	return nil
+}
+
+func setPassword(q execer, userID uuid.UUID, hash string, now time.Time) error {
+
+	result, err := q.Exec(`UPDATE users SET password_hash = $1 , updated_at = $2 WHERE id = $3 AND is_active = true`, hash, now, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
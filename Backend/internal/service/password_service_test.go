@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+)
+
+// a reset link works up to the instant before it expires , and only once
+
+func TestResetPasswordExpiry(t *testing.T) {
+
+	db := newTestDB(t)
+	clock := &fakeClock{}
+	jwtConfig := &config.JWTConfig{ResetExpiry: time.Hour, AccessExpiry: time.Minute}
+
+	userService := NewUserService(db)
+	passwords := NewPasswordService(db, userService, NewSessionService(db, jwtConfig), &sentMail{}, clock, jwtConfig, "http://localhost")
+
+	userID := insertTestUser(t, db, "UTC")
+	issued := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	newToken := func() string {
+
+		token, err := util.RandomToken()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query := `INSERT INTO password_reset_tokens (user_id , token_hash , expires_at , created_at) VALUES ($1 , $2 , $3 , $4)`
+
+		if _, err := db.Exec(query, userID, util.HashToken(token), issued.Add(jwtConfig.ResetExpiry), issued); err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	reset := func(token string) error {
+		return passwords.ResetPassword(&domain.ResetPasswordRequest{Token: token, NewPassword: "a new password"})
+	}
+
+	expired := newToken()
+	clock.now = issued.Add(time.Hour)
+
+	if err := reset(expired); !errors.Is(err, domain.ErrTokenExpired) {
+		t.Errorf("at expiry: got %v , want ErrTokenExpired", err)
+	}
+
+	valid := newToken()
+	clock.now = issued.Add(time.Hour - time.Second)
+
+	if err := reset(valid); err != nil {
+		t.Fatalf("a second before expiry: %v", err)
+	}
+
+	if err := reset(valid); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("used twice: got %v , want ErrInvalidToken", err)
+	}
+
+	user, err := userService.GetUserByID(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !util.CheckPassword("a new password", user.PasswordHash) {
+		t.Error("password wasn't changed")
+	}
+
+	if !user.UpdatedAt.Equal(clock.now) {
+		t.Errorf("updated_at = %s , want the clock's %s", user.UpdatedAt, clock.now)
+	}
+}
+
+// each reset link replaces the one before , the emailed token is the one that works
+
+func TestStartResetReplacesEarlierLink(t *testing.T) {
+
+	db := newTestDB(t)
+	mail := &sentMail{}
+	jwtConfig := &config.JWTConfig{ResetExpiry: time.Hour, AccessExpiry: time.Minute}
+
+	userService := NewUserService(db)
+	passwords := NewPasswordService(db, userService, NewSessionService(db, jwtConfig), mail, &fakeClock{now: time.Now()}, jwtConfig, "http://localhost")
+
+	user, err := userService.GetUserByID(insertTestUser(t, db, "UTC"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := passwords.startReset(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(*mail) != 2 {
+		t.Fatalf("%d emails , want 2", len(*mail))
+	}
+
+	token := func(msg *mailer.Message) string {
+
+		t.Helper()
+
+		match := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(msg.Body)
+
+		if match == nil {
+			t.Fatalf("no link in %q", msg.Body)
+		}
+
+		token, err := url.QueryUnescape(match[1])
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	reset := func(token string) error {
+		return passwords.ResetPassword(&domain.ResetPasswordRequest{Token: token, NewPassword: "a new password"})
+	}
+
+	if err := reset(token((*mail)[0])); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("first link: got %v , want ErrInvalidToken", err)
+	}
+
+	if err := reset(token((*mail)[1])); err != nil {
+		t.Errorf("second link: %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+// payment service - payments ledger of an invoice
+
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/fx"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+type PaymentService struct {
+	db    *database.DB
+	rates fx.Provider
+}
+
+// payment service function
+
+func NewPaymentService(db *database.DB, rates fx.Provider) *PaymentService {
+	return &PaymentService{db: db, rates: rates}
+}
+
+// invoice fields the ledger needs , read with the row locked
+
+type ledgerInvoice struct {
+	status         string
+	currency       string
+	baseCurrency   string
+	totalAmount    money.Decimal
+	dueDate        time.Time
+	amountPaid     money.Decimal
+	amountCredited money.Decimal
+	timezone       string
+}
+
+// what the client still owes in total , the invoice less its credit notes
+
+func (inv *ledgerInvoice) due() money.Decimal {
+	return inv.totalAmount.Sub(inv.amountCredited)
+}
+
+// locking the invoice row and reading its current paid and credited amounts
+// the owner's timezone comes along , overdue is decided on their own calendar day like the overdue sweep does
+
+func lockLedgerInvoice(tx *sql.Tx, userID, invoiceID uuid.UUID) (*ledgerInvoice, error) {
+
+	inv := &ledgerInvoice{}
+
+	query := `
+	       SELECT i.status , i.currency , i.base_currency , i.total_amount , i.due_date , u.timezone
+				 FROM invoices i JOIN users u ON u.id = i.user_id
+				 WHERE i.id = $1 AND i.user_id = $2 FOR UPDATE OF i
+	`
+
+	err := tx.QueryRow(query, invoiceID, userID).Scan(&inv.status, &inv.currency, &inv.baseCurrency, &inv.totalAmount, &inv.dueDate, &inv.timezone)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`SELECT COALESCE(SUM(amount) , 0) FROM payments WHERE invoice_id = $1`, invoiceID).Scan(&inv.amountPaid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`SELECT COALESCE(SUM(total_amount) , 0) FROM credit_notes WHERE invoice_id = $1`, invoiceID).Scan(&inv.amountCredited)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// status an invoice should have for the amount paid so far , against what's due after credit notes
+// fully paid -> paid , still open past the due date -> overdue , otherwise partially paid or sent
+// an invoice credited in full without any payment is canceled
+// today is the user's calendar date from localDate
+
+func settlementStatus(amountPaid, totalAmount money.Decimal, dueDate, today time.Time) string {
+
+	switch {
+	case totalAmount.Sign() <= 0 && amountPaid.Sign() == 0:
+		return domain.InvoiceStatusCanceled
+	case amountPaid.Cmp(totalAmount) >= 0:
+		return domain.InvoiceStatusPaid
+	case dueDate.Before(today):
+		return domain.InvoiceStatusOverdue
+	case amountPaid.Sign() > 0:
+		return domain.InvoiceStatusPartiallyPaid
+	default:
+		return domain.InvoiceStatusSent
+	}
+}
+
+// moving the invoice to the status matching its ledger and writing the history row
+// paid_date is set when the invoice becomes paid and cleared when it stops being paid
+
+func applySettlement(tx *sql.Tx, userID, invoiceID uuid.UUID, from, to string, paidDate *time.Time, reason string) error {
+
+	if from == to {
+		return nil
+	}
+
+	if to != domain.InvoiceStatusPaid {
+		paidDate = nil
+	}
+
+	query := `UPDATE invoices SET status = $1 , paid_date = $2 , updated_at = $3 WHERE id = $4 AND user_id = $5`
+
+	if _, err := tx.Exec(query, to, paidDate, time.Now(), invoiceID, userID); err != nil {
+		return err
+	}
+
+	return recordStatusChange(tx, invoiceID, &userID, from, to, &reason)
+}
+
+// recording a payment against a sent , partially paid or overdue invoice
+// the invoice moves to paid automatically once the balance reaches zero
+
+func (s *PaymentService) RecordPayment(userID, invoiceID uuid.UUID, req *domain.CreatePaymentRequest) (*domain.Payment, error) {
+
+	paidAt := time.Now()
+
+	if req.PaidAt != nil {
+		var err error
+
+		if paidAt, err = parseDate("paid_at", *req.PaidAt); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	inv, err := lockLedgerInvoice(tx, userID, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// only invoices that were sent and aren't settled or canceled can take payments
+
+	switch inv.status {
+	case domain.InvoiceStatusSent, domain.InvoiceStatusPartiallyPaid, domain.InvoiceStatusOverdue:
+	default:
+		return nil, domain.ErrInvoiceNotPayable
+	}
+
+	// amounts are exact , so a payment can't have more decimals than the currency (no 10.005 USD)
+
+	if req.Amount.Places() > money.MinorUnits(inv.currency) {
+		return nil, fmt.Errorf("amount has more decimal places than %s allows: %w", inv.currency, domain.ErrInvalidInput)
+	}
+
+	// no over payments
+
+	if req.Amount.GreaterThan(inv.due().Sub(inv.amountPaid)) {
+		return nil, domain.ErrPaymentExceedsBalance
+	}
+
+	// rate on the day the money came in , to the same base currency as the invoice
+
+	exchangeRate, err := exchangeRate(s.rates, userID, inv.currency, inv.baseCurrency, paidAt, req.ExchangeRate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &domain.Payment{
+		ID:           uuid.New(),
+		InvoiceID:    invoiceID,
+		UserID:       userID,
+		Amount:       req.Amount,
+		Currency:     inv.currency,
+		BaseCurrency: inv.baseCurrency,
+		ExchangeRate: exchangeRate,
+		Method:       req.Method,
+		Reference:    req.Reference,
+		PaidAt:       paidAt,
+		Notes:        req.Notes,
+		CreatedAt:    time.Now(),
+	}
+
+	query := `
+		      INSERT INTO payments (
+					   id , invoice_id , user_id , amount , currency , method , reference , paid_at , notes , created_at , base_currency , exchange_rate
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12)
+		    `
+
+	_, err = tx.Exec(
+		query,
+		payment.ID, payment.InvoiceID, payment.UserID, payment.Amount, payment.Currency, payment.Method, payment.Reference, payment.PaidAt, payment.Notes, payment.CreatedAt, payment.BaseCurrency, payment.ExchangeRate,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// an overdue invoice stays overdue until it's fully paid
+
+	next := settlementStatus(inv.amountPaid.Add(payment.Amount), inv.due(), inv.dueDate, localDate(time.Now(), inv.timezone))
+
+	if next != inv.status {
+		if err := domain.ValidateStatusTransition(inv.status, next); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = applySettlement(tx, userID, invoiceID, inv.status, next, &paidAt, "payment recorded"); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+
+}
+
+// listing the payments of an invoice , oldest first
+
+func (s *PaymentService) ListPayments(userID, invoiceID uuid.UUID) ([]*domain.Payment, error) {
+
+	var exists bool
+
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM invoices WHERE id = $1 AND user_id = $2)`, invoiceID, userID).Scan(&exists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	query := `
+		     SELECT id , invoice_id , user_id , amount , currency , base_currency , exchange_rate , method , reference , paid_at , notes , created_at
+				 FROM payments WHERE invoice_id = $1 ORDER BY paid_at , created_at
+		   `
+
+	rows, err := s.db.Query(query, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	payments := []*domain.Payment{}
+
+	for rows.Next() {
+		payment := &domain.Payment{}
+
+		err := rows.Scan(
+			&payment.ID, &payment.InvoiceID, &payment.UserID, &payment.Amount, &payment.Currency, &payment.BaseCurrency, &payment.ExchangeRate, &payment.Method, &payment.Reference, &payment.PaidAt, &payment.Notes, &payment.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+
+}
+
+// deleting a wrongly recorded payment , the invoice status follows the remaining balance
+// these moves (for example paid -> partially paid) are driven by the ledger , not by the transition table
+
+func (s *PaymentService) DeletePayment(userID, invoiceID, paymentID uuid.UUID) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	inv, err := lockLedgerInvoice(tx, userID, invoiceID)
+
+	if err != nil {
+		return err
+	}
+
+	var amount money.Decimal
+
+	err = tx.QueryRow(`DELETE FROM payments WHERE id = $1 AND invoice_id = $2 RETURNING amount`, paymentID, invoiceID).Scan(&amount)
+
+	if err == sql.ErrNoRows {
+		return domain.ErrPaymentNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// canceled invoices keep their status , the payment is only taken off the ledger
+
+	if inv.status != domain.InvoiceStatusCanceled {
+
+		// paid date of the latest remaining payment , used if the invoice is still fully paid
+
+		var lastPaidAt *time.Time
+
+		if err = tx.QueryRow(`SELECT MAX(paid_at) FROM payments WHERE invoice_id = $1`, invoiceID).Scan(&lastPaidAt); err != nil {
+			return err
+		}
+
+		next := settlementStatus(inv.amountPaid.Sub(amount), inv.due(), inv.dueDate, localDate(time.Now(), inv.timezone))
+
+		if err = applySettlement(tx, userID, invoiceID, inv.status, next, lastPaidAt, "payment deleted"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+
+}
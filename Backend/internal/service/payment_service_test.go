@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+)
+
+func TestSettlementStatus(t *testing.T) {
+
+	total := money.MustParse("100")
+	dueDate := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	onTime := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		paid  string
+		total money.Decimal
+		today time.Time
+		want  string
+	}{
+		{"nothing paid", "0", total, onTime, domain.InvoiceStatusSent},
+		{"part paid", "40", total, onTime, domain.InvoiceStatusPartiallyPaid},
+		{"paid in full", "100", total, onTime, domain.InvoiceStatusPaid},
+		{"overpaid", "120", total, late, domain.InvoiceStatusPaid},
+		{"nothing paid , late", "0", total, late, domain.InvoiceStatusOverdue},
+		{"part paid , late", "40", total, late, domain.InvoiceStatusOverdue},
+		{"credited in full", "0", money.Zero, late, domain.InvoiceStatusCanceled},
+	}
+
+	for _, tt := range tests {
+		if got := settlementStatus(money.MustParse(tt.paid), tt.total, dueDate, tt.today); got != tt.want {
+			t.Errorf("%s: got %s , want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// a payment deleted at 20:00 UTC on the due date , the invoice is only late where the 11th has already begun
+
+func TestSettlementStatusInUsersTimezone(t *testing.T) {
+
+	now := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+	dueDate := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	total := money.MustParse("100")
+
+	tests := map[string]string{
+		"UTC":                 domain.InvoiceStatusSent,
+		"America/Los_Angeles": domain.InvoiceStatusSent,
+		"Asia/Kolkata":        domain.InvoiceStatusOverdue,
+		"Asia/Tokyo":          domain.InvoiceStatusOverdue,
+	}
+
+	for tz, want := range tests {
+		if got := settlementStatus(money.Zero, total, dueDate, localDate(now, tz)); got != want {
+			t.Errorf("%s: got %s , want %s", tz, got, want)
+		}
+	}
+}
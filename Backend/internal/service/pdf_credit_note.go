@@ -0,0 +1,203 @@
+// credit note pdf - one layout for every template , with the source invoice's client , gst details and the user's brand
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+)
+
+// credit note as an invoice with the credited lines and amounts , so the invoice table / totals / gst helpers draw it
+
+func creditNoteDocument(note *domain.CreditNote, invoice *domain.Invoice) *domain.Invoice {
+
+	doc := *invoice
+
+	doc.Items = make([]*domain.InvoiceItem, len(note.Items))
+
+	for i, line := range note.Items {
+		doc.Items[i] = &domain.InvoiceItem{
+			ID:          line.ID,
+			Description: line.Description,
+			HSNSAC:      line.HSNSAC,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.Amount,
+			TaxRate:     line.TaxRate,
+			TaxAmount:   line.TaxAmount,
+			SortOrder:   line.SortOrder,
+		}
+	}
+
+	doc.Subtotal, doc.TaxAmount, doc.DiscountAmount, doc.TotalAmount = note.Subtotal, note.TaxAmount, note.DiscountAmount, note.TotalAmount
+	doc.CGSTAmount, doc.SGSTAmount, doc.IGSTAmount = note.CGSTAmount, note.SGSTAmount, note.IGSTAmount
+	doc.AmountPaid, doc.AmountCredited, doc.BalanceDue = money.Zero, money.Zero, money.Zero
+
+	// the reason stands in for the notes
+
+	doc.Notes, doc.TermsAndConditions = &note.Reason, nil
+	doc.TaxSummary = taxSummary(&doc)
+
+	return &doc
+}
+
+// credit note pdf , invoice is the source invoice loaded with its client
+
+func (s *PDFService) GenerateCreditNotePDF(note *domain.CreditNote, invoice *domain.Invoice, user *domain.User) ([]byte, error) {
+
+	doc := creditNoteDocument(note, invoice)
+
+	pdf := newPDFDoc(s.fonts)
+
+	renderCreditNote(pdf, note, doc, user, s.loadBrand(pdf, user))
+
+	var buf bytes.Buffer
+
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// business on the left and the credit note title on the right , the source invoice under the details
+// and a totals block ending in the credited total
+
+func renderCreditNote(pdf *pdfDoc, note *domain.CreditNote, doc *domain.Invoice, user *domain.User, brand *pdfBrand) {
+
+	font := brand.useFont(pdf, fontSans)
+	primary := brand.primaryOr(rgb{180, 30, 30})
+	accent := brand.accentOr(rgb{250, 232, 232})
+	contentWidth := pdfPageWidth - 2*pdfMargin
+
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetFooterFunc(pageNumberFooter(pdf, font, note.CreditNoteNumber))
+	pdf.AddPage()
+
+	// business name with the logo above it
+
+	if brand.drawLogo(pdf, pdfMargin, pdfMargin, 16) > 0 {
+		pdf.SetY(pdfMargin + 20)
+	}
+
+	top := pdf.GetY()
+
+	pdf.SetFont(font, "B", 16)
+	pdf.CellFormat(contentWidth/2, 8, user.DisplayName(), "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 9)
+
+	for _, line := range businessLines(user) {
+		pdf.CellFormat(contentWidth/2, 4.5, line, "", 1, "L", false, 0, "")
+	}
+
+	bottom := pdf.GetY()
+
+	// title and numbers on the right
+
+	title := "CREDIT NOTE"
+
+	if doc.TaxScheme == domain.TaxSchemeGST {
+		title = "TAX CREDIT NOTE"
+	}
+
+	pdf.SetXY(pdfMargin+contentWidth/2, top)
+	pdf.SetFont(font, "B", 20)
+	pdf.SetTextColor(primary.r, primary.g, primary.b)
+	pdf.CellFormat(contentWidth/2, 10, title, "", 2, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	details := [][2]string{
+		{"Credit Note No.", note.CreditNoteNumber},
+		{"Date", note.IssueDate.Format(pdfDateLayout)},
+		{"Against Invoice", doc.InvoiceNumber},
+		{"Invoice Date", doc.IssueDate.Format(pdfDateLayout)},
+	}
+
+	details = append(details, gstDetails(doc)...)
+
+	for _, d := range details {
+		pdf.SetX(pdfMargin + contentWidth/2)
+		pdf.SetFont(font, "B", 9)
+		pdf.CellFormat(contentWidth/4, 5, d[0], "", 0, "R", false, 0, "")
+		pdf.SetFont(font, "", 9)
+		pdf.CellFormat(contentWidth/4, 5, d[1], "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetY(max(bottom, pdf.GetY()) + 8)
+
+	// client
+
+	pdf.SetFont(font, "B", 10)
+	pdf.CellFormat(contentWidth, 6, "Credit To", "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 10)
+
+	for _, line := range billToLines(doc) {
+		pdf.CellFormat(contentWidth, 5, line, "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(8)
+
+	// credited lines
+
+	table := &itemsTable{
+		font: font,
+		size: 10,
+		columns: withLineColumns([]tableColumn{
+			{header: "Description", width: contentWidth - 85, align: "L", wrap: true, value: itemDescription},
+			{header: "Qty", width: 25, align: "R", value: itemQuantity},
+			{header: "Unit Price", width: 30, align: "R", value: itemUnitPrice},
+			{header: "Credited", width: 30, align: "R", value: itemAmount},
+		}, doc),
+		header:     tableHeaderStyle{style: "B", size: 9, height: 8, border: "B", fill: &accent},
+		rowHeight:  7,
+		lineHeight: 5,
+		border:     "B",
+		currency:   doc.Currency,
+	}
+
+	table.draw(pdf, doc.Items)
+
+	pdf.Ln(6)
+
+	// totals , the last one is what's credited
+
+	rows := totalRows(doc)
+	rows[len(rows)-1].label = "Total Credit"
+
+	keepTogether(pdf, float64(len(rows))*6+4)
+
+	for _, row := range rows {
+		style := ""
+
+		if row.grand {
+			style = "B"
+			pdf.SetTextColor(primary.r, primary.g, primary.b)
+		}
+
+		pdf.SetFont(font, style, 10)
+		pdf.SetX(pdfMargin + contentWidth - 80)
+		pdf.CellFormat(40, 6, row.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 6, row.value, "", 1, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	pdf.Ln(10)
+
+	drawGSTSummary(pdf, doc, font, contentWidth, "B", &accent)
+
+	// the reason , printed where an invoice has its notes
+
+	pdf.SetFont(font, "B", 10)
+	pdf.CellFormat(0, 6, "Reason", "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 9)
+	pdf.MultiCell(0, 5, note.Reason, "", "L", false)
+
+	pdf.Ln(4)
+	pdf.SetFont(font, "", 9)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.MultiCell(0, 5, fmt.Sprintf("This credit note reduces the amount due on invoice %s by %s.", doc.InvoiceNumber, formatMoney(doc.Currency, note.TotalAmount)), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+}
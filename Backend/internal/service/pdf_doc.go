@@ -0,0 +1,238 @@
+// pdf document - a gofpdf document that picks the font for each run of text
+// text drawn in a role's font is split into runs , characters the role's family has no glyph for go to the first fallback that has one
+// so a cell like "राहुल Sharma 北京" draws each script with a font that can draw it
+
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type pdfDoc struct {
+	*gofpdf.Fpdf
+
+	fonts *pdfFonts
+
+	// font last set with SetFont , a role or a fallback's name
+
+	family string
+	style  string
+	size   float64
+
+	// fallbacks already added to the pdf
+
+	registered map[int]bool
+}
+
+// new A4 document , {nb} in text is replaced by the page count
+
+func newPDFDoc(fonts *pdfFonts) *pdfDoc {
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AliasNbPages("")
+
+	return &pdfDoc{Fpdf: pdf, fonts: fonts, registered: map[int]bool{}}
+}
+
+// one piece of text drawn with one font
+
+type textRun struct {
+	font string
+	text string
+}
+
+func (d *pdfDoc) SetFont(family, style string, size float64) {
+
+	d.Fpdf.SetFont(family, style, size)
+
+	d.family, d.style = family, strings.ToUpper(style)
+	d.size, _ = d.Fpdf.GetFontSize()
+}
+
+// splitting text by the font that can draw it , spaces stay with the run they are in
+// text the current family draws in full is a single run
+
+func (d *pdfDoc) runs(text string) []textRun {
+
+	family, ok := d.fonts.roles[d.family]
+
+	if !ok || len(d.fonts.fallbacks) == 0 || family.missing(text) == 0 {
+		return []textRun{{font: d.family, text: text}}
+	}
+
+	runs := []textRun{}
+
+	var current strings.Builder
+
+	font := d.family
+
+	for _, r := range text {
+
+		next := font
+
+		if !unicode.IsSpace(r) && !unicode.IsControl(r) {
+			next = d.fontFor(family, r)
+		}
+
+		if next != font && current.Len() > 0 {
+			runs = append(runs, textRun{font: font, text: current.String()})
+			current.Reset()
+		}
+
+		font = next
+		current.WriteRune(r)
+	}
+
+	return append(runs, textRun{font: font, text: current.String()})
+}
+
+// the role's family when it has the character , otherwise the first fallback that does (registered on first use)
+// characters no font has stay with the role's family
+
+func (d *pdfDoc) fontFor(family *fontFamily, r rune) string {
+
+	if family.has(r) {
+		return d.family
+	}
+
+	for i, fallback := range d.fonts.fallbacks {
+
+		if !fallback.has(r) {
+			continue
+		}
+
+		if !d.registered[i] {
+			for style, data := range fallback.styles {
+				d.Fpdf.AddUTF8FontFromBytes(fallbackFontName(i), style, data)
+			}
+
+			d.registered[i] = true
+		}
+
+		return fallbackFontName(i)
+	}
+
+	return d.family
+}
+
+func fallbackFontName(i int) string {
+	return "fallback" + string(rune('a'+i))
+}
+
+// running fn with the run's font set , the document's font is set back afterwards
+
+func (d *pdfDoc) withFont(run textRun, fn func()) {
+
+	if run.font == d.family {
+		fn()
+		return
+	}
+
+	d.Fpdf.SetFont(run.font, d.style, d.size)
+	fn()
+	d.Fpdf.SetFont(d.family, d.style, d.size)
+}
+
+func (d *pdfDoc) GetStringWidth(text string) float64 {
+
+	runs := d.runs(text)
+
+	if len(runs) == 1 {
+		return d.Fpdf.GetStringWidth(text)
+	}
+
+	width := 0.0
+
+	for _, run := range runs {
+		d.withFont(run, func() { width += d.Fpdf.GetStringWidth(run.text) })
+	}
+
+	return width
+}
+
+func (d *pdfDoc) Cell(w, h float64, text string) {
+	d.CellFormat(w, h, text, "", 0, "L", false, 0, "")
+}
+
+// a cell with text in more than one font is drawn as an empty cell (border and fill) with the runs laid over it
+// the runs are placed by the cell's alignment , and the position moves on like one cell would
+
+func (d *pdfDoc) CellFormat(w, h float64, text, border string, ln int, align string, fill bool, link int, linkStr string) {
+
+	runs := d.runs(text)
+
+	if len(runs) == 1 {
+		d.Fpdf.CellFormat(w, h, text, border, ln, align, fill, link, linkStr)
+		return
+	}
+
+	x, y := d.GetXY()
+
+	if w == 0 {
+		pageWidth, _ := d.GetPageSize()
+		_, _, right, _ := d.GetMargins()
+		w = pageWidth - right - x
+	}
+
+	margin := d.GetCellMargin()
+	width := d.GetStringWidth(text)
+
+	start := x + margin
+
+	switch {
+	case strings.Contains(align, "R"):
+		start = x + w - margin - width
+	case strings.Contains(align, "C"):
+		start = x + (w-width)/2
+	}
+
+	// the box first , then the runs without cell margins of their own
+
+	d.Fpdf.CellFormat(w, h, "", border, 0, "", fill, link, linkStr)
+
+	d.SetCellMargin(0)
+	d.SetXY(start, y)
+
+	vertical := strings.Trim(align, "LCR")
+
+	for _, run := range runs {
+		d.withFont(run, func() {
+			d.Fpdf.CellFormat(d.Fpdf.GetStringWidth(run.text), h, run.text, "", 0, "L"+vertical, false, 0, "")
+		})
+	}
+
+	d.SetCellMargin(margin)
+
+	switch ln {
+	case 1:
+		left, _, _, _ := d.GetMargins()
+		d.SetXY(left, y+h)
+	case 2:
+		d.SetXY(x, y+h)
+	default:
+		d.SetXY(x+w, y)
+	}
+}
+
+// text in more than one font is wrapped with wrapText and drawn a line at a time
+
+func (d *pdfDoc) MultiCell(w, h float64, text, border, align string, fill bool) {
+
+	if len(d.runs(text)) == 1 {
+		d.Fpdf.MultiCell(w, h, text, border, align, fill)
+		return
+	}
+
+	if w == 0 {
+		pageWidth, _ := d.GetPageSize()
+		_, _, right, _ := d.GetMargins()
+		w = pageWidth - right - d.GetX()
+	}
+
+	for _, line := range wrapText(d, text, w) {
+		d.CellFormat(w, h, line, border, 2, align, fill, 0, "")
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+)
+
+// dejavu cut down to ascii as the role's family , with the full font as the only fallback
+// stands in for a latin font plus a noto fallback , which the repo doesn't ship
+
+func asciiOnlyFonts(t *testing.T) *pdfFonts {
+
+	t.Helper()
+
+	full, err := loadFontFamily(testFontDir, "DejaVuSansCondensed")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ascii := *full
+	ascii.ranges = [][2]rune{{0x20, 0x7e}}
+
+	return &pdfFonts{
+		roles:     map[string]*fontFamily{fontSans: &ascii, fontSerif: &ascii, fontMono: &ascii},
+		fallbacks: []*fontFamily{full},
+	}
+}
+
+func TestPDFDocRuns(t *testing.T) {
+
+	fonts := asciiOnlyFonts(t)
+
+	doc := newPDFDoc(fonts)
+	fonts.register(doc.Fpdf, fontSans)
+	doc.SetFont(fontSans, "B", 10)
+
+	got := doc.runs("José Müller ₹")
+
+	want := []textRun{
+		{fontSans, "Jos"},
+		{"fallbacka", "é "},
+		{fontSans, "M"},
+		{"fallbacka", "ü"},
+		{fontSans, "ller "},
+		{"fallbacka", "₹"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runs = %q , want %q", got, want)
+	}
+
+	// text the role's family has in full is one run , and a run doesn't change the document's font
+
+	if got := doc.runs("Invoice 42"); len(got) != 1 {
+		t.Errorf("ascii text split into %d runs", len(got))
+	}
+
+	if doc.family != fontSans || doc.style != "B" || doc.size != 10 {
+		t.Errorf("font after runs is %s %s %v", doc.family, doc.style, doc.size)
+	}
+}
+
+// a cell drawn in several runs is as wide as the text and leaves the position where one cell would
+
+func TestPDFDocCellFormatPosition(t *testing.T) {
+
+	fonts := asciiOnlyFonts(t)
+
+	plain := newPDFDoc(&pdfFonts{roles: map[string]*fontFamily{fontSans: fonts.fallbacks[0]}})
+	mixed := newPDFDoc(fonts)
+
+	for _, doc := range []*pdfDoc{plain, mixed} {
+		doc.fonts.register(doc.Fpdf, fontSans)
+		doc.AddPage()
+		doc.SetFont(fontSans, "", 10)
+	}
+
+	text := "José Müller"
+
+	if p, m := plain.GetStringWidth(text), mixed.GetStringWidth(text); p-m > 0.01 || m-p > 0.01 {
+		t.Errorf("width of %q is %v in runs , %v in one font", text, m, p)
+	}
+
+	for _, ln := range []int{0, 1, 2} {
+		for _, doc := range []*pdfDoc{plain, mixed} {
+			doc.SetXY(30, 40)
+			doc.CellFormat(60, 8, text, "1", ln, "R", false, 0, "")
+		}
+
+		px, py := plain.GetXY()
+		mx, my := mixed.GetXY()
+
+		if px != mx || py != my {
+			t.Errorf("ln %d: position after the cell is (%v , %v) , want (%v , %v)", ln, mx, my, px, py)
+		}
+	}
+}
+
+// names , items and notes in several scripts come back out of the pdf , the characters the main font lacks drawn with the fallback
+
+func TestInvoicePDFMixedScriptRoundTrip(t *testing.T) {
+
+	s := newTestPDFService(t)
+	s.fonts = asciiOnlyFonts(t)
+
+	for template := range pdfTemplates {
+
+		invoice, user := testInvoice(template, 2)
+
+		user.BusinessName = strPtr("Crème Brûlée Café")
+		invoice.Client.Name = "José Müller"
+		invoice.Items[0].Description = "Größe XL , façade € 12"
+		invoice.Notes = strPtr("Merci beaucoup , Zoë")
+
+		data, err := s.GenerateInvoicePDF(invoice, user)
+
+		if err != nil {
+			t.Fatalf("%s: %v", template, err)
+		}
+
+		texts := pdfTexts(t, data)
+
+		joined := ""
+
+		for _, text := range texts {
+			joined += text.text
+		}
+
+		for _, want := range []string{"Crème Brûlée Café", "José Müller", "Größe XL , façade € 12", "Merci beaucoup , Zoë", "₹"} {
+			if !strings.Contains(joined, want) {
+				t.Errorf("%s: %q not in the pdf", template, want)
+			}
+		}
+
+		fonts := map[string]string{}
+
+		for _, text := range texts {
+			if strings.ContainsAny(text.text, "éüö€₹") {
+				fonts["fallback"] = text.font
+			}
+
+			if strings.Contains(text.text, "Jos") {
+				fonts["main"] = text.font
+			}
+		}
+
+		if fonts["fallback"] == "" || fonts["fallback"] == fonts["main"] {
+			t.Errorf("%s: accented text drawn with %q , the rest with %q", template, fonts["fallback"], fonts["main"])
+		}
+	}
+}
+
+// a fallback that isn't installed is skipped instead of failing the service
+
+func TestMissingFallbackFont(t *testing.T) {
+
+	s := newTestPDFService(t, "NoSuchFont")
+
+	if len(s.fonts.fallbacks) != 0 {
+		t.Errorf("%d fallbacks loaded , want 0", len(s.fonts.fallbacks))
+	}
+}
+
+// with the default noto fallbacks in place (`make fonts`) hindi and chinese text come back out of the pdf
+
+func TestInvoicePDFNotoRoundTrip(t *testing.T) {
+
+	for _, name := range []string{"NotoSansDevanagari-Regular.ttf", "NotoSansSC-Regular.ttf"} {
+		if _, err := os.Stat(filepath.Join(testFontDir, name)); err != nil {
+			t.Skipf("%s not installed , run make fonts", name)
+		}
+	}
+
+	s := newTestPDFService(t, "NotoSansDevanagari", "NotoSansSC")
+
+	invoice, user := testInvoice(domain.TemplateDefault, 2)
+
+	invoice.Client.Name = "राहुल शर्मा"
+	invoice.Items[0].Description = "वेबसाइट डिज़ाइन , 北京 office"
+	invoice.Notes = strPtr("谢谢 , धन्यवाद")
+
+	data, err := s.GenerateInvoicePDF(invoice, user)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := ""
+
+	for _, text := range pdfTexts(t, data) {
+		joined += text.text
+	}
+
+	for _, want := range []string{"राहुल शर्मा", "वेबसाइट डिज़ाइन , 北京 office", "谢谢 , धन्यवाद"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("%q not in the pdf", want)
+		}
+	}
+}
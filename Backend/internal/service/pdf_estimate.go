@@ -0,0 +1,51 @@
+// estimate pdf - the estimate drawn with the invoice template it picked
+
+package service
+
+import (
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+)
+
+// estimate as an invoice , so the templates and tax helpers draw it
+// the expiry date takes the place of the due date
+
+func estimateDocument(estimate *domain.Estimate) *domain.Invoice {
+
+	return &domain.Invoice{
+		ID:                 estimate.ID,
+		UserID:             estimate.UserID,
+		ClientID:           estimate.ClientID,
+		InvoiceNumber:      estimate.EstimateNumber,
+		Status:             estimate.Status,
+		IssueDate:          estimate.IssueDate,
+		DueDate:            estimate.ExpiryDate,
+		Currency:           estimate.Currency,
+		Subtotal:           estimate.Subtotal,
+		TaxRate:            estimate.TaxRate,
+		TaxAmount:          estimate.TaxAmount,
+		TaxScheme:          estimate.TaxScheme,
+		RoundingMode:       estimate.RoundingMode,
+		TaxRounding:        estimate.TaxRounding,
+		PlaceOfSupply:      estimate.PlaceOfSupply,
+		SellerGSTIN:        estimate.SellerGSTIN,
+		BuyerGSTIN:         estimate.BuyerGSTIN,
+		CGSTAmount:         estimate.CGSTAmount,
+		SGSTAmount:         estimate.SGSTAmount,
+		IGSTAmount:         estimate.IGSTAmount,
+		DiscountAmount:     estimate.DiscountAmount,
+		TotalAmount:        estimate.TotalAmount,
+		BalanceDue:         estimate.TotalAmount,
+		TemplateID:         estimate.TemplateID,
+		Notes:              estimate.Notes,
+		TermsAndConditions: estimate.TermsAndConditions,
+		Items:              estimate.Items,
+		Client:             estimate.Client,
+		TaxSummary:         estimate.TaxSummary,
+	}
+}
+
+// estimate pdf , rendered on every request as estimates are small and change until they're answered
+
+func (s *PDFService) GenerateEstimatePDF(estimate *domain.Estimate, user *domain.User) ([]byte, error) {
+	return s.renderDocument(estimateDocument(estimate), user, estimateLabels)
+}
@@ -0,0 +1,294 @@
+// pdf fonts - unicode truetype fonts embedded in the generated pdfs
+// gofpdf's core fonts only know latin-1 , so names like "José" , "राहुल" or the rupee sign need a truetype font
+
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// font roles the templates ask for , each one is registered in the pdf as a family of that name
+
+const (
+	fontSans  = "sans"
+	fontSerif = "serif"
+	fontMono  = "mono"
+)
+
+// gofpdf styles and the file name endings tried for each , in order
+
+var fontStyleSuffixes = []struct {
+	style    string
+	suffixes []string
+}{
+	{"", []string{"", "-Regular"}},
+	{"B", []string{"-Bold"}},
+	{"I", []string{"-Italic", "-Oblique"}},
+	{"BI", []string{"-BoldItalic", "-BoldOblique"}},
+}
+
+// one font family , ttf data by gofpdf style and the characters the regular style has glyphs for
+
+type fontFamily struct {
+	name   string
+	styles map[string][]byte
+	ranges [][2]rune
+}
+
+// families by role , plus the fallbacks tried for characters a role's family has no glyph for
+
+type pdfFonts struct {
+	roles     map[string]*fontFamily
+	fallbacks []*fontFamily
+}
+
+// reading the configured fonts , the sans font is required and stands in for missing serif / mono fonts
+
+func loadPDFFonts(cfg *config.PDFConfig) (*pdfFonts, error) {
+
+	sans, err := loadFontFamily(cfg.FontDir, cfg.SansFont)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fonts := &pdfFonts{roles: map[string]*fontFamily{fontSans: sans, fontSerif: sans, fontMono: sans}}
+
+	for role, name := range map[string]string{fontSerif: cfg.SerifFont, fontMono: cfg.MonoFont} {
+		if name == "" {
+			continue
+		}
+
+		if fonts.roles[role], err = loadFontFamily(cfg.FontDir, name); err != nil {
+			return nil, err
+		}
+	}
+
+	// fallbacks are configured by default but not shipped , a missing one only loses its script
+
+	for _, name := range cfg.FallbackFonts {
+		family, err := loadFontFamily(cfg.FontDir, name)
+
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("pdf: fallback font %s not found in %s , text it covers won't render", name, cfg.FontDir)
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		fonts.fallbacks = append(fonts.fallbacks, family)
+	}
+
+	return fonts, nil
+}
+
+// reading one family , styles without a file of their own use the regular one
+// every file is parsed once here , gofpdf only prints parse errors when a pdf is rendered
+
+func loadFontFamily(dir, name string) (*fontFamily, error) {
+
+	if name == "" {
+		return nil, fmt.Errorf("pdf font name is empty")
+	}
+
+	family := &fontFamily{name: name, styles: map[string][]byte{}}
+
+	for _, s := range fontStyleSuffixes {
+		for _, suffix := range s.suffixes {
+			data, err := os.ReadFile(filepath.Join(dir, name+suffix+".ttf"))
+
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			family.styles[s.style] = data
+			break
+		}
+	}
+
+	regular, ok := family.styles[""]
+
+	if !ok {
+		return nil, fmt.Errorf("pdf font %s not found in %s: %w", name, dir, os.ErrNotExist)
+	}
+
+	for _, s := range fontStyleSuffixes {
+		if _, ok := family.styles[s.style]; !ok {
+			family.styles[s.style] = regular
+		}
+	}
+
+	check := gofpdf.New("P", "mm", "A4", "")
+
+	for style, data := range family.styles {
+		check.AddUTF8FontFromBytes(name, style, data)
+		check.SetFont(name, style, 10)
+
+		if err := check.Error(); err != nil {
+			return nil, fmt.Errorf("pdf font %s (style %q) can't be used: %w", name, style, err)
+		}
+	}
+
+	ranges, err := fontCoverage(regular)
+
+	if err != nil {
+		return nil, fmt.Errorf("pdf font %s: %w", name, err)
+	}
+
+	family.ranges = ranges
+
+	return family, nil
+}
+
+// how many characters of the text the family has no glyph for , spaces and control characters don't count
+
+func (f *fontFamily) missing(text string) int {
+
+	n := 0
+
+	for _, r := range text {
+		if !unicode.IsSpace(r) && !unicode.IsControl(r) && !f.has(r) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// whether the family has a glyph for the character
+
+func (f *fontFamily) has(r rune) bool {
+
+	i := sort.Search(len(f.ranges), func(i int) bool { return f.ranges[i][1] >= r })
+
+	return i < len(f.ranges) && f.ranges[i][0] <= r
+}
+
+// registering a role's family with the pdf , a no-op when it's already there
+
+func (f *pdfFonts) register(pdf *gofpdf.Fpdf, role string) {
+
+	for style, data := range f.roles[role].styles {
+		pdf.AddUTF8FontFromBytes(role, style, data)
+	}
+}
+
+// character ranges with glyphs , read from the font's unicode cmap (format 12 when there is one , else format 4)
+
+func fontCoverage(data []byte) ([][2]rune, error) {
+
+	errBadFont := fmt.Errorf("not a truetype font")
+
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+
+	var cmap []byte
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+
+	for i := 0; i < numTables; i++ {
+		entry := 12 + 16*i
+
+		if entry+16 > len(data) {
+			return nil, errBadFont
+		}
+
+		if string(data[entry:entry+4]) == "cmap" {
+			offset := int(binary.BigEndian.Uint32(data[entry+8:]))
+			length := int(binary.BigEndian.Uint32(data[entry+12:]))
+
+			if offset+length > len(data) {
+				return nil, errBadFont
+			}
+
+			cmap = data[offset : offset+length]
+		}
+	}
+
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("font has no cmap table")
+	}
+
+	var format4, format12 []byte
+
+	for i := 0; i < int(binary.BigEndian.Uint16(cmap[2:])); i++ {
+		record := 4 + 8*i
+
+		if record+8 > len(cmap) {
+			return nil, errBadFont
+		}
+
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+
+		// unicode platform , or windows unicode bmp (1) / full repertoire (10)
+
+		if (platform != 0 && platform != 3) || (platform == 3 && encoding != 1 && encoding != 10) || offset+2 > len(cmap) {
+			continue
+		}
+
+		switch binary.BigEndian.Uint16(cmap[offset:]) {
+		case 4:
+			format4 = cmap[offset:]
+		case 12:
+			format12 = cmap[offset:]
+		}
+	}
+
+	var ranges [][2]rune
+
+	switch {
+	case len(format12) >= 16:
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+
+		for i := 0; i < groups && 16+12*i+12 <= len(format12); i++ {
+			group := format12[16+12*i:]
+			ranges = append(ranges, [2]rune{rune(binary.BigEndian.Uint32(group)), rune(binary.BigEndian.Uint32(group[4:]))})
+		}
+
+	case len(format4) >= 14:
+		segments := int(binary.BigEndian.Uint16(format4[6:])) / 2
+
+		if 16+4*segments > len(format4) {
+			return nil, errBadFont
+		}
+
+		for i := 0; i < segments; i++ {
+			end := rune(binary.BigEndian.Uint16(format4[14+2*i:]))
+			start := rune(binary.BigEndian.Uint16(format4[16+2*segments+2*i:]))
+
+			// the last segment is the 0xFFFF terminator
+
+			if start == 0xFFFF {
+				continue
+			}
+
+			ranges = append(ranges, [2]rune{start, end})
+		}
+
+	default:
+		return nil, fmt.Errorf("font has no unicode cmap")
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	return ranges, nil
+}
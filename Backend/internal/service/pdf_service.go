@@ -5,60 +5,208 @@ package service
 import (
 	"bytes"
 	"fmt"
+	"log"
+	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/database"
 	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/storage"
 	"github.com/jung-kurt/gofpdf"
 )
 
-type PDFService struct{}
+// dates as printed on invoices
 
-// pdf service function
+const pdfDateLayout = "January 2, 2006"
 
-func NewPDFService() *PDFService {
-	return &PDFService{}
+type PDFService struct {
+	db      *database.DB
+	storage storage.Storage
+	fonts   *pdfFonts
 }
 
-//  invoice pdf function
+// pdf service function , storage keeps business logos , cached pdfs and archived copies
+// fails when the configured fonts can't be loaded
 
-func (s *PDFService) GenerateInvoicePDF(invoice *domain.Invoice, user *domain.User) ([]byte, error) {
+func NewPDFService(db *database.DB, storage storage.Storage, cfg *config.PDFConfig) (*PDFService, error) {
 
-	// writing pdf conventions
+	fonts, err := loadPDFFonts(cfg)
 
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
+	if err != nil {
+		return nil, err
+	}
 
-	// setting font
+	return &PDFService{db: db, storage: storage, fonts: fonts}, nil
+}
 
-	pdf.SetFont("Arial", "", 12)
+// one pdf layout , picked by the invoice's template_id
 
-	// header of the invoice - business information
+type pdfTemplate interface {
+	render(pdf *pdfDoc, invoice *domain.Invoice, user *domain.User, brand *pdfBrand, labels pdfLabels)
+}
 
-	s.addHeader(pdf, user, invoice)
+type rgb struct {
+	r, g, b int
+}
 
-	// client information
+// user's brand for one render , empty fields mean the template's own colours and font
 
-	s.addClientInfo(pdf, invoice.Client)
+type pdfBrand struct {
+	primary   *rgb
+	accent    *rgb
+	font      string
+	logo      string
+	logoRatio float64
+	fonts     *pdfFonts
+}
 
-	// invoice details
+func (b *pdfBrand) primaryOr(def rgb) rgb {
+	if b.primary != nil {
+		return *b.primary
+	}
+	return def
+}
 
-	s.addInvoiceDetails(pdf, invoice)
+func (b *pdfBrand) accentOr(def rgb) rgb {
+	if b.accent != nil {
+		return *b.accent
+	}
+	return def
+}
 
-	// line items table
+// the brand font (or the template's default role) , registered with the pdf and ready for SetFont
 
-	s.addItemsTable(pdf, invoice.Items)
+func (b *pdfBrand) useFont(pdf *pdfDoc, def string) string {
 
-	// totals
+	role := def
 
-	s.addTotals(pdf, invoice)
+	if b.font != "" {
+		role = b.font
+	}
 
-	// notes and terms
+	b.fonts.register(pdf.Fpdf, role)
+
+	return role
+}
+
+// drawing the logo with the given height , returns the width it took (0 without a logo)
+
+func (b *pdfBrand) drawLogo(pdf *pdfDoc, x, y, h float64) float64 {
+
+	if b.logo == "" {
+		return 0
+	}
+
+	w := h * b.logoRatio
+
+	pdf.ImageOptions(b.logo, x, y, w, h, false, gofpdf.ImageOptions{}, 0, "")
+
+	return w
+}
+
+// font role for each brand font
+
+var brandFonts = map[string]string{
+	domain.BrandFontHelvetica: fontSans,
+	domain.BrandFontTimes:     fontSerif,
+	domain.BrandFontCourier:   fontMono,
+}
+
+// "#1a2b3c" to rgb , nil when it isn't a colour
+
+func parseHexColor(value *string) *rgb {
+
+	if value == nil || len(*value) != 7 || (*value)[0] != '#' {
+		return nil
+	}
+
+	n, err := strconv.ParseUint((*value)[1:], 16, 32)
+
+	if err != nil {
+		return nil
+	}
+
+	return &rgb{r: int(n >> 16 & 0xff), g: int(n >> 8 & 0xff), b: int(n & 0xff)}
+}
+
+// building the brand of a user , registering the logo with the pdf
+// a logo that can't be loaded is left out instead of failing the whole invoice
+
+func (s *PDFService) loadBrand(pdf *pdfDoc, user *domain.User) *pdfBrand {
+
+	brand := &pdfBrand{
+		primary: parseHexColor(user.BrandPrimaryColor),
+		accent:  parseHexColor(user.BrandAccentColor),
+		fonts:   s.fonts,
+	}
+
+	if user.BrandFont != nil {
+		brand.font = brandFonts[*user.BrandFont]
+	}
+
+	if user.LogoKey == nil || *user.LogoKey == "" || s.storage == nil {
+		return brand
+	}
+
+	data, err := s.storage.Get(*user.LogoKey)
+
+	if err != nil {
+		log.Printf("pdf: loading logo %s: %v", *user.LogoKey, err)
+		return brand
+	}
+
+	imageType := "PNG"
+
+	if http.DetectContentType(data) == "image/jpeg" {
+		imageType = "JPG"
+	}
+
+	info := pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+
+	if pdf.Ok() && info != nil && info.Height() > 0 {
+		brand.logo = "logo"
+		brand.logoRatio = info.Width() / info.Height()
+	} else {
+		log.Printf("pdf: logo %s can't be embedded: %v", *user.LogoKey, pdf.Error())
+		pdf.ClearError()
+	}
+
+	return brand
+}
+
+// every layout by template id , ids match the domain.Template* constants
+
+var pdfTemplates = map[string]pdfTemplate{
+	domain.TemplateDefault:      defaultTemplate{},
+	domain.TemplateModern:       modernTemplate{},
+	domain.TemplateMinimal:      minimalTemplate{},
+	domain.TemplateProfessional: professionalTemplate{},
+}
+
+//  invoice pdf function
+// unknown template ids (only possible for old rows) fall back to the default layout
+
+func (s *PDFService) GenerateInvoicePDF(invoice *domain.Invoice, user *domain.User) ([]byte, error) {
+	return s.renderDocument(invoice, user, invoiceLabels(invoice))
+}
+
+// rendering an invoice shaped document with the template it picked , labels say what kind of document it is
+
+func (s *PDFService) renderDocument(invoice *domain.Invoice, user *domain.User, labels pdfLabels) ([]byte, error) {
+
+	tpl, ok := pdfTemplates[invoice.TemplateID]
 
-	s.addNotesAndTerms(pdf, invoice)
+	if !ok {
+		tpl = pdfTemplates[domain.TemplateDefault]
+	}
+
+	// writing pdf conventions
 
-	// footer part of the invoice
+	pdf := newPDFDoc(s.fonts)
 
-	s.addFooter(pdf)
+	tpl.render(pdf, invoice, user, s.loadBrand(pdf, user), labels)
 
 	//getting the pdf as bytes
 
@@ -71,13 +219,73 @@ func (s *PDFService) GenerateInvoicePDF(invoice *domain.Invoice, user *domain.Us
 	return buf.Bytes(), nil
 }
 
+// DEFAULT TEMPLATE - the original layout
+
+type defaultTemplate struct {
+	font   string
+	brand  *pdfBrand
+	labels pdfLabels
+}
+
+func (t defaultTemplate) render(pdf *pdfDoc, invoice *domain.Invoice, user *domain.User, brand *pdfBrand, labels pdfLabels) {
+
+	t.font = brand.useFont(pdf, fontSans)
+	t.brand = brand
+	t.labels = labels
+
+	// footer part of the invoice , drawn on every page by gofpdf
+
+	pdf.SetFooterFunc(func() { t.addFooter(pdf) })
+
+	pdf.AddPage()
+
+	// setting font
+
+	pdf.SetFont(t.font, "", 12)
+
+	// header of the invoice - business information
+
+	t.addHeader(pdf, user, invoice)
+
+	// client information
+
+	t.addClientInfo(pdf, invoice)
+
+	// invoice details
+
+	t.addInvoiceDetails(pdf, invoice)
+
+	// line items table
+
+	t.addItemsTable(pdf, invoice)
+
+	// totals
+
+	t.addTotals(pdf, invoice)
+
+	// gst summary by rate
+
+	accent := t.brand.accentOr(rgb{200, 220, 255})
+	drawGSTSummary(pdf, invoice, t.font, 190, "1", &accent)
+
+	// notes and terms
+
+	t.addNotesAndTerms(pdf, invoice)
+}
+
 // add header function
 
-func (s *PDFService) addHeader(pdf *gofpdf.Fpdf, user *domain.User, invoice *domain.Invoice) {
+func (t defaultTemplate) addHeader(pdf *pdfDoc, user *domain.User, invoice *domain.Invoice) {
+
+	// logo in the top right corner , 20mm high
+
+	if t.brand.logo != "" {
+		t.brand.drawLogo(pdf, 200-20*t.brand.logoRatio, 10, 20)
+	}
 
 	// company name
 
-	pdf.SetFont("Arial", "B", 20)
+	pdf.SetFont(t.font, "B", 20)
 
 	if user.BusinessName != nil && *user.BusinessName != "" {
 		pdf.Cell(0, 10, *user.BusinessName)
@@ -89,7 +297,7 @@ func (s *PDFService) addHeader(pdf *gofpdf.Fpdf, user *domain.User, invoice *dom
 
 	// company details
 
-	pdf.SetFont("Arial", "", 10)
+	pdf.SetFont(t.font, "", 10)
 
 	if user.BusinessAddress != nil && *user.BusinessAddress != "" {
 		pdf.Cell(0, 5, *user.BusinessAddress)
@@ -115,9 +323,11 @@ func (s *PDFService) addHeader(pdf *gofpdf.Fpdf, user *domain.User, invoice *dom
 
 	//invoice title section
 
-	pdf.SetFont("Arial", "B", 24)
-	pdf.SetTextColor(0, 102, 204)
-	pdf.Cell(0, 10, "INVOICE")
+	primary := t.brand.primaryOr(rgb{0, 102, 204})
+
+	pdf.SetFont(t.font, "B", 24)
+	pdf.SetTextColor(primary.r, primary.g, primary.b)
+	pdf.Cell(0, 10, t.labels.title)
 	pdf.SetTextColor(0, 0, 0)
 	pdf.Ln(12)
 
@@ -125,17 +335,19 @@ func (s *PDFService) addHeader(pdf *gofpdf.Fpdf, user *domain.User, invoice *dom
 
 //  client information function
 
-func (s *PDFService) addClientInfo(pdf *gofpdf.Fpdf, client *domain.Client) {
+func (t defaultTemplate) addClientInfo(pdf *pdfDoc, invoice *domain.Invoice) {
+
+	client := invoice.Client
 
 	if client == nil {
 		return
 	}
 
-	pdf.SetFont("Arial", "B", 11)
+	pdf.SetFont(t.font, "B", 11)
 	pdf.Cell(0, 6, "Bill To:")
 	pdf.Ln(6)
 
-	pdf.SetFont("Arial", "", 10)
+	pdf.SetFont(t.font, "", 10)
 	pdf.Cell(0, 5, client.Name)
 	pdf.Ln(5)
 
@@ -187,75 +399,94 @@ func (s *PDFService) addClientInfo(pdf *gofpdf.Fpdf, client *domain.Client) {
 		pdf.Ln(5)
 	}
 
+	for _, line := range gstBuyerLines(invoice) {
+		pdf.Cell(0, 5, line)
+		pdf.Ln(5)
+	}
+
 	pdf.Ln(8)
 
 }
 
 // invoice details  function like issue date , invoice number , status , due date
 
-func (s *PDFService) addInvoiceDetails(pdf *gofpdf.Fpdf, invoice *domain.Invoice) {
+func (t defaultTemplate) addInvoiceDetails(pdf *pdfDoc, invoice *domain.Invoice) {
 
-	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(40, 6, "Invoice Number:")
-	pdf.SetFont("Arial", "", 10)
+	pdf.SetFont(t.font, "B", 10)
+	pdf.Cell(40, 6, t.labels.noun+" Number:")
+	pdf.SetFont(t.font, "", 10)
 	pdf.Cell(0, 6, invoice.InvoiceNumber)
 	pdf.Ln(6)
 
 	// issue date
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(t.font, "B", 10)
 	pdf.Cell(40, 6, "Issue Date:")
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, invoice.IssueDate.Format("February 19, 2026"))
+	pdf.SetFont(t.font, "", 10)
+	pdf.Cell(0, 6, invoice.IssueDate.Format(pdfDateLayout))
 	pdf.Ln(6)
 
 	//due date
-	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(40, 6, "Due Date:")
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, invoice.DueDate.Format("February 19, 2026"))
+	pdf.SetFont(t.font, "B", 10)
+	pdf.Cell(40, 6, t.labels.due+":")
+	pdf.SetFont(t.font, "", 10)
+	pdf.Cell(0, 6, invoice.DueDate.Format(pdfDateLayout))
 	pdf.Ln(6)
 
 	// status
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(t.font, "B", 10)
 	pdf.Cell(40, 6, "Status:")
-	pdf.SetFont("Arial", "", 10)
+	pdf.SetFont(t.font, "", 10)
 	pdf.Cell(0, 6, invoice.Status)
-	pdf.Ln(10)
-
-}
-
-//  adding items table
-
-func (s *PDFService) addItemsTable(pdf *gofpdf.Fpdf, items []*domain.InvoiceItem) {
-
-	// table header
+	pdf.Ln(6)
 
-	pdf.SetFillColor(200, 220, 255)
-	pdf.SetFont("Arial", "B", 10)
+	// gstins and place of supply on gst invoices
 
-	pdf.CellFormat(90, 8, "Description", "1", 0, "L", true, 0, "")
-	pdf.CellFormat(30, 8, "Quantity", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(35, 8, "Unit Price", "1", 0, "R", true, 0, "")
-	pdf.CellFormat(35, 8, "Amount", "1", 1, "R", true, 0, "")
+	for _, d := range gstDetails(invoice) {
+		pdf.SetFont(t.font, "B", 10)
+		pdf.Cell(40, 6, d[0]+":")
+		pdf.SetFont(t.font, "", 10)
+		pdf.Cell(0, 6, d[1])
+		pdf.Ln(6)
+	}
 
-	// table body
+	pdf.Ln(4)
 
-	pdf.SetFont("Arial", "", 10)
+}
 
-	for _, item := range items {
+//  adding items table , wraps and breaks across pages
+
+func (t defaultTemplate) addItemsTable(pdf *pdfDoc, invoice *domain.Invoice) {
+
+	accent := t.brand.accentOr(rgb{200, 220, 255})
+
+	table := &itemsTable{
+		font: t.font,
+		size: 10,
+		columns: withLineColumns([]tableColumn{
+			{header: "Description", width: 90, align: "L", wrap: true, value: itemDescription},
+			{header: "Quantity", width: 30, align: "C", value: itemQuantity},
+			{header: "Unit Price", width: 35, align: "R", value: itemUnitPrice},
+			{header: "Amount", width: 35, align: "R", value: itemAmount},
+		}, invoice),
+		header:     tableHeaderStyle{style: "B", size: 10, height: 8, border: "1", fill: &accent},
+		rowHeight:  7,
+		lineHeight: 5,
+		border:     "1",
+		currency:   invoice.Currency,
+	}
 
-		pdf.CellFormat(90, 7, item.Description, "1", 0, "L", false, 0, "")
-		pdf.CellFormat(30, 7, fmt.Sprintf("%.2f", item.Quantity), "1", 0, "C", false, 0, "")
-		pdf.CellFormat(35, 7, fmt.Sprintf("%.2f", item.UnitPrice), "1", 0, "R", false, 0, "")
-		pdf.CellFormat(35, 7, fmt.Sprintf("%.2f", item.Amount), "1", 1, "R", false, 0, "")
+	table.draw(pdf, invoice.Items)
 
-	}
 	pdf.Ln(5)
 }
 
 // add totals function
 
-func (s *PDFService) addTotals(pdf *gofpdf.Fpdf, invoice *domain.Invoice) {
+func (t defaultTemplate) addTotals(pdf *pdfDoc, invoice *domain.Invoice) {
+
+	// the whole block goes on one page
+
+	keepTogether(pdf, float64(len(totalRows(invoice)))*6+8)
 
 	// positions for the totals
 
@@ -263,55 +494,55 @@ func (s *PDFService) addTotals(pdf *gofpdf.Fpdf, invoice *domain.Invoice) {
 
 	pdf.SetX(startX)
 	pdf.Cell(35, 6, "Subtotal:")
-	pdf.Cell(35, 6, fmt.Sprintf("%s %.2f", invoice.Currency, invoice.Subtotal))
+	pdf.Cell(35, 6, formatMoney(invoice.Currency, invoice.Subtotal))
 	pdf.Ln(6)
 
 	// tax part of the invoice
 
-	if invoice.TaxRate > 0 {
+	for _, row := range taxRows(invoice) {
 		pdf.SetX(startX)
-		pdf.Cell(35, 6, fmt.Sprintf("Tax (%.2f%%):", invoice.TaxRate))
-		pdf.Cell(35, 6, fmt.Sprintf("%s %.2f", invoice.Currency, invoice.TaxAmount))
+		pdf.Cell(35, 6, row.label+":")
+		pdf.Cell(35, 6, row.value)
 		pdf.Ln(6)
 	}
 
 	// discount is greater than zero
 
-	if invoice.DiscountAmount > 0 {
+	if invoice.DiscountAmount.Sign() > 0 {
 		pdf.SetX(startX)
 		pdf.Cell(35, 6, "Discount:")
-		pdf.Cell(35, 6, fmt.Sprintf("-%s %.2f", invoice.Currency, invoice.DiscountAmount))
+		pdf.Cell(35, 6, "-"+formatMoney(invoice.Currency, invoice.DiscountAmount))
 		pdf.Ln(6)
 	}
 
 	// final - total
 
-	pdf.SetFont("Arial", "B", 12)
+	pdf.SetFont(t.font, "B", 12)
 	pdf.SetX(startX)
 	pdf.Cell(35, 8, "Total:")
-	pdf.Cell(35, 8, fmt.Sprintf("%s %.2f", invoice.Currency, invoice.TotalAmount))
+	pdf.Cell(35, 8, formatMoney(invoice.Currency, invoice.TotalAmount))
 	pdf.Ln(12)
 
 }
 
 // add notes and terms of the invoice
 
-func (s *PDFService) addNotesAndTerms(pdf *gofpdf.Fpdf, invoice *domain.Invoice) {
+func (t defaultTemplate) addNotesAndTerms(pdf *pdfDoc, invoice *domain.Invoice) {
 
 	if invoice.Notes != nil && *invoice.Notes != "" {
-		pdf.SetFont("Arial", "B", 10)
+		pdf.SetFont(t.font, "B", 10)
 		pdf.Cell(0, 6, "Notes:")
 		pdf.Ln(5)
-		pdf.SetFont("Arial", "", 9)
+		pdf.SetFont(t.font, "", 9)
 		pdf.MultiCell(0, 5, *invoice.Notes, "", "", false)
 		pdf.Ln(5)
 	}
 
 	if invoice.TermsAndConditions != nil && *invoice.TermsAndConditions != "" {
-		pdf.SetFont("Arial", "B", 10)
+		pdf.SetFont(t.font, "B", 10)
 		pdf.Cell(0, 6, "Terms & Conditions:")
 		pdf.Ln(5)
-		pdf.SetFont("Arial", "", 9)
+		pdf.SetFont(t.font, "", 9)
 		pdf.MultiCell(0, 5, *invoice.TermsAndConditions, "", "", false)
 		pdf.Ln(5)
 	}
@@ -320,9 +551,14 @@ func (s *PDFService) addNotesAndTerms(pdf *gofpdf.Fpdf, invoice *domain.Invoice)
 
 // footer part of the invoice
 
-func (s *PDFService) addFooter(pdf *gofpdf.Fpdf) {
-	pdf.SetY(-20)
-	pdf.SetFont("Arial", "1", 8)
+func (t defaultTemplate) addFooter(pdf *pdfDoc) {
+	left, _, _, _ := pdf.GetMargins()
+
+	pdf.SetY(-15)
+	pdf.SetFont(t.font, "I", 8)
 	pdf.SetTextColor(128, 128, 128)
-	pdf.Cell(0, 10, fmt.Sprintf("Generated on %s", time.Now().Format("February 19 2026")))
+	pdf.Cell(0, 10, fmt.Sprintf("Generated on %s", time.Now().Format(pdfDateLayout)))
+	pdf.SetX(left)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
 }
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// every template's text against testdata/pdf/<template>.golden , go test -run TestInvoicePDFGolden -update rewrites them
+// the text is compared rather than the bytes , gofpdf stamps each file with the time it was made
+
+func TestInvoicePDFGolden(t *testing.T) {
+
+	s := newTestPDFService(t)
+
+	for template := range pdfTemplates {
+		t.Run(template, func(t *testing.T) {
+
+			invoice, user := testInvoice(template, 3)
+
+			data, err := s.GenerateInvoicePDF(invoice, user)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if n := pdfPageCount(data); n != 1 {
+				t.Errorf("%d pages , want 1", n)
+			}
+
+			got := pdfPlainText(t, data) + "\n"
+			golden := filepath.Join("testdata", "pdf", template+".golden")
+
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != string(want) {
+				t.Errorf("text differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// same invoice , same pdf text , renders don't depend on map order or state left by an earlier render
+
+func TestInvoicePDFDeterministic(t *testing.T) {
+
+	s := newTestPDFService(t)
+
+	for template := range pdfTemplates {
+
+		invoice, user := testInvoice(template, 3)
+
+		first, err := s.GenerateInvoicePDF(invoice, user)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		second, err := s.GenerateInvoicePDF(invoice, user)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if pdfPlainText(t, first) != pdfPlainText(t, second) {
+			t.Errorf("%s: two renders of the same invoice differ", template)
+		}
+	}
+}
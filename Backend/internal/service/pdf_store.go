@@ -0,0 +1,232 @@
+// stored pdfs - cached renders of invoices and archived copies of the pdfs emailed to clients
+
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/storage"
+	"github.com/google/uuid"
+)
+
+// bump when a layout changes , so pdfs cached with the old layout are rendered again
+
+const pdfLayoutVersion = 3
+
+// everything a rendered pdf depends on , hashed into its etag
+// tracking fields and timestamps that aren't printed are left out so they don't throw the cache away
+
+func pdfFingerprint(invoice *domain.Invoice, user *domain.User) string {
+
+	printed := *invoice
+	printed.PDFURL = nil
+	printed.PDFGeneratedAt = nil
+	printed.PDFKey = nil
+	printed.EmailSent = false
+	printed.EmailSentAt = nil
+	printed.EmailOpened = false
+	printed.EmailOpenedAt = nil
+	printed.RemindersPaused = false
+	printed.UpdatedAt = time.Time{}
+
+	inputs := struct {
+		Version      int
+		Invoice      *domain.Invoice
+		Name         string
+		Business     []string
+		LogoKey      *string
+		PrimaryColor *string
+		AccentColor  *string
+		Font         *string
+	}{
+		Version:      pdfLayoutVersion,
+		Invoice:      &printed,
+		Name:         user.DisplayName(),
+		Business:     businessLines(user),
+		LogoKey:      user.LogoKey,
+		PrimaryColor: user.BrandPrimaryColor,
+		AccentColor:  user.BrandAccentColor,
+		Font:         user.BrandFont,
+	}
+
+	data, _ := json.Marshal(inputs)
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// etag of the invoice's pdf as it would be rendered now , without rendering it
+
+func (s *PDFService) PDFETag(invoice *domain.Invoice, user *domain.User) string {
+	return pdfFingerprint(invoice, user)
+}
+
+// the invoice's pdf and its etag , from storage when nothing it shows has changed since it was stored
+// a fresh render is stored and recorded in pdf_key / pdf_url / pdf_generated_at
+// storage problems are logged and the rendered pdf is still returned
+
+func (s *PDFService) GetInvoicePDF(invoice *domain.Invoice, user *domain.User) ([]byte, string, error) {
+
+	etag := pdfFingerprint(invoice, user)
+	key := fmt.Sprintf("pdfs/%s/%s/%s.pdf", invoice.UserID, invoice.ID, etag)
+
+	if invoice.PDFKey != nil && *invoice.PDFKey == key {
+		data, err := s.storage.Get(key)
+
+		if err == nil {
+			return data, etag, nil
+		}
+
+		if err != storage.ErrNotFound {
+			log.Printf("pdf: reading cached %s: %v", key, err)
+		}
+	}
+
+	data, err := s.GenerateInvoicePDF(invoice, user)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.storage.Put(key, data, "application/pdf"); err != nil {
+		log.Printf("pdf: storing %s: %v", key, err)
+		return data, etag, nil
+	}
+
+	// updated_at is left alone , storing a render doesn't change the invoice
+
+	pdfURL := fmt.Sprintf("/api/v1/invoices/%s/download", invoice.ID)
+
+	if _, err := s.db.Exec(`UPDATE invoices SET pdf_key = $1 , pdf_url = $2 , pdf_generated_at = $3 WHERE id = $4`, key, pdfURL, time.Now(), invoice.ID); err != nil {
+		log.Printf("pdf: recording %s: %v", key, err)
+		return data, etag, nil
+	}
+
+	if invoice.PDFKey != nil && *invoice.PDFKey != "" && *invoice.PDFKey != key {
+		if err := s.storage.Delete(*invoice.PDFKey); err != nil {
+			log.Printf("pdf: deleting old %s: %v", *invoice.PDFKey, err)
+		}
+	}
+
+	return data, etag, nil
+}
+
+// ARCHIVES
+
+// storing the copy of a pdf that is about to be emailed , keyed by the email so it's never overwritten
+// the row is written by recordPDFArchive once the email went out
+
+func (s *PDFService) storeArchive(invoice *domain.Invoice, emailEventID uuid.UUID, data []byte) (*domain.InvoicePDFArchive, error) {
+
+	sum := sha256.Sum256(data)
+
+	archive := &domain.InvoicePDFArchive{
+		ID:           uuid.New(),
+		InvoiceID:    invoice.ID,
+		EmailEventID: &emailEventID,
+		StorageKey:   fmt.Sprintf("archive/%s/%s/%s.pdf", invoice.UserID, invoice.ID, emailEventID),
+		Checksum:     hex.EncodeToString(sum[:]),
+		SizeBytes:    len(data),
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.storage.Put(archive.StorageKey, data, "application/pdf"); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// writing the archive row , inside the transaction that records the email
+
+func recordPDFArchive(tx *sql.Tx, archive *domain.InvoicePDFArchive) error {
+
+	query := `
+		      INSERT INTO invoice_pdf_archives (id , invoice_id , email_event_id , storage_key , checksum , size_bytes , created_at)
+					VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7)
+		    `
+
+	_, err := tx.Exec(query, archive.ID, archive.InvoiceID, archive.EmailEventID, archive.StorageKey, archive.Checksum, archive.SizeBytes, archive.CreatedAt)
+
+	return err
+}
+
+const pdfArchiveColumns = `id , invoice_id , email_event_id , storage_key , checksum , size_bytes , created_at`
+
+func scanPDFArchive(row rowScanner, archive *domain.InvoicePDFArchive) error {
+	return row.Scan(&archive.ID, &archive.InvoiceID, &archive.EmailEventID, &archive.StorageKey, &archive.Checksum, &archive.SizeBytes, &archive.CreatedAt)
+}
+
+// archived pdfs of an invoice , newest first
+
+func (s *PDFService) ListArchives(userID, invoiceID uuid.UUID) ([]*domain.InvoicePDFArchive, error) {
+
+	query := `
+		     SELECT ` + pdfArchiveColumns + ` FROM invoice_pdf_archives
+				 WHERE invoice_id = $1 AND EXISTS (SELECT 1 FROM invoices WHERE id = $1 AND user_id = $2)
+				 ORDER BY created_at DESC
+		   `
+
+	rows, err := s.db.Query(query, invoiceID, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	archives := []*domain.InvoicePDFArchive{}
+
+	for rows.Next() {
+		archive := &domain.InvoicePDFArchive{}
+
+		if err := scanPDFArchive(rows, archive); err != nil {
+			return nil, err
+		}
+
+		archives = append(archives, archive)
+	}
+
+	return archives, rows.Err()
+}
+
+// one archived pdf with its bytes
+
+func (s *PDFService) GetArchive(userID, invoiceID, archiveID uuid.UUID) (*domain.InvoicePDFArchive, []byte, error) {
+
+	query := `
+		     SELECT ` + pdfArchiveColumns + ` FROM invoice_pdf_archives
+				 WHERE id = $1 AND invoice_id = $2 AND EXISTS (SELECT 1 FROM invoices WHERE id = $2 AND user_id = $3)
+		   `
+
+	archive := &domain.InvoicePDFArchive{}
+
+	err := scanPDFArchive(s.db.QueryRow(query, archiveID, invoiceID, userID), archive)
+
+	if err == sql.ErrNoRows {
+		return nil, nil, domain.ErrPDFArchiveNotFound
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := s.storage.Get(archive.StorageKey)
+
+	if err == storage.ErrNotFound {
+		return nil, nil, domain.ErrPDFArchiveNotFound
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return archive, data, nil
+}
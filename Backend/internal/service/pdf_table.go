@@ -0,0 +1,322 @@
+// pdf table - the items table all layouts share
+// descriptions wrap over several lines , and a table that doesn't fit breaks onto new pages with the header repeated
+// and the running subtotal carried forward
+
+package service
+
+import (
+	"strings"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+)
+
+// one column , value gives the cell text for an item (index counts from 0) in the invoice's currency
+// wrap marks the column long text wraps in , the other columns stay on one line
+
+type tableColumn struct {
+	header string
+	width  float64
+	align  string
+	wrap   bool
+	value  func(index int, item *domain.InvoiceItem, currency string) string
+}
+
+// look of the header row
+
+type tableHeaderStyle struct {
+	style  string
+	size   float64
+	height float64
+	border string
+	fill   *rgb
+	text   rgb
+}
+
+// a template's items table
+// rowHeight is the height of a one line row , every extra wrapped line adds lineHeight
+// border is the CellFormat border of body cells and stripe (when set) fills every other row
+
+type itemsTable struct {
+	font       string
+	size       float64
+	columns    []tableColumn
+	header     tableHeaderStyle
+	rowHeight  float64
+	lineHeight float64
+	border     string
+	stripe     *rgb
+	currency   string
+}
+
+// the usual description / quantity / unit price / amount values
+
+func itemDescription(_ int, item *domain.InvoiceItem, _ string) string { return item.Description }
+func itemQuantity(_ int, item *domain.InvoiceItem, _ string) string {
+	return item.Quantity.StringFixed(max(2, item.Quantity.Places()))
+}
+func itemUnitPrice(_ int, item *domain.InvoiceItem, currency string) string {
+	return item.UnitPrice.StringFixed(max(money.MinorUnits(currency), item.UnitPrice.Places()))
+}
+func itemAmount(_ int, item *domain.InvoiceItem, currency string) string {
+	return formatAmount(currency, item.Amount)
+}
+
+// hsn / sac code and tax rate of a line
+
+func itemHSNSAC(_ int, item *domain.InvoiceItem, _ string) string {
+	if item.HSNSAC == nil {
+		return ""
+	}
+	return *item.HSNSAC
+}
+func itemTaxRate(_ int, item *domain.InvoiceItem, _ string) string {
+	return item.TaxRate.String() + "%"
+}
+
+// line discount , "10%" or the amount taken off
+
+func itemDiscount(_ int, item *domain.InvoiceItem, currency string) string {
+	if item.DiscountAmount.IsZero() {
+		return ""
+	}
+	if item.DiscountType != nil && *item.DiscountType == domain.DiscountPercentage {
+		return item.DiscountValue.String() + "%"
+	}
+	return formatAmount(currency, item.DiscountAmount)
+}
+
+// lowest y content can reach before gofpdf would break the page
+
+func pageBottom(pdf *pdfDoc) float64 {
+
+	_, height := pdf.GetPageSize()
+	_, margin := pdf.GetAutoPageBreak()
+
+	return height - margin
+}
+
+// starting a new page when a block of the given height doesn't fit on this one
+
+func keepTogether(pdf *pdfDoc, height float64) {
+
+	if pdf.GetY()+height > pageBottom(pdf) {
+		pdf.AddPage()
+	}
+}
+
+// splitting text into lines that fit the width , on spaces where possible
+// words wider than the whole width (or text without spaces , like cjk) are cut where they overflow
+
+func wrapText(pdf *pdfDoc, text string, width float64) []string {
+
+	width -= 2 * pdf.GetCellMargin()
+
+	lines := []string{}
+
+	for _, paragraph := range strings.Split(text, "\n") {
+
+		line := ""
+
+		for _, word := range strings.Fields(paragraph) {
+
+			candidate := word
+
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if pdf.GetStringWidth(candidate) <= width {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			for len([]rune(word)) > 1 && pdf.GetStringWidth(word) > width {
+				runes := []rune(word)
+				n := len(runes) - 1
+
+				for n > 1 && pdf.GetStringWidth(string(runes[:n])) > width {
+					n--
+				}
+
+				lines = append(lines, string(runes[:n]))
+				word = string(runes[n:])
+			}
+
+			line = word
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// drawing the header row at the left margin
+
+func (t *itemsTable) drawHeader(pdf *pdfDoc) {
+
+	h := t.header
+	left, _, _, _ := pdf.GetMargins()
+
+	pdf.SetX(left)
+	pdf.SetFont(t.font, h.style, h.size)
+	pdf.SetTextColor(h.text.r, h.text.g, h.text.b)
+
+	if h.fill != nil {
+		pdf.SetFillColor(h.fill.r, h.fill.g, h.fill.b)
+	}
+
+	for _, col := range t.columns {
+		pdf.CellFormat(col.width, h.height, col.header, h.border, 0, col.align, h.fill != nil, 0, "")
+	}
+
+	pdf.Ln(-1)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(t.font, "", t.size)
+}
+
+// "carried forward" / "brought forward" row with the running subtotal
+
+func (t *itemsTable) drawCarryRow(pdf *pdfDoc, label string, subtotal money.Decimal) {
+
+	left, _, _, _ := pdf.GetMargins()
+	labelWidth := 0.0
+
+	for _, col := range t.columns[:len(t.columns)-1] {
+		labelWidth += col.width
+	}
+
+	pdf.SetX(left)
+	pdf.SetFont(t.font, "I", t.size)
+	pdf.CellFormat(labelWidth, t.rowHeight, label, t.border, 0, "R", false, 0, "")
+	pdf.CellFormat(t.columns[len(t.columns)-1].width, t.rowHeight, formatMoney(t.currency, subtotal), t.border, 1, "R", false, 0, "")
+	pdf.SetFont(t.font, "", t.size)
+}
+
+// cell lines of every item and the row heights they need
+// wrapped cells are cut short (ending in "...") rather than making a row taller than a page
+
+func (t *itemsTable) layoutRows(pdf *pdfDoc, items []*domain.InvoiceItem) ([][][]string, []float64) {
+
+	_, top, _, _ := pdf.GetMargins()
+
+	maxHeight := pageBottom(pdf) - top - t.header.height - 2*t.rowHeight
+	maxLines := 1 + int((maxHeight-t.rowHeight)/t.lineHeight)
+
+	pdf.SetFont(t.font, "", t.size)
+
+	texts := make([][][]string, len(items))
+	heights := make([]float64, len(items))
+
+	for i, item := range items {
+
+		texts[i] = make([][]string, len(t.columns))
+		lines := 1
+
+		for c, col := range t.columns {
+			text := col.value(i, item, t.currency)
+
+			if !col.wrap {
+				texts[i][c] = []string{text}
+				continue
+			}
+
+			wrapped := wrapText(pdf, text, col.width)
+
+			if len(wrapped) > maxLines {
+				wrapped = append(wrapped[:maxLines-1], wrapped[maxLines-1]+"...")
+			}
+
+			texts[i][c] = wrapped
+
+			if len(wrapped) > lines {
+				lines = len(wrapped)
+			}
+		}
+
+		heights[i] = t.rowHeight + float64(lines-1)*t.lineHeight
+	}
+
+	return texts, heights
+}
+
+// drawing the whole table from the current position
+
+func (t *itemsTable) draw(pdf *pdfDoc, items []*domain.InvoiceItem) {
+
+	left, _, _, _ := pdf.GetMargins()
+
+	texts, heights := t.layoutRows(pdf, items)
+
+	// the header never sits alone at the bottom of a page
+
+	first := t.rowHeight
+
+	if len(heights) > 0 {
+		first = heights[0]
+	}
+
+	keepTogether(pdf, t.header.height+first)
+	t.drawHeader(pdf)
+
+	subtotal := money.Zero
+
+	for i, item := range items {
+
+		// room is kept under every row for the carried forward line , the last row only needs its own height
+
+		reserve := 0.0
+
+		if i < len(items)-1 {
+			reserve = t.rowHeight
+		}
+
+		if i > 0 && pdf.GetY()+heights[i]+reserve > pageBottom(pdf) {
+			t.drawCarryRow(pdf, "Carried forward", subtotal)
+			pdf.AddPage()
+			t.drawHeader(pdf)
+			t.drawCarryRow(pdf, "Brought forward", subtotal)
+		}
+
+		// cell boxes first at the full row height , then the text on top
+
+		fill := t.stripe != nil && i%2 == 1
+		y := pdf.GetY()
+
+		if fill {
+			pdf.SetFillColor(t.stripe.r, t.stripe.g, t.stripe.b)
+		}
+
+		pdf.SetX(left)
+
+		for _, col := range t.columns {
+			pdf.CellFormat(col.width, heights[i], "", t.border, 0, "", fill, 0, "")
+		}
+
+		x := left
+		pad := (t.rowHeight - t.lineHeight) / 2
+
+		for c, col := range t.columns {
+			if col.wrap {
+				for l, line := range texts[i][c] {
+					pdf.SetXY(x, y+pad+float64(l)*t.lineHeight)
+					pdf.CellFormat(col.width, t.lineHeight, line, "", 0, col.align, false, 0, "")
+				}
+			} else {
+				pdf.SetXY(x, y)
+				pdf.CellFormat(col.width, t.rowHeight, texts[i][c][0], "", 0, col.align, false, 0, "")
+			}
+
+			x += col.width
+		}
+
+		pdf.SetXY(left, y+heights[i])
+
+		subtotal = subtotal.Add(item.Amount)
+	}
+}
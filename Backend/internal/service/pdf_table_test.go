@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// hundreds of lines , some wrapping over several lines , break across pages in every template
+// every line is printed once and in order , no row runs into the page footer and every page is numbered
+
+func TestInvoicePDFManyItems(t *testing.T) {
+
+	s := newTestPDFService(t)
+
+	for template := range pdfTemplates {
+		t.Run(template, func(t *testing.T) {
+
+			invoice, user := testInvoice(template, 300)
+
+			for i, item := range invoice.Items {
+				item.Description = fmt.Sprintf("item-%03d", i+1)
+
+				if i%25 == 0 {
+					item.Description += strings.Repeat(" with a description long enough to wrap", 4)
+				}
+			}
+
+			data, err := s.GenerateInvoicePDF(invoice, user)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pages := pdfPageCount(data)
+
+			if pages < 2 {
+				t.Fatalf("%d pages for 300 lines", pages)
+			}
+
+			texts := pdfTexts(t, data)
+
+			// the lowest footer line of each page , rows have to stay above it
+
+			footers := map[int]float64{}
+			numbered := map[string]bool{}
+
+			for _, text := range texts {
+				if strings.HasPrefix(text.text, "Page ") && strings.Contains(text.text, " of ") {
+					numbered[text.text] = true
+
+					if y, ok := footers[text.page]; !ok || text.y > y {
+						footers[text.page] = text.y
+					}
+				}
+			}
+
+			for page := 1; page <= pages; page++ {
+				if want := fmt.Sprintf("Page %d of %d", page, pages); !numbered[want] {
+					t.Errorf("no %q footer", want)
+				}
+			}
+
+			next, carried, brought := 1, 0, 0
+
+			for _, text := range texts {
+
+				switch text.text {
+				case "Carried forward":
+					carried++
+				case "Brought forward":
+					brought++
+				}
+
+				if !strings.HasPrefix(text.text, "item-") {
+					continue
+				}
+
+				n, _ := strconv.Atoi(strings.Fields(text.text)[0][len("item-"):])
+
+				if n != next {
+					t.Fatalf("line %d printed where line %d should be", n, next)
+				}
+
+				next++
+
+				if footer, ok := footers[text.page]; ok && text.y <= footer {
+					t.Errorf("line %d on page %d is drawn at the footer (y %.2f , footer %.2f)", n, text.page, text.y, footer)
+				}
+			}
+
+			if next != 301 {
+				t.Errorf("%d of 300 lines printed", next-1)
+			}
+
+			if carried == 0 || carried != brought {
+				t.Errorf("%d carried forward and %d brought forward rows", carried, brought)
+			}
+		})
+	}
+}
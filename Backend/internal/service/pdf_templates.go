@@ -0,0 +1,853 @@
+// pdf templates - the modern , minimal and professional layouts and the helpers they share
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+)
+
+// A4 width and the page margin used by the layouts
+
+const (
+	pdfPageWidth = 210.0
+	pdfMargin    = 15.0
+)
+
+// HELPERS
+
+// business contact lines under the business name
+
+func businessLines(user *domain.User) []string {
+
+	lines := []string{}
+
+	if user.BusinessAddress != nil && *user.BusinessAddress != "" {
+		lines = append(lines, *user.BusinessAddress)
+	}
+
+	if user.BusinessEmail != nil && *user.BusinessEmail != "" {
+		lines = append(lines, *user.BusinessEmail)
+	}
+
+	if user.BusinessPhone != nil && *user.BusinessPhone != "" {
+		lines = append(lines, *user.BusinessPhone)
+	}
+
+	if user.TaxID != nil && *user.TaxID != "" {
+		lines = append(lines, "Tax ID: "+*user.TaxID)
+	}
+
+	return lines
+}
+
+// client address block , name first
+
+func clientLines(client *domain.Client) []string {
+
+	if client == nil {
+		return nil
+	}
+
+	lines := []string{client.Name}
+
+	for _, part := range []*string{client.CompanyName, client.AddressLine1, client.AddressLine2} {
+		if part != nil && *part != "" {
+			lines = append(lines, *part)
+		}
+	}
+
+	cityLine := []string{}
+
+	for _, part := range []*string{client.City, client.State, client.PostalCode} {
+		if part != nil && *part != "" {
+			cityLine = append(cityLine, *part)
+		}
+	}
+
+	if len(cityLine) > 0 {
+		lines = append(lines, strings.Join(cityLine, ", "))
+	}
+
+	if client.Country != nil && *client.Country != "" {
+		lines = append(lines, *client.Country)
+	}
+
+	if client.Email != nil && *client.Email != "" {
+		lines = append(lines, *client.Email)
+	}
+
+	return lines
+}
+
+// symbols printed instead of the currency code , other currencies keep the code
+
+var currencySymbols = map[string]string{
+	"INR": "₹",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"KRW": "₩",
+	"RUB": "₽",
+	"TRY": "₺",
+	"PHP": "₱",
+	"NGN": "₦",
+	"ILS": "₪",
+	"VND": "₫",
+	"THB": "฿",
+}
+
+// amounts are printed with the currency's own decimal places , 1500 JPY / 12.50 USD / 3.250 KWD
+
+func formatMoney(currency string, amount money.Decimal) string {
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol + formatAmount(currency, amount)
+	}
+
+	return currency + " " + formatAmount(currency, amount)
+}
+
+// amount without the currency , for table cells
+
+func formatAmount(currency string, amount money.Decimal) string {
+	return amount.StringFixed(money.MinorUnits(currency))
+}
+
+// words the layouts print for the kind of document , invoices and estimates share the templates
+// title is the big heading , heading the title case one of the minimal layout and noun goes before "No."
+
+type pdfLabels struct {
+	title    string
+	heading  string
+	noun     string
+	due      string
+	dueShort string
+}
+
+// "TAX INVOICE" for gst invoices , as the gst rules ask
+
+func invoiceLabels(invoice *domain.Invoice) pdfLabels {
+
+	labels := pdfLabels{title: "INVOICE", heading: "Invoice", noun: "Invoice", due: "Due Date", dueShort: "Due"}
+
+	if invoice.TaxScheme == domain.TaxSchemeGST {
+		labels.title, labels.heading = "TAX INVOICE", "Tax Invoice"
+	}
+
+	return labels
+}
+
+var estimateLabels = pdfLabels{title: "ESTIMATE", heading: "Estimate", noun: "Estimate", due: "Valid Until", dueShort: "Valid until"}
+
+// seller gstin and reverse charge , added to the invoice details of gst invoices
+
+func gstDetails(invoice *domain.Invoice) [][2]string {
+
+	if invoice.TaxScheme != domain.TaxSchemeGST {
+		return nil
+	}
+
+	details := [][2]string{}
+
+	if invoice.SellerGSTIN != nil {
+		details = append(details, [2]string{"GSTIN", *invoice.SellerGSTIN})
+	}
+
+	return append(details, [2]string{"Reverse Charge", "No"})
+}
+
+// buyer gstin and place of supply , printed under the client's address on gst invoices
+
+func gstBuyerLines(invoice *domain.Invoice) []string {
+
+	if invoice.TaxScheme != domain.TaxSchemeGST {
+		return nil
+	}
+
+	buyer := "Unregistered"
+
+	if invoice.BuyerGSTIN != nil {
+		buyer = *invoice.BuyerGSTIN
+	}
+
+	lines := []string{"GSTIN: " + buyer}
+
+	if invoice.PlaceOfSupply != nil {
+		lines = append(lines, "Place of Supply: "+gstStateLabel(*invoice.PlaceOfSupply))
+	}
+
+	return lines
+}
+
+// client address block with the gst lines of gst invoices
+
+func billToLines(invoice *domain.Invoice) []string {
+	return append(clientLines(invoice.Client), gstBuyerLines(invoice)...)
+}
+
+// extra item columns after the description , which gives up the room for them
+// gst invoices get hsn / sac and gst rate , other invoices a tax rate column when the lines mix rates
+// and any invoice with a discounted line a discount column
+
+func withLineColumns(columns []tableColumn, invoice *domain.Invoice) []tableColumn {
+
+	extra := []tableColumn{}
+
+	if invoice.TaxScheme == domain.TaxSchemeGST {
+		extra = append(extra,
+			tableColumn{header: "HSN/SAC", width: 18, align: "C", value: itemHSNSAC},
+			tableColumn{header: "GST %", width: 14, align: "R", value: itemTaxRate},
+		)
+	} else if len(invoice.TaxSummary) > 1 {
+		extra = append(extra, tableColumn{header: "Tax %", width: 14, align: "R", value: itemTaxRate})
+	}
+
+	for _, item := range invoice.Items {
+		if item.DiscountAmount.Sign() > 0 {
+			extra = append(extra, tableColumn{header: "Discount", width: 20, align: "R", value: itemDiscount})
+			break
+		}
+	}
+
+	if len(extra) == 0 {
+		return columns
+	}
+
+	for i, col := range columns {
+		if !col.wrap {
+			continue
+		}
+
+		// upper case headers (the minimal layout) stay upper case
+
+		upper := strings.ToUpper(col.header) == col.header
+
+		for _, e := range extra {
+			col.width -= e.width
+		}
+
+		out := append([]tableColumn{}, columns[:i]...)
+		out = append(out, col)
+
+		for _, e := range extra {
+			if upper {
+				e.header = strings.ToUpper(e.header)
+			}
+
+			out = append(out, e)
+		}
+
+		return append(out, columns[i+1:]...)
+	}
+
+	return columns
+}
+
+// label and value of one totals row
+
+type totalRow struct {
+	label string
+	value string
+	grand bool
+}
+
+// totals block , paid / credited and balance due only show up once something was paid or credited
+
+func totalRows(invoice *domain.Invoice) []totalRow {
+
+	rows := []totalRow{{label: "Subtotal", value: formatMoney(invoice.Currency, invoice.Subtotal)}}
+
+	rows = append(rows, taxRows(invoice)...)
+
+	if invoice.DiscountAmount.Sign() > 0 {
+		rows = append(rows, totalRow{label: "Discount", value: "-" + formatMoney(invoice.Currency, invoice.DiscountAmount)})
+	}
+
+	rows = append(rows, totalRow{label: "Total", value: formatMoney(invoice.Currency, invoice.TotalAmount), grand: true})
+
+	if invoice.AmountPaid.Sign() > 0 {
+		rows = append(rows, totalRow{label: "Paid", value: formatMoney(invoice.Currency, invoice.AmountPaid)})
+	}
+
+	if invoice.AmountCredited.Sign() > 0 {
+		rows = append(rows, totalRow{label: "Credited", value: "-" + formatMoney(invoice.Currency, invoice.AmountCredited)})
+	}
+
+	if invoice.AmountPaid.Sign() > 0 || invoice.AmountCredited.Sign() > 0 {
+		rows = append(rows, totalRow{label: "Balance Due", value: formatMoney(invoice.Currency, invoice.BalanceDue), grand: true})
+	}
+
+	return rows
+}
+
+// tax lines of the totals , cgst + sgst or igst on gst invoices and one line per rate otherwise
+
+func taxRows(invoice *domain.Invoice) []totalRow {
+
+	if invoice.TaxScheme != domain.TaxSchemeGST {
+
+		rows := []totalRow{}
+
+		for _, group := range invoice.TaxSummary {
+			if !group.TaxAmount.IsZero() {
+				rows = append(rows, totalRow{label: fmt.Sprintf("Tax (%s%%)", group.Rate), value: formatMoney(invoice.Currency, group.TaxAmount)})
+			}
+		}
+
+		if len(rows) == 0 && !invoice.TaxAmount.IsZero() {
+			rows = append(rows, totalRow{label: "Tax", value: formatMoney(invoice.Currency, invoice.TaxAmount)})
+		}
+
+		return rows
+	}
+
+	if gstIntraState(invoice) {
+		return []totalRow{
+			{label: "CGST", value: formatMoney(invoice.Currency, invoice.CGSTAmount)},
+			{label: "SGST", value: formatMoney(invoice.Currency, invoice.SGSTAmount)},
+		}
+	}
+
+	return []totalRow{{label: "IGST", value: formatMoney(invoice.Currency, invoice.IGSTAmount)}}
+}
+
+// gst summary by rate under the totals , cgst and sgst columns for intra state supplies and igst otherwise
+// border and fill follow the template's items table , the block is never split over two pages
+
+func drawGSTSummary(pdf *pdfDoc, invoice *domain.Invoice, font string, width float64, border string, fill *rgb) {
+
+	if invoice.TaxScheme != domain.TaxSchemeGST || len(invoice.TaxSummary) == 0 {
+		return
+	}
+
+	intraState := gstIntraState(invoice)
+
+	headers := []string{"GST Rate", "Taxable Value", "IGST", "Total Tax"}
+
+	if intraState {
+		headers = []string{"GST Rate", "Taxable Value", "CGST", "SGST", "Total Tax"}
+	}
+
+	amount := func(value money.Decimal) string { return formatMoney(invoice.Currency, value) }
+
+	rows := [][]string{}
+	total := &domain.TaxRateSummary{}
+
+	for _, group := range invoice.TaxSummary {
+		row := []string{group.Rate.String() + "%", amount(group.TaxableAmount)}
+
+		if intraState {
+			row = append(row, amount(group.CGSTAmount), amount(group.SGSTAmount))
+		} else {
+			row = append(row, amount(group.IGSTAmount))
+		}
+
+		rows = append(rows, append(row, amount(group.TaxAmount)))
+
+		total.TaxableAmount = total.TaxableAmount.Add(group.TaxableAmount)
+		total.CGSTAmount = total.CGSTAmount.Add(group.CGSTAmount)
+		total.SGSTAmount = total.SGSTAmount.Add(group.SGSTAmount)
+		total.IGSTAmount = total.IGSTAmount.Add(group.IGSTAmount)
+		total.TaxAmount = total.TaxAmount.Add(group.TaxAmount)
+	}
+
+	totalRow := []string{"Total", amount(total.TaxableAmount)}
+
+	if intraState {
+		totalRow = append(totalRow, amount(total.CGSTAmount), amount(total.SGSTAmount))
+	} else {
+		totalRow = append(totalRow, amount(total.IGSTAmount))
+	}
+
+	totalRow = append(totalRow, amount(total.TaxAmount))
+
+	left, _, _, _ := pdf.GetMargins()
+	colWidth := width / float64(len(headers))
+
+	keepTogether(pdf, 6+float64(len(rows)+2)*6)
+
+	pdf.SetX(left)
+	pdf.SetFont(font, "B", 10)
+	pdf.CellFormat(width, 6, "GST Summary", "", 1, "L", false, 0, "")
+
+	if fill != nil {
+		pdf.SetFillColor(fill.r, fill.g, fill.b)
+	}
+
+	pdf.SetFont(font, "B", 9)
+	pdf.SetX(left)
+
+	for i, header := range headers {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(colWidth, 6, header, border, 0, align, fill != nil, 0, "")
+	}
+
+	pdf.Ln(-1)
+
+	for r, row := range append(rows, totalRow) {
+		style := ""
+		if r == len(rows) {
+			style = "B"
+		}
+
+		pdf.SetFont(font, style, 9)
+		pdf.SetX(left)
+
+		for i, cell := range row {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(colWidth, 6, cell, border, 0, align, false, 0, "")
+		}
+
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(6)
+}
+
+// notes and terms as titled paragraphs
+
+func writeNotesAndTerms(pdf *pdfDoc, invoice *domain.Invoice, font string) {
+
+	sections := []struct {
+		title string
+		text  *string
+	}{
+		{"Notes", invoice.Notes},
+		{"Terms & Conditions", invoice.TermsAndConditions},
+	}
+
+	for _, section := range sections {
+
+		if section.text == nil || *section.text == "" {
+			continue
+		}
+
+		pdf.SetFont(font, "B", 10)
+		pdf.CellFormat(0, 6, section.title, "", 1, "L", false, 0, "")
+		pdf.SetFont(font, "", 9)
+		pdf.MultiCell(0, 5, *section.text, "", "L", false)
+		pdf.Ln(4)
+	}
+}
+
+// page number footer , "Page 1 of 2"
+
+func pageNumberFooter(pdf *pdfDoc, font, left string) func() {
+
+	return func() {
+		pdf.SetY(-15)
+		pdf.SetFont(font, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 10, left, "", 0, "L", false, 0, "")
+		pdf.SetX(pdfMargin)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+// MODERN TEMPLATE - coloured header band , two column details , striped items
+
+type modernTemplate struct{}
+
+func (t modernTemplate) render(pdf *pdfDoc, invoice *domain.Invoice, user *domain.User, brand *pdfBrand, labels pdfLabels) {
+
+	font := brand.useFont(pdf, fontSans)
+	primary := brand.primaryOr(rgb{0, 102, 204})
+	accent := brand.accentOr(rgb{235, 243, 255})
+	contentWidth := pdfPageWidth - 2*pdfMargin
+
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetFooterFunc(pageNumberFooter(pdf, font, "Thank you for your business"))
+	pdf.AddPage()
+
+	// header band with the logo , the business name and the title
+
+	pdf.SetFillColor(primary.r, primary.g, primary.b)
+	pdf.Rect(0, 0, pdfPageWidth, 42, "F")
+
+	textX := pdfMargin
+
+	if logoWidth := brand.drawLogo(pdf, pdfMargin, 9, 24); logoWidth > 0 {
+		textX += logoWidth + 5
+	}
+
+	textWidth := contentWidth - (textX - pdfMargin)
+
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(textX, 12)
+	pdf.SetFont(font, "B", 20)
+	pdf.CellFormat(textWidth-60, 10, user.DisplayName(), "", 0, "L", false, 0, "")
+	pdf.SetFont(font, "B", 26)
+
+	if invoice.TaxScheme == domain.TaxSchemeGST {
+		pdf.SetFont(font, "B", 22)
+	}
+
+	pdf.CellFormat(60, 10, labels.title, "", 1, "R", false, 0, "")
+
+	pdf.SetFont(font, "", 9)
+	pdf.SetX(textX)
+	pdf.CellFormat(textWidth, 5, strings.Join(businessLines(user), "  |  "), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	// bill to on the left , invoice details on the right
+
+	top := 52.0
+
+	pdf.SetXY(pdfMargin, top)
+	pdf.SetFont(font, "B", 9)
+	pdf.SetTextColor(primary.r, primary.g, primary.b)
+	pdf.CellFormat(90, 5, "BILL TO", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	for i, line := range billToLines(invoice) {
+		if i == 0 {
+			pdf.SetFont(font, "B", 11)
+		} else {
+			pdf.SetFont(font, "", 10)
+		}
+
+		pdf.SetX(pdfMargin)
+		pdf.CellFormat(90, 5, line, "", 1, "L", false, 0, "")
+	}
+
+	leftBottom := pdf.GetY()
+
+	details := [][2]string{
+		{labels.noun + " No.", invoice.InvoiceNumber},
+		{"Issue Date", invoice.IssueDate.Format(pdfDateLayout)},
+		{labels.due, invoice.DueDate.Format(pdfDateLayout)},
+		{"Status", strings.ToUpper(invoice.Status)},
+	}
+
+	details = append(details, gstDetails(invoice)...)
+
+	pdf.SetY(top)
+
+	for _, d := range details {
+		pdf.SetX(120)
+		pdf.SetFont(font, "B", 9)
+		pdf.SetTextColor(primary.r, primary.g, primary.b)
+		pdf.CellFormat(30, 6, d[0], "", 0, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont(font, "", 10)
+		pdf.CellFormat(45, 6, d[1], "", 1, "R", false, 0, "")
+	}
+
+	if pdf.GetY() < leftBottom {
+		pdf.SetY(leftBottom)
+	}
+
+	pdf.Ln(10)
+
+	// items , primary header and accent striped rows without borders
+
+	table := &itemsTable{
+		font: font,
+		size: 10,
+		columns: withLineColumns([]tableColumn{
+			{header: "Description", width: 90, align: "L", wrap: true, value: itemDescription},
+			{header: "Qty", width: 25, align: "R", value: itemQuantity},
+			{header: "Unit Price", width: 32.5, align: "R", value: itemUnitPrice},
+			{header: "Amount", width: 32.5, align: "R", value: itemAmount},
+		}, invoice),
+		header:     tableHeaderStyle{style: "B", size: 10, height: 9, fill: &primary, text: rgb{255, 255, 255}},
+		rowHeight:  8,
+		lineHeight: 5,
+		stripe:     &accent,
+		currency:   invoice.Currency,
+	}
+
+	table.draw(pdf, invoice.Items)
+
+	pdf.Ln(6)
+
+	// totals , grand rows on the primary colour
+
+	rows := totalRows(invoice)
+	keepTogether(pdf, float64(len(rows))*8)
+
+	for _, row := range rows {
+		pdf.SetX(110)
+
+		if row.grand {
+			pdf.SetFillColor(primary.r, primary.g, primary.b)
+			pdf.SetTextColor(255, 255, 255)
+			pdf.SetFont(font, "B", 11)
+		} else {
+			pdf.SetFont(font, "", 10)
+		}
+
+		pdf.CellFormat(40, 8, row.label, "", 0, "L", row.grand, 0, "")
+		pdf.CellFormat(45, 8, row.value, "", 1, "R", row.grand, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	pdf.Ln(10)
+
+	drawGSTSummary(pdf, invoice, font, contentWidth, "", &accent)
+	writeNotesAndTerms(pdf, invoice, font)
+}
+
+// MINIMAL TEMPLATE - black and grey only , thin rules , no boxes
+
+type minimalTemplate struct{}
+
+func (t minimalTemplate) render(pdf *pdfDoc, invoice *domain.Invoice, user *domain.User, brand *pdfBrand, labels pdfLabels) {
+
+	font := brand.useFont(pdf, fontSans)
+	primary := brand.primaryOr(rgb{0, 0, 0})
+	accent := brand.accentOr(rgb{200, 200, 200})
+	contentWidth := pdfPageWidth - 2*pdfMargin
+
+	pdf.SetMargins(pdfMargin+5, pdfMargin+5, pdfMargin+5)
+	pdf.SetFooterFunc(pageNumberFooter(pdf, font, invoice.InvoiceNumber))
+	pdf.AddPage()
+
+	contentWidth -= 10
+
+	pdf.SetDrawColor(accent.r, accent.g, accent.b)
+	pdf.SetLineWidth(0.2)
+
+	// small logo above everything else
+
+	if brand.drawLogo(pdf, pdfMargin+5, pdfMargin+5, 12) > 0 {
+		pdf.SetY(pdfMargin + 5 + 16)
+	}
+
+	// business name and invoice number on one line
+
+	pdf.SetFont(font, "B", 14)
+	pdf.SetTextColor(primary.r, primary.g, primary.b)
+	pdf.CellFormat(contentWidth/2, 8, user.DisplayName(), "", 0, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(font, "", 14)
+	pdf.CellFormat(contentWidth/2, 8, labels.heading+" "+invoice.InvoiceNumber, "", 1, "R", false, 0, "")
+
+	pdf.SetFont(font, "", 9)
+	pdf.SetTextColor(110, 110, 110)
+
+	for _, line := range businessLines(user) {
+		pdf.CellFormat(contentWidth, 4.5, line, "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(10)
+
+	// dates and client in plain text
+
+	pdf.CellFormat(contentWidth, 5, fmt.Sprintf("Issued %s   %s %s", invoice.IssueDate.Format(pdfDateLayout), labels.dueShort, invoice.DueDate.Format(pdfDateLayout)), "", 1, "L", false, 0, "")
+
+	for _, d := range gstDetails(invoice) {
+		pdf.CellFormat(contentWidth, 5, d[0]+"  "+d[1], "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(4)
+
+	pdf.CellFormat(contentWidth, 5, "Billed to", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(font, "", 10)
+
+	for _, line := range billToLines(invoice) {
+		pdf.CellFormat(contentWidth, 5, line, "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(10)
+
+	// items with a rule under each row
+
+	table := &itemsTable{
+		font: font,
+		size: 10,
+		columns: withLineColumns([]tableColumn{
+			{header: "DESCRIPTION", width: contentWidth - 75, align: "L", wrap: true, value: itemDescription},
+			{header: "QTY", width: 20, align: "R", value: itemQuantity},
+			{header: "PRICE", width: 27.5, align: "R", value: itemUnitPrice},
+			{header: "AMOUNT", width: 27.5, align: "R", value: itemAmount},
+		}, invoice),
+		header:     tableHeaderStyle{size: 8, height: 7, border: "B", text: rgb{110, 110, 110}},
+		rowHeight:  8,
+		lineHeight: 5,
+		border:     "B",
+		currency:   invoice.Currency,
+	}
+
+	table.draw(pdf, invoice.Items)
+
+	pdf.Ln(6)
+
+	// totals , right aligned , grand rows in the primary colour
+
+	rows := totalRows(invoice)
+	keepTogether(pdf, float64(len(rows))*6)
+
+	for _, row := range rows {
+		style := ""
+		if row.grand {
+			style = "B"
+			pdf.SetTextColor(primary.r, primary.g, primary.b)
+		}
+
+		pdf.SetFont(font, style, 10)
+		pdf.SetX(pdfMargin + 5 + contentWidth - 80)
+		pdf.CellFormat(40, 6, row.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 6, row.value, "", 1, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	pdf.Ln(12)
+
+	drawGSTSummary(pdf, invoice, font, contentWidth, "B", nil)
+	writeNotesAndTerms(pdf, invoice, font)
+}
+
+// PROFESSIONAL TEMPLATE - serif letterhead , boxed details and a fully bordered table
+
+type professionalTemplate struct{}
+
+func (t professionalTemplate) render(pdf *pdfDoc, invoice *domain.Invoice, user *domain.User, brand *pdfBrand, labels pdfLabels) {
+
+	font := brand.useFont(pdf, fontSerif)
+	primary := brand.primaryOr(rgb{0, 0, 0})
+	accent := brand.accentOr(rgb{225, 225, 225})
+	contentWidth := pdfPageWidth - 2*pdfMargin
+
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetFooterFunc(pageNumberFooter(pdf, font, user.DisplayName()))
+	pdf.AddPage()
+
+	// letterhead on the left , under the logo
+
+	if brand.drawLogo(pdf, pdfMargin, pdfMargin, 18) > 0 {
+		pdf.SetY(pdfMargin + 21)
+	}
+
+	pdf.SetFont(font, "B", 18)
+	pdf.CellFormat(110, 9, user.DisplayName(), "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 10)
+
+	for _, line := range businessLines(user) {
+		pdf.CellFormat(110, 5, line, "", 1, "L", false, 0, "")
+	}
+
+	letterheadBottom := pdf.GetY()
+
+	// title and details box on the right
+
+	boxX := 125.0
+	pdf.SetXY(boxX, pdfMargin)
+	pdf.SetFont(font, "B", 22)
+	pdf.SetTextColor(primary.r, primary.g, primary.b)
+	pdf.CellFormat(70, 10, labels.title, "", 1, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	pdf.SetFillColor(accent.r, accent.g, accent.b)
+
+	details := [][2]string{
+		{labels.noun + " No.", invoice.InvoiceNumber},
+		{"Issue Date", invoice.IssueDate.Format(pdfDateLayout)},
+		{labels.due, invoice.DueDate.Format(pdfDateLayout)},
+		{"Status", strings.ToUpper(invoice.Status)},
+	}
+
+	details = append(details, gstDetails(invoice)...)
+
+	for _, d := range details {
+		pdf.SetX(boxX)
+		pdf.SetFont(font, "B", 10)
+		pdf.CellFormat(32, 6, d[0], "1", 0, "L", true, 0, "")
+		pdf.SetFont(font, "", 10)
+		pdf.CellFormat(38, 6, d[1], "1", 1, "R", false, 0, "")
+	}
+
+	if pdf.GetY() < letterheadBottom {
+		pdf.SetY(letterheadBottom)
+	}
+
+	// double rule under the letterhead
+
+	pdf.Ln(4)
+	y := pdf.GetY()
+	pdf.SetDrawColor(primary.r, primary.g, primary.b)
+	pdf.SetLineWidth(0.6)
+	pdf.Line(pdfMargin, y, pdfMargin+contentWidth, y)
+	pdf.SetLineWidth(0.2)
+	pdf.Line(pdfMargin, y+1.2, pdfMargin+contentWidth, y+1.2)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Ln(6)
+
+	// bill to
+
+	pdf.SetFont(font, "B", 11)
+	pdf.CellFormat(contentWidth, 6, "Bill To", "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 10)
+
+	for _, line := range billToLines(invoice) {
+		pdf.CellFormat(contentWidth, 5, line, "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(6)
+
+	// items , numbered rows with full borders
+
+	widths := []float64{10, 85, 25, 30, 30}
+
+	table := &itemsTable{
+		font: font,
+		size: 10,
+		columns: withLineColumns([]tableColumn{
+			{header: "#", width: widths[0], align: "C", value: func(i int, _ *domain.InvoiceItem, _ string) string { return fmt.Sprintf("%d", i+1) }},
+			{header: "Description", width: widths[1], align: "L", wrap: true, value: itemDescription},
+			{header: "Quantity", width: widths[2], align: "R", value: itemQuantity},
+			{header: "Unit Price", width: widths[3], align: "R", value: itemUnitPrice},
+			{header: "Amount", width: widths[4], align: "R", value: itemAmount},
+		}, invoice),
+		header:     tableHeaderStyle{style: "B", size: 10, height: 8, border: "1", fill: &accent},
+		rowHeight:  7,
+		lineHeight: 5,
+		border:     "1",
+		currency:   invoice.Currency,
+	}
+
+	table.draw(pdf, invoice.Items)
+
+	// totals box joined to the table , moved to the next page as a whole when it doesn't fit
+
+	rows := totalRows(invoice)
+	keepTogether(pdf, float64(len(rows))*7)
+	pdf.SetFillColor(accent.r, accent.g, accent.b)
+
+	for _, row := range rows {
+		style := ""
+		if row.grand {
+			style = "B"
+		}
+
+		pdf.SetX(pdfMargin + widths[0] + widths[1] + widths[2])
+		pdf.SetFont(font, style, 10)
+		pdf.CellFormat(widths[3], 7, row.label, "1", 0, "L", row.grand, 0, "")
+		pdf.CellFormat(widths[4], 7, row.value, "1", 1, "R", row.grand, 0, "")
+	}
+
+	pdf.Ln(10)
+
+	drawGSTSummary(pdf, invoice, font, contentWidth, "1", &accent)
+	writeNotesAndTerms(pdf, invoice, font)
+
+	// sign off
+
+	pdf.Ln(8)
+	pdf.SetFont(font, "I", 10)
+	pdf.CellFormat(contentWidth, 5, "For "+user.DisplayName(), "", 1, "R", false, 0, "")
+}
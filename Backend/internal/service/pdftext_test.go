@@ -0,0 +1,200 @@
+// pdfs for the service tests - a PDFService on the repo's fonts , a sample invoice and the text read back out of a rendered pdf
+// gofpdf writes utf-8 text as (utf-16be) Tj strings in flate compressed page streams , which is all the reader here handles
+
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/google/uuid"
+)
+
+const testFontDir = "../../fonts"
+
+func newTestPDFService(t *testing.T, fallbacks ...string) *PDFService {
+
+	t.Helper()
+
+	s, err := NewPDFService(nil, nil, &config.PDFConfig{FontDir: testFontDir, SansFont: "DejaVuSansCondensed", FallbackFonts: fallbacks})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// a sent invoice with the given number of lines , all at 18%
+
+func testInvoice(template string, lines int) (*domain.Invoice, *domain.User) {
+
+	user := &domain.User{
+		ID:              uuid.New(),
+		Email:           "owner@example.com",
+		FullName:        "Asha Rao",
+		BusinessName:    strPtr("Rao Studio"),
+		BusinessAddress: strPtr("12 MG Road , Bengaluru"),
+		DefaultCurrency: "INR",
+		BaseCurrency:    "INR",
+		Timezone:        "Asia/Kolkata",
+	}
+
+	invoice := &domain.Invoice{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		InvoiceNumber: "INV-0042",
+		Status:        domain.InvoiceStatusSent,
+		IssueDate:     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:       time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		Currency:      "INR",
+		BaseCurrency:  "INR",
+		TaxScheme:     domain.TaxSchemeFlat,
+		TaxRate:       money.MustParse("18"),
+		TemplateID:    template,
+		Client:        &domain.Client{Name: "Ravi Kumar", Email: strPtr("ravi@example.com")},
+	}
+
+	for i := range lines {
+
+		item := &domain.InvoiceItem{
+			Description: "Design work , line " + strings.Repeat("x", i%3+1) + " " + strconv.Itoa(i+1),
+			Quantity:    money.FromInt(1),
+			UnitPrice:   money.MustParse("100"),
+			Amount:      money.MustParse("100"),
+			TaxRate:     money.MustParse("18"),
+			TaxAmount:   money.MustParse("18"),
+			SortOrder:   i,
+		}
+
+		invoice.Items = append(invoice.Items, item)
+		invoice.Subtotal = invoice.Subtotal.Add(item.Amount)
+		invoice.TaxAmount = invoice.TaxAmount.Add(item.TaxAmount)
+	}
+
+	invoice.TotalAmount = invoice.Subtotal.Add(invoice.TaxAmount)
+	invoice.BalanceDue = invoice.TotalAmount
+
+	return invoice, user
+}
+
+// one Tj string , the font resource it was drawn with and where (page from 1 , baseline in points up from the bottom)
+
+type pdfText struct {
+	font string
+	text string
+	page int
+	y    float64
+}
+
+var (
+	pdfStreamPattern  = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfContentPattern = regexp.MustCompile(`(?s)/(\w+) [\d.]+ Tf|BT [\d.-]+ ([\d.-]+) Td \(((?:\\.|[^\\)])*)\)\s*Tj`)
+	pdfPagePattern    = regexp.MustCompile(`/Type /Page\b[^s]`)
+)
+
+// every string drawn in the pdf , in page order
+
+func pdfTexts(t *testing.T, data []byte) []pdfText {
+
+	t.Helper()
+
+	texts := []pdfText{}
+	page := 0
+
+	for _, stream := range pdfStreamPattern.FindAllSubmatch(data, -1) {
+
+		r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+
+		if err != nil {
+			continue
+		}
+
+		content, err := io.ReadAll(r)
+
+		// font files are streams too , only page content has text in it
+
+		if err != nil || !bytes.Contains(content, []byte("BT ")) {
+			continue
+		}
+
+		page++
+		font := ""
+
+		for _, m := range pdfContentPattern.FindAllSubmatch(content, -1) {
+
+			if m[1] != nil {
+				font = string(m[1])
+				continue
+			}
+
+			y, _ := strconv.ParseFloat(string(m[2]), 64)
+
+			texts = append(texts, pdfText{font: font, text: decodePDFString(m[3]), page: page, y: y})
+		}
+	}
+
+	return texts
+}
+
+// all the text of the pdf , one drawn string per line
+
+func pdfPlainText(t *testing.T, data []byte) string {
+
+	t.Helper()
+
+	lines := []string{}
+
+	for _, text := range pdfTexts(t, data) {
+		lines = append(lines, text.text)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func pdfPageCount(data []byte) int {
+	return len(pdfPagePattern.FindAll(data, -1))
+}
+
+// undoing gofpdf's escaping , then utf-16be to a string
+
+func decodePDFString(escaped []byte) string {
+
+	raw := []byte{}
+
+	for i := 0; i < len(escaped); i++ {
+
+		if escaped[i] == '\\' && i+1 < len(escaped) {
+			i++
+
+			if escaped[i] == 'r' {
+				raw = append(raw, '\r')
+				continue
+			}
+		}
+
+		raw = append(raw, escaped[i])
+	}
+
+	units := make([]uint16, len(raw)/2)
+
+	for i := range units {
+		units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+	}
+
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,281 @@
+// recurrence rules for recurring invoice schedules
+// fixed frequencies (weekly , monthly , quarterly , yearly) and a cron-like rule for custom schedules
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+)
+
+// how far ahead a custom rule is searched for its next date
+
+const maxRuleSearchDays = 4 * 366
+
+// cron-like rule with day level fields - "<day-of-month> <month> <day-of-week>"
+// each field takes * , a number , a list (1,15) , a range (1-5) and a step (*/2 or 1-31/7)
+// day of week is 0-6 with 0 as sunday , like in cron
+// if both day of month and day of week are restricted , a date matching either one matches (cron semantics)
+
+type cronRule struct {
+	days        map[int]bool
+	months      map[int]bool
+	weekdays    map[int]bool
+	daysAny     bool
+	weekdaysAny bool
+}
+
+// parsing a rule like "1 * *" (1st of every month) or "* * 1" (every monday)
+
+func parseCronRule(rule string) (*cronRule, error) {
+
+	fields := strings.Fields(rule)
+
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("rule must have 3 fields (day-of-month month day-of-week): %w", domain.ErrInvalidInput)
+	}
+
+	days, err := parseCronField(fields[0], 1, 31)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := parseCronField(fields[1], 1, 12)
+	if err != nil {
+		return nil, err
+	}
+
+	weekdays, err := parseCronField(fields[2], 0, 6)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cronRule{
+		days:        days,
+		months:      months,
+		weekdays:    weekdays,
+		daysAny:     fields[0] == "*",
+		weekdaysAny: fields[2] == "*",
+	}, nil
+}
+
+// parsing one comma separated field into the set of values it allows
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+
+	values := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step in rule field %q: %w", field, domain.ErrInvalidInput)
+			}
+
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			n, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in rule field %q: %w", field, domain.ErrInvalidInput)
+			}
+
+			lo, hi = n, n
+
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid range in rule field %q: %w", field, domain.ErrInvalidInput)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("rule field %q is out of range %d-%d: %w", field, min, max, domain.ErrInvalidInput)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// checking a single date against the rule
+
+func (r *cronRule) matches(date time.Time) bool {
+
+	if !r.months[int(date.Month())] {
+		return false
+	}
+
+	dayOK := r.days[date.Day()]
+	weekdayOK := r.weekdays[int(date.Weekday())]
+
+	switch {
+	case r.daysAny && r.weekdaysAny:
+		return true
+	case r.daysAny:
+		return weekdayOK
+	case r.weekdaysAny:
+		return dayOK
+	default:
+		return dayOK || weekdayOK
+	}
+}
+
+// first matching date strictly after the given date
+
+func (r *cronRule) next(after time.Time) (time.Time, bool) {
+
+	date := after
+
+	for i := 0; i < maxRuleSearchDays; i++ {
+		date = date.AddDate(0, 0, 1)
+
+		if r.matches(date) {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// validating frequency and rule of a schedule
+
+func validateRecurrence(frequency string, rule *string) error {
+
+	if frequency != domain.FrequencyCustom {
+		return nil
+	}
+
+	if rule == nil || *rule == "" {
+		return fmt.Errorf("rule is required for custom frequency: %w", domain.ErrInvalidInput)
+	}
+
+	_, err := parseCronRule(*rule)
+
+	return err
+}
+
+// adding months to the start date , keeping its day when the month is long enough
+// anchoring on the start date means 31 jan -> 28 feb -> 31 mar , instead of drifting to the 28th
+
+func addMonthsClamped(start time.Time, months int) time.Time {
+
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+
+	day := start.Day()
+
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// next period date of a schedule after the given period , false when there is no further date
+
+func nextRunDate(schedule *domain.RecurringSchedule, period time.Time) (time.Time, bool) {
+
+	var next time.Time
+
+	switch schedule.Frequency {
+	case domain.FrequencyWeekly:
+		next = period.AddDate(0, 0, 7)
+
+	case domain.FrequencyMonthly, domain.FrequencyQuarterly, domain.FrequencyYearly:
+
+		step := map[string]int{
+			domain.FrequencyMonthly:   1,
+			domain.FrequencyQuarterly: 3,
+			domain.FrequencyYearly:    12,
+		}[schedule.Frequency]
+
+		// months between the start and this period , then one step more
+
+		elapsed := (period.Year()-schedule.StartDate.Year())*12 + int(period.Month()-schedule.StartDate.Month())
+		next = addMonthsClamped(schedule.StartDate, elapsed+step)
+
+	case domain.FrequencyCustom:
+		rule, err := parseCronRule(*schedule.Rule)
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		var ok bool
+
+		if next, ok = rule.next(period); !ok {
+			return time.Time{}, false
+		}
+
+	default:
+		return time.Time{}, false
+	}
+
+	if schedule.EndDate != nil && next.After(*schedule.EndDate) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+// first period of a new schedule - the start date itself , or the first date matching a custom rule
+
+func firstRunDate(frequency string, rule *string, start time.Time) (time.Time, bool) {
+
+	if frequency != domain.FrequencyCustom {
+		return start, true
+	}
+
+	parsed, err := parseCronRule(*rule)
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if parsed.matches(start) {
+		return start, true
+	}
+
+	return parsed.next(start)
+}
+
+// next period of a schedule that has generated up to lastPeriod (nil when it hasn't generated anything) , nil when it has no further period
+
+func scheduleNextRun(schedule *domain.RecurringSchedule, lastPeriod *time.Time) *time.Time {
+
+	if lastPeriod != nil {
+		if next, ok := nextRunDate(schedule, *lastPeriod); ok {
+			return &next
+		}
+
+		return nil
+	}
+
+	first, ok := firstRunDate(schedule.Frequency, schedule.Rule, schedule.StartDate)
+
+	if !ok || (schedule.EndDate != nil && first.After(*schedule.EndDate)) {
+		return nil
+	}
+
+	return &first
+}
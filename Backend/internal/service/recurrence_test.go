@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseCronRule(t *testing.T) {
+
+	tests := []struct {
+		rule    string
+		matches []time.Time
+		misses  []time.Time
+	}{
+		// 1st and 15th of every month
+
+		{"1,15 * *", []time.Time{date(2026, 3, 1), date(2026, 3, 15)}, []time.Time{date(2026, 3, 2), date(2026, 3, 31)}},
+
+		// every monday
+
+		{"* * 1", []time.Time{date(2026, 3, 2), date(2026, 3, 9)}, []time.Time{date(2026, 3, 3), date(2026, 3, 8)}},
+
+		// weekdays of the first quarter
+
+		{"* 1-3 1-5", []time.Time{date(2026, 1, 5), date(2026, 3, 31)}, []time.Time{date(2026, 1, 4), date(2026, 4, 1)}},
+
+		// every other month on the 10th
+
+		{"10 */2 *", []time.Time{date(2026, 1, 10), date(2026, 3, 10)}, []time.Time{date(2026, 2, 10), date(2026, 3, 11)}},
+
+		// day of month and day of week both restricted , either one matches like in cron
+
+		{"13 * 5", []time.Time{date(2026, 3, 13), date(2026, 3, 6), date(2026, 4, 13)}, []time.Time{date(2026, 3, 12)}},
+	}
+
+	for _, tt := range tests {
+
+		rule, err := parseCronRule(tt.rule)
+
+		if err != nil {
+			t.Errorf("%q: %v", tt.rule, err)
+			continue
+		}
+
+		for _, d := range tt.matches {
+			if !rule.matches(d) {
+				t.Errorf("%q doesn't match %s", tt.rule, d.Format(dateLayout))
+			}
+		}
+
+		for _, d := range tt.misses {
+			if rule.matches(d) {
+				t.Errorf("%q matches %s", tt.rule, d.Format(dateLayout))
+			}
+		}
+	}
+
+	for _, rule := range []string{"", "1 *", "1 * * *", "0 * *", "32 * *", "* 13 *", "* * 7", "5-1 * *", "*/0 * *", "a * *", "1-b * *"} {
+		if _, err := parseCronRule(rule); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("%q: got %v , want ErrInvalidInput", rule, err)
+		}
+	}
+}
+
+// a rule that never matches (31st of february) has no next date
+
+func TestCronRuleNextNeverMatches(t *testing.T) {
+
+	rule, err := parseCronRule("31 2 *")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next, ok := rule.next(date(2026, 1, 1)); ok {
+		t.Errorf("next = %s , want none", next.Format(dateLayout))
+	}
+}
+
+// anchored on the start date , a month end start keeps coming back to the month end
+
+func TestAddMonthsClamped(t *testing.T) {
+
+	tests := []struct {
+		start  time.Time
+		months int
+		want   time.Time
+	}{
+		{date(2026, 1, 31), 1, date(2026, 2, 28)},
+		{date(2026, 1, 31), 2, date(2026, 3, 31)},
+		{date(2026, 1, 31), 3, date(2026, 4, 30)},
+		{date(2028, 1, 31), 1, date(2028, 2, 29)},
+		{date(2028, 1, 31), 2, date(2028, 3, 31)},
+		{date(2026, 11, 30), 3, date(2027, 2, 28)},
+		{date(2026, 1, 15), 12, date(2027, 1, 15)},
+	}
+
+	for _, tt := range tests {
+		if got := addMonthsClamped(tt.start, tt.months); !got.Equal(tt.want) {
+			t.Errorf("%s + %d months = %s , want %s", tt.start.Format(dateLayout), tt.months, got.Format(dateLayout), tt.want.Format(dateLayout))
+		}
+	}
+}
+
+func TestNextRunDate(t *testing.T) {
+
+	rule := "1,15 * *"
+	end := date(2026, 4, 30)
+
+	tests := []struct {
+		name     string
+		schedule *domain.RecurringSchedule
+		periods  []time.Time
+	}{
+		{
+			"monthly from jan 31",
+			&domain.RecurringSchedule{Frequency: domain.FrequencyMonthly, StartDate: date(2026, 1, 31)},
+			[]time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31), date(2026, 4, 30)},
+		},
+		{
+			"monthly from jan 31 in a leap year",
+			&domain.RecurringSchedule{Frequency: domain.FrequencyMonthly, StartDate: date(2028, 1, 31)},
+			[]time.Time{date(2028, 1, 31), date(2028, 2, 29), date(2028, 3, 31)},
+		},
+		{
+			"weekly",
+			&domain.RecurringSchedule{Frequency: domain.FrequencyWeekly, StartDate: date(2026, 2, 24)},
+			[]time.Time{date(2026, 2, 24), date(2026, 3, 3), date(2026, 3, 10)},
+		},
+		{
+			"quarterly",
+			&domain.RecurringSchedule{Frequency: domain.FrequencyQuarterly, StartDate: date(2026, 11, 30)},
+			[]time.Time{date(2026, 11, 30), date(2027, 2, 28), date(2027, 5, 30)},
+		},
+		{
+			"yearly from a leap day",
+			&domain.RecurringSchedule{Frequency: domain.FrequencyYearly, StartDate: date(2028, 2, 29)},
+			[]time.Time{date(2028, 2, 29), date(2029, 2, 28), date(2030, 2, 28), date(2031, 2, 28), date(2032, 2, 29)},
+		},
+		{
+			"custom",
+			&domain.RecurringSchedule{Frequency: domain.FrequencyCustom, Rule: &rule, StartDate: date(2026, 1, 1)},
+			[]time.Time{date(2026, 1, 1), date(2026, 1, 15), date(2026, 2, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		for i := 1; i < len(tt.periods); i++ {
+			got, ok := nextRunDate(tt.schedule, tt.periods[i-1])
+
+			if !ok || !got.Equal(tt.periods[i]) {
+				t.Errorf("%s: after %s got %s (%v) , want %s", tt.name, tt.periods[i-1].Format(dateLayout), got.Format(dateLayout), ok, tt.periods[i].Format(dateLayout))
+			}
+		}
+	}
+
+	// nothing after the end date
+
+	schedule := &domain.RecurringSchedule{Frequency: domain.FrequencyMonthly, StartDate: date(2026, 1, 31), EndDate: &end}
+
+	if next, ok := nextRunDate(schedule, date(2026, 4, 30)); ok {
+		t.Errorf("next after the end date: %s", next.Format(dateLayout))
+	}
+}
+
+// a schedule that ran out after its end date was shortened comes back when the end date moves out again
+
+func TestScheduleNextRun(t *testing.T) {
+
+	short, long := date(2026, 2, 15), date(2026, 12, 31)
+	last := date(2026, 1, 31)
+
+	schedule := &domain.RecurringSchedule{Frequency: domain.FrequencyMonthly, StartDate: date(2026, 1, 31), EndDate: &short}
+
+	if next := scheduleNextRun(schedule, &last); next != nil {
+		t.Errorf("next with the short end date = %s , want none", next.Format(dateLayout))
+	}
+
+	schedule.EndDate = &long
+
+	if next := scheduleNextRun(schedule, &last); next == nil || !next.Equal(date(2026, 2, 28)) {
+		t.Errorf("next with the long end date = %v , want 2026-02-28", next)
+	}
+
+	// nothing generated yet , the first period
+
+	if next := scheduleNextRun(schedule, nil); next == nil || !next.Equal(schedule.StartDate) {
+		t.Errorf("first period = %v , want the start date", next)
+	}
+
+	schedule.EndDate = nil
+	rule := "1 * *"
+	schedule.Frequency, schedule.Rule = domain.FrequencyCustom, &rule
+
+	if next := scheduleNextRun(schedule, nil); next == nil || !next.Equal(date(2026, 2, 1)) {
+		t.Errorf("first custom period = %v , want 2026-02-01", next)
+	}
+}
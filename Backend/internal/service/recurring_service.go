@@ -0,0 +1,447 @@
+// recurring service - recurring invoice schedules and the background generator
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/google/uuid"
+)
+
+type RecurringService struct {
+	db                  *database.DB
+	invoiceService      *InvoiceService
+	invoiceEmailService *InvoiceEmailService
+}
+
+// recurring service function , the email service emails the invoices of auto send schedules
+
+func NewRecurringService(db *database.DB, invoiceService *InvoiceService, invoiceEmailService *InvoiceEmailService) *RecurringService {
+	return &RecurringService{
+		db:                  db,
+		invoiceService:      invoiceService,
+		invoiceEmailService: invoiceEmailService,
+	}
+}
+
+const scheduleColumns = `id , user_id , template_invoice_id , frequency , rule , start_date , end_date , next_run_date , auto_send , is_active , last_run_at , last_error , created_at , updated_at`
+
+func scanSchedule(row rowScanner, schedule *domain.RecurringSchedule) error {
+	return row.Scan(
+		&schedule.ID, &schedule.UserID, &schedule.TemplateInvoiceID, &schedule.Frequency, &schedule.Rule, &schedule.StartDate, &schedule.EndDate, &schedule.NextRunDate, &schedule.AutoSend, &schedule.IsActive, &schedule.LastRunAt, &schedule.LastError, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+}
+
+// creating a schedule for one of the user's invoices
+
+func (s *RecurringService) CreateSchedule(userID uuid.UUID, req *domain.CreateRecurringScheduleRequest) (*domain.RecurringSchedule, error) {
+
+	// template invoice must belong to the user
+
+	if _, err := s.invoiceService.GetInvoiceByID(userID, req.TemplateInvoiceID); err != nil {
+		return nil, err
+	}
+
+	if err := validateRecurrence(req.Frequency, req.Rule); err != nil {
+		return nil, err
+	}
+
+	startDate, err := parseDate("start_date", req.StartDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var endDate *time.Time
+
+	if req.EndDate != nil {
+		date, err := parseDate("end_date", *req.EndDate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if date.Before(startDate) {
+			return nil, fmt.Errorf("end_date cannot be before start_date: %w", domain.ErrInvalidInput)
+		}
+
+		endDate = &date
+	}
+
+	schedule := &domain.RecurringSchedule{
+		ID:                uuid.New(),
+		UserID:            userID,
+		TemplateInvoiceID: req.TemplateInvoiceID,
+		Frequency:         req.Frequency,
+		Rule:              req.Rule,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		AutoSend:          req.AutoSend,
+		IsActive:          true,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	schedule.NextRunDate = scheduleNextRun(schedule, nil)
+
+	query := `
+		      INSERT INTO recurring_schedules (
+					   id , user_id , template_invoice_id , frequency , rule , start_date , end_date , next_run_date , auto_send , is_active , created_at , updated_at
+					) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12)
+		    `
+
+	_, err = s.db.Exec(
+		query,
+		schedule.ID, schedule.UserID, schedule.TemplateInvoiceID, schedule.Frequency, schedule.Rule, schedule.StartDate, schedule.EndDate, schedule.NextRunDate, schedule.AutoSend, schedule.IsActive, schedule.CreatedAt, schedule.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+
+}
+
+// getting one schedule of the user
+
+func (s *RecurringService) GetSchedule(userID, scheduleID uuid.UUID) (*domain.RecurringSchedule, error) {
+
+	schedule := &domain.RecurringSchedule{}
+
+	query := `SELECT ` + scheduleColumns + ` FROM recurring_schedules WHERE id = $1 AND user_id = $2`
+
+	err := scanSchedule(s.db.QueryRow(query, scheduleID, userID), schedule)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrScheduleNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+
+}
+
+// listing all the schedules of the user
+
+func (s *RecurringService) ListSchedules(userID uuid.UUID) ([]*domain.RecurringSchedule, error) {
+
+	query := `SELECT ` + scheduleColumns + ` FROM recurring_schedules WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := s.db.Query(query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	schedules := []*domain.RecurringSchedule{}
+
+	for rows.Next() {
+		schedule := &domain.RecurringSchedule{}
+
+		if err := scanSchedule(rows, schedule); err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+
+}
+
+// updating end date , auto send and pausing / resuming a schedule
+
+func (s *RecurringService) UpdateSchedule(userID, scheduleID uuid.UUID, req *domain.UpdateRecurringScheduleRequest) (*domain.RecurringSchedule, error) {
+
+	schedule, err := s.GetSchedule(userID, scheduleID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EndDate != nil {
+		date, err := parseDate("end_date", *req.EndDate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if date.Before(schedule.StartDate) {
+			return nil, fmt.Errorf("end_date cannot be before start_date: %w", domain.ErrInvalidInput)
+		}
+
+		schedule.EndDate = &date
+	}
+
+	if req.AutoSend != nil {
+		schedule.AutoSend = *req.AutoSend
+	}
+
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+
+	// the next period is worked out again from the last generated one , so a later end date or a resume brings a finished schedule back
+
+	if req.EndDate != nil || req.IsActive != nil {
+
+		var last sql.NullTime
+
+		if err := s.db.QueryRow(`SELECT MAX(period_date) FROM recurring_invoice_runs WHERE schedule_id = $1`, scheduleID).Scan(&last); err != nil {
+			return nil, err
+		}
+
+		var lastPeriod *time.Time
+
+		if last.Valid {
+			lastPeriod = &last.Time
+		}
+
+		schedule.NextRunDate = scheduleNextRun(schedule, lastPeriod)
+	}
+
+	query := `UPDATE recurring_schedules SET end_date = $1 , next_run_date = $2 , auto_send = $3 , is_active = $4 , updated_at = $5 WHERE id = $6 AND user_id = $7`
+
+	_, err = s.db.Exec(query, schedule.EndDate, schedule.NextRunDate, schedule.AutoSend, schedule.IsActive, time.Now(), scheduleID, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSchedule(userID, scheduleID)
+
+}
+
+// deleting a schedule , invoices it already created are kept
+
+func (s *RecurringService) DeleteSchedule(userID, scheduleID uuid.UUID) error {
+
+	result, err := s.db.Exec(`DELETE FROM recurring_schedules WHERE id = $1 AND user_id = $2`, scheduleID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrScheduleNotFound
+	}
+
+	return nil
+
+}
+
+// background generator , checking for due schedules on every tick until the context is done
+// same ticker pattern as the rate limiter cleanup
+
+func (s *RecurringService) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunDue(time.Now()); err != nil {
+			log.Printf("recurring invoices: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generating invoices for every active schedule whose next period is on or before now
+
+func (s *RecurringService) RunDue(now time.Time) error {
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rows, err := s.db.Query(`SELECT id FROM recurring_schedules WHERE is_active = true AND next_run_date <= $1`, today)
+
+	if err != nil {
+		return err
+	}
+
+	ids := []uuid.UUID{}
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// one failing schedule doesn't stop the others , its error is kept on the schedule
+
+	for _, id := range ids {
+		for {
+			generated, err := s.runSchedulePeriod(id, today)
+
+			if err != nil {
+				s.recordScheduleError(id, err)
+				break
+			}
+
+			if !generated {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// generating the invoice for the schedule's next period in one transaction
+// the period is claimed in recurring_invoice_runs first , so a period that was already generated is skipped
+// an auto send invoice is emailed once the transaction is committed , a failed email leaves it as a draft
+// returns false when the schedule has nothing due (or another instance holds it)
+
+func (s *RecurringService) runSchedulePeriod(scheduleID uuid.UUID, today time.Time) (bool, error) {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	schedule := &domain.RecurringSchedule{}
+
+	query := `SELECT ` + scheduleColumns + ` FROM recurring_schedules WHERE id = $1 AND is_active = true FOR UPDATE SKIP LOCKED`
+
+	err = scanSchedule(tx.QueryRow(query, scheduleID), schedule)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if schedule.NextRunDate == nil || schedule.NextRunDate.After(today) {
+		return false, nil
+	}
+
+	period := *schedule.NextRunDate
+
+	// claiming the period
+
+	var runID uuid.UUID
+
+	err = tx.QueryRow(
+		`INSERT INTO recurring_invoice_runs (id , schedule_id , period_date , created_at) VALUES ($1 , $2 , $3 , $4) ON CONFLICT (schedule_id , period_date) DO NOTHING RETURNING id`,
+		uuid.New(), schedule.ID, period, time.Now(),
+	).Scan(&runID)
+
+	claimed := err == nil
+
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	var invoice *domain.Invoice
+
+	if claimed {
+		if invoice, err = s.generateInvoice(tx, schedule, runID, period); err != nil {
+			return false, err
+		}
+	}
+
+	// moving the schedule to its next period , even when the period was generated before
+
+	var next *time.Time
+
+	if date, ok := nextRunDate(schedule, period); ok {
+		next = &date
+	}
+
+	_, err = tx.Exec(
+		`UPDATE recurring_schedules SET next_run_date = $1 , last_run_at = $2 , last_error = NULL , updated_at = $2 WHERE id = $3`,
+		next, time.Now(), schedule.ID,
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	if invoice != nil && schedule.AutoSend {
+		if _, err := s.invoiceEmailService.SendInvoice(schedule.UserID, invoice.ID, &domain.SendInvoiceRequest{}); err != nil {
+			return true, fmt.Errorf("auto send of invoice %s: %w", invoice.InvoiceNumber, err)
+		}
+	}
+
+	return true, nil
+
+}
+
+// creating the invoice of one period from the template invoice
+// issue date is the period date , due date keeps the template's payment terms
+
+func (s *RecurringService) generateInvoice(tx *sql.Tx, schedule *domain.RecurringSchedule, runID uuid.UUID, period time.Time) (*domain.Invoice, error) {
+
+	template, err := s.invoiceService.GetInvoiceByID(schedule.UserID, schedule.TemplateInvoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	terms := int(template.DueDate.Sub(template.IssueDate).Hours() / 24)
+
+	req := copyInvoiceRequest(template, period, period.AddDate(0, 0, terms))
+
+	// goes through the same create path as CreateInvoice , including the free tier limit
+
+	invoice, err := s.invoiceService.createInvoiceTx(tx, schedule.UserID, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Exec(`UPDATE recurring_invoice_runs SET invoice_id = $1 WHERE id = $2`, invoice.ID, runID); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+
+}
+
+// keeping the last error on the schedule , the period stays due and is retried on the next tick
+// (for example when the free tier limit was reached)
+
+func (s *RecurringService) recordScheduleError(scheduleID uuid.UUID, runErr error) {
+
+	_, err := s.db.Exec(`UPDATE recurring_schedules SET last_error = $1 , last_run_at = $2 , updated_at = $2 WHERE id = $3`, runErr.Error(), time.Now(), scheduleID)
+
+	if err != nil {
+		log.Printf("recurring invoices: saving error for schedule %s: %v", scheduleID, err)
+	}
+}
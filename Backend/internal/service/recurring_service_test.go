@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+)
+
+// shortening the end date past the next period ends the schedule , moving it out again or resuming brings the next period back
+
+func TestUpdateScheduleRecomputesNextRun(t *testing.T) {
+
+	db := newTestDB(t)
+	userService := NewUserService(db)
+	recurring := NewRecurringService(db, NewInvoiceService(db, userService, nil), nil)
+
+	userID := insertTestUser(t, db, "UTC")
+	invoiceID := insertTestInvoice(t, db, userID, domain.InvoiceStatusSent, date(2026, 1, 31))
+
+	schedule, err := recurring.CreateSchedule(userID, &domain.CreateRecurringScheduleRequest{
+		TemplateInvoiceID: invoiceID,
+		Frequency:         domain.FrequencyMonthly,
+		StartDate:         "2026-01-31",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first period has been generated
+
+	if _, err := db.Exec(`INSERT INTO recurring_invoice_runs (schedule_id , period_date) VALUES ($1 , $2)`, schedule.ID, date(2026, 1, 31)); err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(req *domain.UpdateRecurringScheduleRequest) *domain.RecurringSchedule {
+
+		t.Helper()
+
+		updated, err := recurring.UpdateSchedule(userID, schedule.ID, req)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return updated
+	}
+
+	short, long := "2026-02-15", "2026-12-31"
+	paused, resumed := false, true
+
+	if got := update(&domain.UpdateRecurringScheduleRequest{EndDate: &short}); got.NextRunDate != nil {
+		t.Errorf("next run with the short end date = %s , want none", got.NextRunDate.Format(dateLayout))
+	}
+
+	if got := update(&domain.UpdateRecurringScheduleRequest{EndDate: &long}); got.NextRunDate == nil || !got.NextRunDate.Equal(date(2026, 2, 28)) {
+		t.Errorf("next run with the long end date = %v , want 2026-02-28", got.NextRunDate)
+	}
+
+	update(&domain.UpdateRecurringScheduleRequest{IsActive: &paused})
+
+	if got := update(&domain.UpdateRecurringScheduleRequest{IsActive: &resumed}); !got.IsActive || got.NextRunDate == nil || !got.NextRunDate.Equal(date(2026, 2, 28)) {
+		t.Errorf("resumed schedule: active %v , next run %v", got.IsActive, got.NextRunDate)
+	}
+}
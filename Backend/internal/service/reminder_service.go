@@ -0,0 +1,611 @@
+// reminder service - payment reminder sequences (dunning) and the background sender
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/mailer"
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// an occurrence missed by more than this many days (for example while the server was down) is not sent anymore
+
+const reminderGraceDays = 2
+
+// a claim still pending after this long is taken to be lost (the instance died while sending) and claimed again
+// the reminder may then go out twice , which beats it never going out
+
+const reminderClaimTimeout = 15 * time.Minute
+
+// built in template , used by steps without their own template
+
+const (
+	defaultReminderSubject = "Reminder: invoice {{invoice_number}} from {{business_name}}"
+	defaultReminderBody    = "Hi {{client_name}},\n\nThis is a reminder that invoice {{invoice_number}} for {{currency}} {{amount_due}} is due on {{due_date}}.\n\nIf you have already paid, please ignore this email.\n\nThanks,\n{{business_name}}"
+)
+
+type ReminderService struct {
+	db     *database.DB
+	mailer mailer.Mailer
+	clock  util.Clock
+}
+
+// reminder service function
+
+func NewReminderService(db *database.DB, mailer mailer.Mailer, clock util.Clock) *ReminderService {
+	return &ReminderService{
+		db:     db,
+		mailer: mailer,
+		clock:  clock,
+	}
+}
+
+// TEMPLATES
+
+func (s *ReminderService) ListTemplates(userID uuid.UUID) ([]*domain.ReminderTemplate, error) {
+
+	query := `SELECT id , user_id , name , subject , body , created_at , updated_at FROM reminder_templates WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := s.db.Query(query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	templates := []*domain.ReminderTemplate{}
+
+	for rows.Next() {
+		t := &domain.ReminderTemplate{}
+
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Subject, &t.Body, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+
+}
+
+func (s *ReminderService) CreateTemplate(userID uuid.UUID, req *domain.ReminderTemplateRequest) (*domain.ReminderTemplate, error) {
+
+	t := &domain.ReminderTemplate{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		Subject:   req.Subject,
+		Body:      req.Body,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	query := `INSERT INTO reminder_templates (id , user_id , name , subject , body , created_at , updated_at) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7)`
+
+	if _, err := s.db.Exec(query, t.ID, t.UserID, t.Name, t.Subject, t.Body, t.CreatedAt, t.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+
+}
+
+func (s *ReminderService) UpdateTemplate(userID, templateID uuid.UUID, req *domain.ReminderTemplateRequest) (*domain.ReminderTemplate, error) {
+
+	t := &domain.ReminderTemplate{}
+
+	query := `
+		      UPDATE reminder_templates SET name = $1 , subject = $2 , body = $3 , updated_at = $4 WHERE id = $5 AND user_id = $6
+					RETURNING id , user_id , name , subject , body , created_at , updated_at
+		    `
+
+	err := s.db.QueryRow(query, req.Name, req.Subject, req.Body, time.Now(), templateID, userID).Scan(
+		&t.ID, &t.UserID, &t.Name, &t.Subject, &t.Body, &t.CreatedAt, &t.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReminderTemplateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+
+}
+
+// steps using a deleted template fall back to the built in one
+
+func (s *ReminderService) DeleteTemplate(userID, templateID uuid.UUID) error {
+
+	result, err := s.db.Exec(`DELETE FROM reminder_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReminderTemplateNotFound
+	}
+
+	return nil
+
+}
+
+// SEQUENCE
+
+func (s *ReminderService) GetRules(userID uuid.UUID) ([]*domain.ReminderRule, error) {
+	return getReminderRules(s.db, userID)
+}
+
+func getReminderRules(q queryer, userID uuid.UUID) ([]*domain.ReminderRule, error) {
+
+	query := `SELECT id , user_id , template_id , offset_days , repeat_every_days , sort_order , created_at FROM reminder_rules WHERE user_id = $1 ORDER BY sort_order`
+
+	rows, err := q.Query(query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rules := []*domain.ReminderRule{}
+
+	for rows.Next() {
+		rule := &domain.ReminderRule{}
+
+		if err := rows.Scan(&rule.ID, &rule.UserID, &rule.TemplateID, &rule.OffsetDays, &rule.RepeatEveryDays, &rule.SortOrder, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// replacing the user's whole reminder sequence
+// a step keeps its id when the new sequence has a step with the same offset , so its occurrences already in the log aren't sent again
+
+func (s *ReminderService) ReplaceRules(userID uuid.UUID, req *domain.UpdateReminderRulesRequest) ([]*domain.ReminderRule, error) {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// the user's row is locked so two replacements of the same sequence don't interleave
+
+	if _, err = tx.Exec(`SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	current, err := getReminderRules(tx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byOffset := map[int][]*domain.ReminderRule{}
+
+	for _, rule := range current {
+		byOffset[rule.OffsetDays] = append(byOffset[rule.OffsetDays], rule)
+	}
+
+	for i, r := range req.Rules {
+
+		// template must belong to the user
+
+		if r.TemplateID != nil {
+			var exists bool
+
+			err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM reminder_templates WHERE id = $1 AND user_id = $2)`, *r.TemplateID, userID).Scan(&exists)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !exists {
+				return nil, domain.ErrReminderTemplateNotFound
+			}
+		}
+
+		if same := byOffset[r.OffsetDays]; len(same) > 0 {
+			byOffset[r.OffsetDays] = same[1:]
+
+			query := `UPDATE reminder_rules SET template_id = $1 , repeat_every_days = $2 , sort_order = $3 WHERE id = $4`
+
+			if _, err = tx.Exec(query, r.TemplateID, r.RepeatEveryDays, i, same[0].ID); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		query := `INSERT INTO reminder_rules (id , user_id , template_id , offset_days , repeat_every_days , sort_order , created_at) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7)`
+
+		if _, err = tx.Exec(query, uuid.New(), userID, r.TemplateID, r.OffsetDays, r.RepeatEveryDays, i, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	// steps that aren't in the new sequence , their log rows stay with an empty rule_id
+
+	for _, rules := range byOffset {
+		for _, rule := range rules {
+			if _, err = tx.Exec(`DELETE FROM reminder_rules WHERE id = $1`, rule.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetRules(userID)
+
+}
+
+// PAUSING
+
+func (s *ReminderService) SetInvoicePaused(userID, invoiceID uuid.UUID, paused bool) error {
+
+	result, err := s.db.Exec(`UPDATE invoices SET reminders_paused = $1 , updated_at = $2 WHERE id = $3 AND user_id = $4`, paused, time.Now(), invoiceID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrInvoiceNotFound
+	}
+
+	return nil
+
+}
+
+func (s *ReminderService) SetClientPaused(userID, clientID uuid.UUID, paused bool) error {
+
+	result, err := s.db.Exec(`UPDATE clients SET reminders_paused = $1 , updated_at = $2 WHERE id = $3 AND user_id = $4 AND is_active = true`, paused, time.Now(), clientID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrClientNotFound
+	}
+
+	return nil
+
+}
+
+// LOG
+
+func (s *ReminderService) ListInvoiceReminders(userID, invoiceID uuid.UUID) ([]*domain.InvoiceReminder, error) {
+
+	var exists bool
+
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM invoices WHERE id = $1 AND user_id = $2)`, invoiceID, userID).Scan(&exists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	query := `
+		     SELECT id , invoice_id , rule_id , scheduled_for , sent_to , subject , status , error , sent_at , created_at
+				 FROM invoice_reminders WHERE invoice_id = $1 ORDER BY created_at
+		   `
+
+	rows, err := s.db.Query(query, invoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reminders := []*domain.InvoiceReminder{}
+
+	for rows.Next() {
+		rem := &domain.InvoiceReminder{}
+
+		err := rows.Scan(&rem.ID, &rem.InvoiceID, &rem.RuleID, &rem.ScheduledFor, &rem.SentTo, &rem.Subject, &rem.Status, &rem.Error, &rem.SentAt, &rem.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reminders = append(reminders, rem)
+	}
+
+	return reminders, rows.Err()
+
+}
+
+// SENDER
+
+// open invoice that may need a reminder , with what the template needs
+
+type reminderCandidate struct {
+	invoiceID     uuid.UUID
+	userID        uuid.UUID
+	invoiceNumber string
+	dueDate       time.Time
+	currency      string
+	amountDue     money.Decimal
+	clientName    string
+	clientEmail   string
+	businessName  string
+	replyTo       string
+	timezone      string
+}
+
+// background sender , same ticker pattern as the other jobs
+
+func (s *ReminderService) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.SendDue(); err != nil {
+			log.Printf("reminders: %v", err)
+		} else if n > 0 {
+			log.Printf("reminders: %d sent", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sending every reminder that is due today for open invoices
+// invoices or clients with paused reminders , clients without an email and users who haven't verified their email are skipped
+// replies go to the user's reply-to address , or their account email when it isn't set , like for the invoice email
+// returns how many reminders went out
+
+func (s *ReminderService) SendDue() (int, error) {
+
+	now := s.clock.Now()
+
+	query := `
+		     SELECT i.id , i.user_id , i.invoice_number , i.due_date , i.currency ,
+				        i.total_amount - COALESCE((SELECT SUM(p.amount) FROM payments p WHERE p.invoice_id = i.id), 0) - COALESCE((SELECT SUM(cn.total_amount) FROM credit_notes cn WHERE cn.invoice_id = i.id), 0) ,
+								c.name , c.email , COALESCE(NULLIF(u.business_name , '') , u.full_name) , COALESCE(NULLIF(u.reply_to_email , '') , u.email) , u.timezone
+				 FROM invoices i
+				 JOIN clients c ON c.id = i.client_id
+				 JOIN users u ON u.id = i.user_id
+				 WHERE i.status IN ('sent' , 'partially_paid' , 'overdue')
+				   AND i.reminders_paused = false
+					 AND c.reminders_paused = false
+					 AND c.email IS NOT NULL AND c.email <> ''
+					 AND u.email_verified = true
+					 AND EXISTS (SELECT 1 FROM reminder_rules r WHERE r.user_id = i.user_id)
+		   `
+
+	rows, err := s.db.Query(query)
+
+	if err != nil {
+		return 0, err
+	}
+
+	candidates := []*reminderCandidate{}
+
+	for rows.Next() {
+		c := &reminderCandidate{}
+
+		err := rows.Scan(&c.invoiceID, &c.userID, &c.invoiceNumber, &c.dueDate, &c.currency, &c.amountDue, &c.clientName, &c.clientEmail, &c.businessName, &c.replyTo, &c.timezone)
+
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// sequences and templates are loaded once per user
+
+	rulesByUser := map[uuid.UUID][]*domain.ReminderRule{}
+	templates := map[uuid.UUID]*domain.ReminderTemplate{}
+
+	sent := 0
+
+	for _, c := range candidates {
+
+		rules, ok := rulesByUser[c.userID]
+
+		if !ok {
+			if rules, err = getReminderRules(s.db, c.userID); err != nil {
+				return sent, err
+			}
+
+			rulesByUser[c.userID] = rules
+		}
+
+		today := localDate(now, c.timezone)
+
+		for _, rule := range rules {
+
+			occurrence, ok := reminderOccurrence(rule, c.dueDate, today)
+
+			if !ok {
+				continue
+			}
+
+			delivered, err := s.sendReminder(c, rule, occurrence, templates)
+
+			if err != nil {
+				log.Printf("reminders: invoice %s: %v", c.invoiceNumber, err)
+				continue
+			}
+
+			if delivered {
+				sent++
+			}
+		}
+	}
+
+	return sent, nil
+}
+
+// claiming , rendering and sending one occurrence of a step
+// the log row is claimed first , so an occurrence goes out once even with several instances running
+// a failed occurrence is claimed again on the next run , and so is one left pending past reminderClaimTimeout
+
+func (s *ReminderService) sendReminder(c *reminderCandidate, rule *domain.ReminderRule, occurrence time.Time, templates map[uuid.UUID]*domain.ReminderTemplate) (bool, error) {
+
+	subject, body := defaultReminderSubject, defaultReminderBody
+
+	if rule.TemplateID != nil {
+		t, ok := templates[*rule.TemplateID]
+
+		if !ok {
+			t = &domain.ReminderTemplate{}
+
+			err := s.db.QueryRow(`SELECT subject , body FROM reminder_templates WHERE id = $1`, *rule.TemplateID).Scan(&t.Subject, &t.Body)
+
+			if err != nil && err != sql.ErrNoRows {
+				return false, err
+			}
+
+			if err == sql.ErrNoRows {
+				t.Subject, t.Body = defaultReminderSubject, defaultReminderBody
+			}
+
+			templates[*rule.TemplateID] = t
+		}
+
+		subject, body = t.Subject, t.Body
+	}
+
+	replacer := reminderReplacer(c, occurrence)
+	subject = replacer.Replace(subject)
+	body = replacer.Replace(body)
+
+	var reminderID uuid.UUID
+
+	claimQuery := `
+		      INSERT INTO invoice_reminders (id , invoice_id , rule_id , scheduled_for , sent_to , subject , status , created_at , claimed_at)
+					VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $8)
+					ON CONFLICT (invoice_id , rule_id , scheduled_for) DO UPDATE SET status = EXCLUDED.status , error = NULL , claimed_at = EXCLUDED.claimed_at
+					WHERE invoice_reminders.status = 'failed'
+					   OR (invoice_reminders.status = 'pending' AND invoice_reminders.claimed_at < $9)
+					RETURNING id
+		    `
+
+	now := s.clock.Now()
+
+	err := s.db.QueryRow(claimQuery, uuid.New(), c.invoiceID, rule.ID, occurrence, c.clientEmail, subject, domain.ReminderStatusPending, now, now.Add(-reminderClaimTimeout)).Scan(&reminderID)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	sendErr := s.mailer.Send(&mailer.Message{
+		To:      []string{c.clientEmail},
+		ReplyTo: c.replyTo,
+		Subject: subject,
+		Body:    body,
+	})
+
+	if sendErr != nil {
+		_, err = s.db.Exec(`UPDATE invoice_reminders SET status = $1 , error = $2 WHERE id = $3`, domain.ReminderStatusFailed, sendErr.Error(), reminderID)
+
+		if err != nil {
+			return false, err
+		}
+
+		return false, sendErr
+	}
+
+	// email_sent / email_sent_at belong to the invoice email itself , a reminder doesn't touch them
+
+	if _, err = s.db.Exec(`UPDATE invoice_reminders SET status = $1 , sent_at = $2 WHERE id = $3`, domain.ReminderStatusSent, s.clock.Now(), reminderID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// date of the step's occurrence to send today , if there is one
+// the latest occurrence on or before today counts , unless it was missed by more than the grace period
+
+func reminderOccurrence(rule *domain.ReminderRule, dueDate, today time.Time) (time.Time, bool) {
+
+	first := dueDate.AddDate(0, 0, rule.OffsetDays)
+
+	if first.After(today) {
+		return time.Time{}, false
+	}
+
+	occurrence := first
+
+	if rule.RepeatEveryDays != nil && *rule.RepeatEveryDays > 0 {
+		elapsed := int(today.Sub(first).Hours() / 24)
+		occurrence = first.AddDate(0, 0, elapsed / *rule.RepeatEveryDays * *rule.RepeatEveryDays)
+	}
+
+	if occurrence.Before(today.AddDate(0, 0, -reminderGraceDays)) {
+		return time.Time{}, false
+	}
+
+	return occurrence, true
+}
+
+// placeholders available in reminder templates
+
+func reminderReplacer(c *reminderCandidate, occurrence time.Time) *strings.Replacer {
+
+	daysOverdue := int(occurrence.Sub(c.dueDate).Hours() / 24)
+
+	if daysOverdue < 0 {
+		daysOverdue = 0
+	}
+
+	return strings.NewReplacer(
+		"{{client_name}}", c.clientName,
+		"{{invoice_number}}", c.invoiceNumber,
+		"{{amount_due}}", formatAmount(c.currency, c.amountDue),
+		"{{currency}}", c.currency,
+		"{{due_date}}", c.dueDate.Format("January 2, 2006"),
+		"{{days_overdue}}", strconv.Itoa(daysOverdue),
+		"{{business_name}}", c.businessName,
+	)
+}
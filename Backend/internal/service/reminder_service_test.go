@@ -0,0 +1,234 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/google/uuid"
+)
+
+func reminderSteps(offsets ...int) *domain.UpdateReminderRulesRequest {
+
+	req := &domain.UpdateReminderRulesRequest{}
+
+	for _, offset := range offsets {
+		req.Rules = append(req.Rules, &domain.ReminderRuleRequest{OffsetDays: offset})
+	}
+
+	return req
+}
+
+func ruleIDs(rules []*domain.ReminderRule) map[int]uuid.UUID {
+
+	ids := map[int]uuid.UUID{}
+
+	for _, rule := range rules {
+		ids[rule.OffsetDays] = rule.ID
+	}
+
+	return ids
+}
+
+// editing the sequence keeps the ids of the steps that are still in it
+
+func TestReplaceRulesKeepsStepIDs(t *testing.T) {
+
+	db := newTestDB(t)
+	reminders := NewReminderService(db, &sentMail{}, &fakeClock{})
+	userID := insertTestUser(t, db, "UTC")
+
+	before, err := reminders.ReplaceRules(userID, reminderSteps(0, 7))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repeat := 3
+	req := reminderSteps(-3, 0, 7)
+	req.Rules[1].RepeatEveryDays = &repeat
+
+	after, err := reminders.ReplaceRules(userID, req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(after) != 3 {
+		t.Fatalf("%d steps , want 3", len(after))
+	}
+
+	old, now := ruleIDs(before), ruleIDs(after)
+
+	for _, offset := range []int{0, 7} {
+		if old[offset] != now[offset] {
+			t.Errorf("step at %d days got a new id", offset)
+		}
+	}
+
+	if after[1].RepeatEveryDays == nil || *after[1].RepeatEveryDays != 3 || after[1].SortOrder != 1 {
+		t.Errorf("step at 0 days wasn't updated: %+v", after[1])
+	}
+
+	// dropping a step deletes it
+
+	after, err = reminders.ReplaceRules(userID, reminderSteps(7))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(after) != 1 || after[0].ID != old[7] {
+		t.Errorf("steps after dropping two: %+v", after)
+	}
+}
+
+// an invoice due today with a one step sequence , for the sender tests
+
+func reminderFixture(t *testing.T, clock *fakeClock) (*ReminderService, *sentMail, uuid.UUID, uuid.UUID) {
+
+	t.Helper()
+
+	db := newTestDB(t)
+	mail := &sentMail{}
+	reminders := NewReminderService(db, mail, clock)
+
+	userID := insertTestUser(t, db, "UTC")
+	invoiceID := insertTestInvoice(t, db, userID, domain.InvoiceStatusSent, localDate(clock.now, "UTC"))
+
+	if _, err := db.Exec(`UPDATE users SET email_verified = true WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := reminders.ReplaceRules(userID, reminderSteps(0))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return reminders, mail, invoiceID, rules[0].ID
+}
+
+// a claim left pending by an instance that died is sent by a later run once it has timed out
+
+func TestSendDueRetriesStaleClaims(t *testing.T) {
+
+	clock := &fakeClock{now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	reminders, mail, invoiceID, ruleID := reminderFixture(t, clock)
+
+	claimed := clock.now.Add(-time.Minute)
+
+	query := `
+	       INSERT INTO invoice_reminders (invoice_id , rule_id , scheduled_for , sent_to , subject , status , created_at , claimed_at)
+				 VALUES ($1 , $2 , $3 , 'client@example.com' , 'Reminder' , $4 , $5 , $5)
+	`
+
+	if _, err := reminders.db.Exec(query, invoiceID, ruleID, localDate(clock.now, "UTC"), domain.ReminderStatusPending, claimed); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := reminders.SendDue(); err != nil || n != 0 {
+		t.Fatalf("sent %d (%v) while the claim is fresh , want 0", n, err)
+	}
+
+	clock.now = claimed.Add(reminderClaimTimeout + time.Minute)
+
+	if n, err := reminders.SendDue(); err != nil || n != 1 {
+		t.Fatalf("sent %d (%v) after the claim timed out , want 1", n, err)
+	}
+
+	if n, err := reminders.SendDue(); err != nil || n != 0 {
+		t.Fatalf("sent %d (%v) once it was sent , want 0", n, err)
+	}
+
+	if len(*mail) != 1 {
+		t.Errorf("%d emails , want 1", len(*mail))
+	}
+
+	logged, err := reminders.ListInvoiceReminders(reminderOwner(t, reminders, invoiceID), invoiceID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logged) != 1 || logged[0].Status != domain.ReminderStatusSent {
+		t.Errorf("reminder log: %+v", logged)
+	}
+}
+
+// a reminder isn't the invoice email , the invoice's email_sent fields stay as they were
+
+func TestSendDueLeavesInvoiceEmailFields(t *testing.T) {
+
+	clock := &fakeClock{now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	reminders, mail, invoiceID, _ := reminderFixture(t, clock)
+
+	if n, err := reminders.SendDue(); err != nil || n != 1 {
+		t.Fatalf("sent %d (%v) , want 1", n, err)
+	}
+
+	var emailSent bool
+	var emailSentAt *time.Time
+
+	if err := reminders.db.QueryRow(`SELECT email_sent , email_sent_at FROM invoices WHERE id = $1`, invoiceID).Scan(&emailSent, &emailSentAt); err != nil {
+		t.Fatal(err)
+	}
+
+	if emailSent || emailSentAt != nil {
+		t.Errorf("reminder marked the invoice as emailed (%v , %v)", emailSent, emailSentAt)
+	}
+
+	if len(*mail) != 1 {
+		t.Errorf("%d emails , want 1", len(*mail))
+	}
+}
+
+func reminderOwner(t *testing.T, reminders *ReminderService, invoiceID uuid.UUID) uuid.UUID {
+
+	t.Helper()
+
+	var userID uuid.UUID
+
+	if err := reminders.db.QueryRow(`SELECT user_id FROM invoices WHERE id = $1`, invoiceID).Scan(&userID); err != nil {
+		t.Fatal(err)
+	}
+
+	return userID
+}
+
+// replies to a reminder go to the user's reply-to address , or their account email without one
+
+func TestSendDueRepliesToUser(t *testing.T) {
+
+	clock := &fakeClock{now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	reminders, mail, invoiceID, _ := reminderFixture(t, clock)
+	userID := reminderOwner(t, reminders, invoiceID)
+
+	var account string
+
+	if err := reminders.db.QueryRow(`SELECT email FROM users WHERE id = $1`, userID).Scan(&account); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := reminders.SendDue(); err != nil || n != 1 {
+		t.Fatalf("sent %d (%v) , want 1", n, err)
+	}
+
+	if got := (*mail)[0].ReplyTo; got != account {
+		t.Errorf("reply-to without one set = %q , want the account email %q", got, account)
+	}
+
+	if _, err := reminders.db.Exec(`UPDATE users SET reply_to_email = 'billing@example.com' WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	insertTestInvoice(t, reminders.db, userID, domain.InvoiceStatusSent, localDate(clock.now, "UTC"))
+
+	if n, err := reminders.SendDue(); err != nil || n != 1 {
+		t.Fatalf("sent %d (%v) for the second invoice , want 1", n, err)
+	}
+
+	if got := (*mail)[1].ReplyTo; got != "billing@example.com" {
+		t.Errorf("reply-to = %q , want billing@example.com", got)
+	}
+}
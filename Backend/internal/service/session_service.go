@@ -0,0 +1,314 @@
+// session service - logins of a user , listing them and signing them out
+// revoked sessions are kept in memory , so the auth middleware never goes to the database
+
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/google/uuid"
+)
+
+type SessionService struct {
+	db        *database.DB
+	jwtConfig *config.JWTConfig
+
+	// denylist , revoked session id -> when its last access token runs out
+
+	mu      sync.RWMutex
+	revoked map[uuid.UUID]time.Time
+}
+
+// session service function
+
+func NewSessionService(db *database.DB, jwtConfig *config.JWTConfig) *SessionService {
+	return &SessionService{
+		db:        db,
+		jwtConfig: jwtConfig,
+		revoked:   make(map[uuid.UUID]time.Time),
+	}
+}
+
+// DENYLIST
+
+// checked by the auth middleware on every request
+
+func (s *SessionService) IsRevoked(sessionID uuid.UUID) bool {
+
+	s.mu.RLock()
+	until, ok := s.revoked[sessionID]
+	s.mu.RUnlock()
+
+	return ok && time.Now().Before(until)
+}
+
+// adding sessions revoked by this instance right away , the sync picks them up on the others
+
+func (s *SessionService) deny(sessionIDs ...uuid.UUID) {
+
+	until := time.Now().Add(s.jwtConfig.AccessExpiry)
+
+	s.mu.Lock()
+
+	for _, id := range sessionIDs {
+		s.revoked[id] = until
+	}
+
+	s.mu.Unlock()
+}
+
+// reloading the denylist , only sessions revoked within the access token lifetime can still have a live token
+// the rows are merged into the list rather than replacing it , so a deny() made while they were read isn't lost
+
+func (s *SessionService) SyncRevoked() error {
+
+	since := time.Now().Add(-s.jwtConfig.AccessExpiry)
+
+	rows, err := s.db.Query(`SELECT id , revoked_at FROM sessions WHERE revoked_at > $1`, since)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	revoked := make(map[uuid.UUID]time.Time)
+
+	for rows.Next() {
+		var id uuid.UUID
+		var revokedAt time.Time
+
+		if err := rows.Scan(&id, &revokedAt); err != nil {
+			return err
+		}
+
+		revoked[id] = revokedAt.Add(s.jwtConfig.AccessExpiry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.mergeRevoked(revoked)
+
+	return nil
+}
+
+// adding synced sessions to the denylist , an entry is only dropped once its last access token has run out
+
+func (s *SessionService) mergeRevoked(revoked map[uuid.UUID]time.Time) {
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, until := range s.revoked {
+		if !now.Before(until) {
+			delete(s.revoked, id)
+		}
+	}
+
+	for id, until := range revoked {
+		if now.Before(until) && until.After(s.revoked[id]) {
+			s.revoked[id] = until
+		}
+	}
+}
+
+// background sync of the denylist , same ticker pattern as the other jobs
+
+func (s *SessionService) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.SyncRevoked(); err != nil {
+			log.Printf("session sync: %v", err)
+		}
+	}
+}
+
+// SESSIONS
+
+// new session for a login , it lives as long as its refresh tokens
+
+func (s *SessionService) createSession(q execer, userID uuid.UUID, meta *domain.SessionMeta) (uuid.UUID, error) {
+
+	id := uuid.New()
+	now := time.Now()
+
+	device := meta.Device
+
+	if device == "" {
+		device = deviceFromUserAgent(meta.UserAgent)
+	}
+
+	query := `
+	       INSERT INTO sessions (id , user_id , device , ip_address , user_agent , created_at , last_used_at , expires_at)
+				 VALUES ($1 , $2 , NULLIF($3 , '') , NULLIF($4 , '') , NULLIF($5 , '') , $6 , $6 , $7)
+	`
+
+	_, err := q.Exec(query, id, userID, device, meta.IPAddress, meta.UserAgent, now, now.Add(s.jwtConfig.RefreshExpiry))
+
+	return id, err
+}
+
+// a refresh keeps the session alive for another refresh lifetime
+
+func (s *SessionService) touchSession(q execer, sessionID uuid.UUID, meta *domain.SessionMeta) error {
+
+	now := time.Now()
+
+	query := `UPDATE sessions SET last_used_at = $1 , expires_at = $2 , ip_address = COALESCE(NULLIF($3 , '') , ip_address) WHERE id = $4`
+
+	_, err := q.Exec(query, now, now.Add(s.jwtConfig.RefreshExpiry), meta.IPAddress, sessionID)
+
+	return err
+}
+
+// revoking inside a caller's transaction , the caller denies it once committed
+
+func revokeSession(q execer, sessionID uuid.UUID) error {
+
+	_, err := q.Exec(`UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, time.Now(), sessionID)
+
+	return err
+}
+
+// active sessions of the user , most recently used first
+
+func (s *SessionService) ListSessions(userID, currentID uuid.UUID) ([]*domain.Session, error) {
+
+	query := `
+	       SELECT id , device , ip_address , user_agent , created_at , last_used_at , expires_at
+				 FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+				 ORDER BY last_used_at DESC
+	`
+
+	rows, err := s.db.Query(query, userID, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := []*domain.Session{}
+
+	for rows.Next() {
+		session := &domain.Session{}
+
+		if err := rows.Scan(&session.ID, &session.Device, &session.IPAddress, &session.UserAgent, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, err
+		}
+
+		session.Current = session.ID == currentID
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// signing out one session of the user
+
+func (s *SessionService) RevokeSession(userID, sessionID uuid.UUID) error {
+
+	result, err := s.db.Exec(`UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`, time.Now(), sessionID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	s.deny(sessionID)
+
+	return nil
+}
+
+// signing out every session of the user except keep , uuid.Nil keeps none
+// returns how many were signed out
+
+func (s *SessionService) RevokeAllSessions(userID, keep uuid.UUID) (int, error) {
+
+	ids, err := revokeAllSessions(s.db, userID, keep)
+
+	if err != nil {
+		return 0, err
+	}
+
+	s.deny(ids...)
+
+	return len(ids), nil
+}
+
+// the same inside a caller's transaction , the caller denies the returned ids once committed
+
+func revokeAllSessions(q queryer, userID, keep uuid.UUID) ([]uuid.UUID, error) {
+
+	rows, err := q.Query(`UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL RETURNING id`, time.Now(), userID, keep)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// rough device name from the user agent , only for showing in the session list
+
+func deviceFromUserAgent(userAgent string) string {
+
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case ua == "":
+		return ""
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os"):
+		return "Mac"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "Unknown device"
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/google/uuid"
+)
+
+// denies racing the syncs , every session revoked here has to be on the list afterwards
+// run with -race to check the locking as well
+
+func denyDuringSync(t *testing.T, sessions *SessionService, syncOnce func()) {
+
+	t.Helper()
+
+	ids := make([]uuid.UUID, 200)
+
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for _, id := range ids {
+			sessions.deny(id)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for range 20 {
+			syncOnce()
+		}
+	}()
+
+	wg.Wait()
+
+	for _, id := range ids {
+		if !sessions.IsRevoked(id) {
+			t.Fatalf("session %s denied during a sync isn't revoked", id)
+		}
+	}
+}
+
+func TestMergeRevoked(t *testing.T) {
+
+	sessions := NewSessionService(nil, &config.JWTConfig{AccessExpiry: time.Hour})
+
+	synced := map[uuid.UUID]time.Time{uuid.New(): time.Now().Add(time.Minute)}
+
+	denyDuringSync(t, sessions, func() { sessions.mergeRevoked(synced) })
+
+	// synced rows are added , a later expiry wins , expired entries are dropped
+
+	expired, extended := uuid.New(), uuid.New()
+
+	sessions.mu.Lock()
+	sessions.revoked[expired] = time.Now().Add(-time.Second)
+	sessions.revoked[extended] = time.Now().Add(time.Minute)
+	sessions.mu.Unlock()
+
+	later := time.Now().Add(2 * time.Hour)
+
+	sessions.mergeRevoked(map[uuid.UUID]time.Time{extended: later, uuid.New(): time.Now().Add(-time.Minute)})
+
+	sessions.mu.RLock()
+	defer sessions.mu.RUnlock()
+
+	if _, ok := sessions.revoked[expired]; ok {
+		t.Error("an expired entry was kept")
+	}
+
+	if !sessions.revoked[extended].Equal(later) {
+		t.Errorf("expiry = %s , want the synced %s", sessions.revoked[extended], later)
+	}
+
+	if len(sessions.revoked) != 202 {
+		t.Errorf("%d entries , want the 200 denied , the synced one and the extended one", len(sessions.revoked))
+	}
+}
+
+// the same against the database , SyncRevoked reading sessions revoked by another instance
+
+func TestSyncRevokedKeepsDenied(t *testing.T) {
+
+	db := newTestDB(t)
+	sessions := NewSessionService(db, &config.JWTConfig{AccessExpiry: time.Hour})
+
+	userID := insertTestUser(t, db, "UTC")
+	other := uuid.New()
+
+	now := time.Now()
+
+	query := `
+	       INSERT INTO sessions (id , user_id , created_at , last_used_at , expires_at , revoked_at)
+				 VALUES ($1 , $2 , $3 , $3 , $4 , $3)
+	`
+
+	if _, err := db.Exec(query, other, userID, now, now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	denyDuringSync(t, sessions, func() {
+		if err := sessions.SyncRevoked(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !sessions.IsRevoked(other) {
+		t.Error("session revoked by another instance isn't on the list")
+	}
+}
@@ -0,0 +1,400 @@
+// share link service - public invoice links for clients , and what the client can do through them
+
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// purpose the link tokens are signed for , and the default lifetime
+
+const (
+	shareLinkPurpose    = "share-link"
+	defaultShareLinkTTL = 30
+)
+
+type ShareLinkService struct {
+	db             *database.DB
+	invoiceService *InvoiceService
+	userService    *UserService
+	pdfService     *PDFService
+	baseURL        string
+	secret         string
+}
+
+// share link service function , baseURL is the public address of the api
+
+func NewShareLinkService(db *database.DB, invoiceService *InvoiceService, userService *UserService, pdfService *PDFService, baseURL, secret string) *ShareLinkService {
+	return &ShareLinkService{
+		db:             db,
+		invoiceService: invoiceService,
+		userService:    userService,
+		pdfService:     pdfService,
+		baseURL:        baseURL,
+		secret:         secret,
+	}
+}
+
+const shareLinkColumns = `id , invoice_id , created_by , expires_at , revoked_at , access_count , last_accessed_at , created_at`
+
+func (s *ShareLinkService) scanLink(row rowScanner, link *domain.ShareLink) error {
+
+	err := row.Scan(&link.ID, &link.InvoiceID, &link.CreatedBy, &link.ExpiresAt, &link.RevokedAt, &link.AccessCount, &link.LastAccessedAt, &link.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	link.URL = s.baseURL + "/api/v1/public/invoices/" + util.SignID(link.ID, shareLinkPurpose, s.secret)
+
+	return nil
+}
+
+// OWNER SIDE
+
+// creating a link for a sent (or later) invoice
+
+func (s *ShareLinkService) CreateLink(userID, invoiceID uuid.UUID, req *domain.CreateShareLinkRequest) (*domain.ShareLink, error) {
+
+	days := req.ExpiresInDays
+
+	if days == 0 {
+		days = defaultShareLinkTTL
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var status string
+
+	err = tx.QueryRow(`SELECT status FROM invoices WHERE id = $1 AND user_id = $2`, invoiceID, userID).Scan(&status)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status == domain.InvoiceStatusDraft || status == domain.InvoiceStatusCanceled {
+		return nil, domain.ErrInvoiceNotShareable
+	}
+
+	now := time.Now()
+	link := &domain.ShareLink{}
+
+	query := `
+		      INSERT INTO invoice_share_links (id , invoice_id , created_by , expires_at , created_at) VALUES ($1 , $2 , $3 , $4 , $5)
+					RETURNING ` + shareLinkColumns
+
+	err = s.scanLink(tx.QueryRow(query, uuid.New(), invoiceID, userID, now.AddDate(0, 0, days), now), link)
+
+	if err != nil {
+		return nil, err
+	}
+
+	event := &domain.InvoiceEvent{
+		InvoiceID:  invoiceID,
+		Type:       domain.InvoiceEventShareLinkCreated,
+		RelatedID:  &link.ID,
+		OccurredAt: now,
+	}
+
+	if err = recordInvoiceEvent(tx, event); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// links of an invoice , newest first
+
+func (s *ShareLinkService) ListLinks(userID, invoiceID uuid.UUID) ([]*domain.ShareLink, error) {
+
+	query := `
+		     SELECT ` + shareLinkColumns + ` FROM invoice_share_links
+				 WHERE invoice_id = $1 AND EXISTS (SELECT 1 FROM invoices WHERE id = $1 AND user_id = $2)
+				 ORDER BY created_at DESC
+		   `
+
+	rows, err := s.db.Query(query, invoiceID, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	links := []*domain.ShareLink{}
+
+	for rows.Next() {
+		link := &domain.ShareLink{}
+
+		if err := s.scanLink(rows, link); err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// revoking a link , it stops working right away
+
+func (s *ShareLinkService) RevokeLink(userID, invoiceID, linkID uuid.UUID) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	query := `
+		      UPDATE invoice_share_links SET revoked_at = COALESCE(revoked_at , $1)
+					WHERE id = $2 AND invoice_id = $3 AND EXISTS (SELECT 1 FROM invoices WHERE id = $3 AND user_id = $4)
+		    `
+
+	result, err := tx.Exec(query, now, linkID, invoiceID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrShareLinkNotFound
+	}
+
+	event := &domain.InvoiceEvent{
+		InvoiceID:  invoiceID,
+		Type:       domain.InvoiceEventShareLinkRevoked,
+		RelatedID:  &linkID,
+		OccurredAt: now,
+	}
+
+	if err = recordInvoiceEvent(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CLIENT SIDE
+
+// checking a link token , the link must exist and still be valid
+
+func (s *ShareLinkService) resolve(token string) (*domain.ShareLink, error) {
+
+	linkID, err := util.VerifySignedID(token, shareLinkPurpose, s.secret)
+
+	if err != nil {
+		return nil, domain.ErrShareLinkNotFound
+	}
+
+	link := &domain.ShareLink{}
+
+	err = s.scanLink(s.db.QueryRow(`SELECT `+shareLinkColumns+` FROM invoice_share_links WHERE id = $1`, linkID), link)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrShareLinkNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if link.RevokedAt != nil || time.Now().After(link.ExpiresAt) {
+		return nil, domain.ErrShareLinkExpired
+	}
+
+	return link, nil
+}
+
+// logging one use of a link on the invoice timeline
+
+func (s *ShareLinkService) recordAccess(link *domain.ShareLink, eventType, details, ipAddress, userAgent string, note *string) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	if _, err = tx.Exec(`UPDATE invoice_share_links SET access_count = access_count + 1 , last_accessed_at = $1 WHERE id = $2`, now, link.ID); err != nil {
+		return err
+	}
+
+	event := &domain.InvoiceEvent{
+		InvoiceID:  link.InvoiceID,
+		Type:       eventType,
+		RelatedID:  &link.ID,
+		Details:    note,
+		OccurredAt: now,
+	}
+
+	if details != "" {
+		event.Details = &details
+	}
+
+	if ipAddress != "" {
+		event.IPAddress = &ipAddress
+	}
+
+	if userAgent != "" {
+		event.UserAgent = &userAgent
+	}
+
+	if err = recordInvoiceEvent(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loading the invoice behind a link , with the business it's from and the client's latest response
+
+func (s *ShareLinkService) loadPublicInvoice(link *domain.ShareLink) (*domain.PublicInvoice, error) {
+
+	var userID uuid.UUID
+
+	if err := s.db.QueryRow(`SELECT user_id FROM invoices WHERE id = $1`, link.InvoiceID).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrShareLinkNotFound
+		}
+
+		return nil, err
+	}
+
+	invoice, err := s.invoiceService.GetInvoiceByID(userID, link.InvoiceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	public := &domain.PublicInvoice{Invoice: invoice, Business: user}
+
+	response := &domain.InvoiceEvent{}
+
+	query := `
+		     SELECT id , invoice_id , event_type , related_id , ip_address , user_agent , details , occurred_at FROM invoice_events
+				 WHERE invoice_id = $1 AND event_type IN ($2 , $3) ORDER BY occurred_at DESC LIMIT 1
+		   `
+
+	err = s.db.QueryRow(query, link.InvoiceID, domain.InvoiceEventClientAcknowledged, domain.InvoiceEventClientDisputed).Scan(
+		&response.ID, &response.InvoiceID, &response.Type, &response.RelatedID, &response.IPAddress, &response.UserAgent, &response.Details, &response.OccurredAt,
+	)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err == nil {
+		public.Response = response
+	}
+
+	return public, nil
+}
+
+// invoice for the read only page
+
+func (s *ShareLinkService) ViewInvoice(token, ipAddress, userAgent string) (*domain.PublicInvoice, error) {
+
+	link, err := s.resolve(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	public, err := s.loadPublicInvoice(link)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordAccess(link, domain.InvoiceEventShareLinkAccessed, "view", ipAddress, userAgent, nil); err != nil {
+		return nil, err
+	}
+
+	return public, nil
+}
+
+// invoice pdf through the link , returns the pdf and the invoice number for the file name
+
+func (s *ShareLinkService) DownloadPDF(token, ipAddress, userAgent string) ([]byte, string, error) {
+
+	link, err := s.resolve(token)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	public, err := s.loadPublicInvoice(link)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdfBytes, _, err := s.pdfService.GetInvoicePDF(public.Invoice, public.Business)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.recordAccess(link, domain.InvoiceEventShareLinkAccessed, "pdf", ipAddress, userAgent, nil); err != nil {
+		return nil, "", err
+	}
+
+	return pdfBytes, public.Invoice.InvoiceNumber, nil
+}
+
+// client acknowledging (dispute = false) or disputing the invoice , with an optional note
+
+func (s *ShareLinkService) Respond(token string, dispute bool, note, ipAddress, userAgent string) error {
+
+	link, err := s.resolve(token)
+
+	if err != nil {
+		return err
+	}
+
+	eventType := domain.InvoiceEventClientAcknowledged
+
+	if dispute {
+		eventType = domain.InvoiceEventClientDisputed
+	}
+
+	var notePtr *string
+
+	if note != "" {
+		notePtr = &note
+	}
+
+	return s.recordAccess(link, eventType, "", ipAddress, userAgent, notePtr)
+}
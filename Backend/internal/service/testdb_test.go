@@ -0,0 +1,164 @@
+// database for the service tests - a throwaway schema with every up migration applied
+// tests that need it are skipped unless TEST_DATABASE_URL points at a postgres database , like the one in docker-compose.yml
+
+package service
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/google/uuid"
+)
+
+// clock the tests move by hand
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestDB(t *testing.T) *database.DB {
+
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sql.Open("postgres", url)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	// the extension stays in public , the schema only holds the tables
+
+	for _, stmt := range []string{`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`, `CREATE SCHEMA ` + schema} {
+		if _, err := admin.Exec(stmt); err != nil {
+			admin.Close()
+			t.Fatal(err)
+		}
+	}
+
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		admin.Close()
+	})
+
+	db, err := database.New(withSearchPath(url, schema+",public"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../../migrations/*.up.sql")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+
+		migration, err := os.ReadFile(file)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return db
+}
+
+// the search path goes in as a run-time parameter , for both url and key=value connection strings
+
+func withSearchPath(connection, searchPath string) string {
+
+	if !strings.HasPrefix(connection, "postgres://") && !strings.HasPrefix(connection, "postgresql://") {
+		return connection + " search_path=" + searchPath
+	}
+
+	if strings.Contains(connection, "?") {
+		return connection + "&search_path=" + searchPath
+	}
+
+	return connection + "?search_path=" + searchPath
+}
+
+// FIXTURES
+
+func insertTestUser(t *testing.T, db *database.DB, timezone string) uuid.UUID {
+
+	t.Helper()
+
+	var id uuid.UUID
+
+	query := `INSERT INTO users (email , password_hash , full_name , timezone) VALUES ($1 , 'x' , 'Test User' , $2) RETURNING id`
+
+	if err := db.QueryRow(query, uuid.NewString()+"@example.com", timezone).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+// a client and an invoice of the user with the given status and due date
+
+func insertTestInvoice(t *testing.T, db *database.DB, userID uuid.UUID, status string, dueDate time.Time) uuid.UUID {
+
+	t.Helper()
+
+	var clientID, invoiceID uuid.UUID
+
+	err := db.QueryRow(`INSERT INTO clients (user_id , name , email) VALUES ($1 , 'Client' , $2) RETURNING id`, userID, uuid.NewString()+"@example.com").Scan(&clientID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `
+	       INSERT INTO invoices (user_id , client_id , invoice_number , status , issue_date , due_date , total_amount , base_currency)
+				 VALUES ($1 , $2 , $3 , $4 , $5 , $5 , 100 , 'INR') RETURNING id
+	`
+
+	err = db.QueryRow(query, userID, clientID, "INV-"+uuid.NewString()[:8], status, dueDate).Scan(&invoiceID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return invoiceID
+}
+
+func invoiceStatus(t *testing.T, db *database.DB, invoiceID uuid.UUID) string {
+
+	t.Helper()
+
+	var status string
+
+	if err := db.QueryRow(`SELECT status FROM invoices WHERE id = $1`, invoiceID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	return status
+}
@@ -0,0 +1,101 @@
+// tracking service - email open tracking through a signed pixel url
+
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// purpose the pixel tokens are signed for
+
+const emailOpenPurpose = "email-open"
+
+type TrackingService struct {
+	db      *database.DB
+	baseURL string
+	secret  string
+}
+
+// tracking service function , baseURL is the public address of the api
+
+func NewTrackingService(db *database.DB, baseURL, secret string) *TrackingService {
+	return &TrackingService{
+		db:      db,
+		baseURL: baseURL,
+		secret:  secret,
+	}
+}
+
+// pixel url for one sent email , the token is the signed id of its email_sent event
+
+func (s *TrackingService) PixelURL(emailEventID uuid.UUID) string {
+	return s.baseURL + "/api/v1/track/open/" + util.SignID(emailEventID, emailOpenPurpose, s.secret)
+}
+
+// recording an open of a tracked email
+// every open is logged as an event , the invoice keeps the time of the first one
+
+func (s *TrackingService) RecordOpen(token, ipAddress, userAgent string) error {
+
+	emailEventID, err := util.VerifySignedID(token, emailOpenPurpose, s.secret)
+
+	if err != nil {
+		return domain.ErrInvalidToken
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var invoiceID uuid.UUID
+
+	err = tx.QueryRow(`SELECT invoice_id FROM invoice_events WHERE id = $1 AND event_type = $2`, emailEventID, domain.InvoiceEventEmailSent).Scan(&invoiceID)
+
+	// the invoice (and its events) may have been deleted since
+
+	if err == sql.ErrNoRows {
+		return domain.ErrInvoiceNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	event := &domain.InvoiceEvent{
+		InvoiceID:  invoiceID,
+		Type:       domain.InvoiceEventEmailOpened,
+		RelatedID:  &emailEventID,
+		OccurredAt: now,
+	}
+
+	if ipAddress != "" {
+		event.IPAddress = &ipAddress
+	}
+
+	if userAgent != "" {
+		event.UserAgent = &userAgent
+	}
+
+	if err = recordInvoiceEvent(tx, event); err != nil {
+		return err
+	}
+
+	query := `UPDATE invoices SET email_opened = true , email_opened_at = COALESCE(email_opened_at , $1) WHERE id = $2`
+
+	if _, err = tx.Exec(query, now, invoiceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,474 @@
+// two-factor service - authenticator app setup , recovery codes and checking codes at login
+// the clock decides the current totp step and the challenge expiry , so a fixed clock can drive the whole flow
+
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/database"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// how many recovery codes a user gets each time they are made
+
+const recoveryCodeCount = 10
+
+// wrong codes a login challenge takes before the user has to log in again
+
+const maxChallengeAttempts = 5
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+type TwoFactorService struct {
+	db          *database.DB
+	userService *UserService
+	clock       util.Clock
+	config      *config.TwoFactorConfig
+	jwtSecret   string
+}
+
+// two-factor service function
+
+func NewTwoFactorService(db *database.DB, userService *UserService, clock util.Clock, cfg *config.TwoFactorConfig, jwtSecret string) *TwoFactorService {
+	return &TwoFactorService{
+		db:          db,
+		userService: userService,
+		clock:       clock,
+		config:      cfg,
+		jwtSecret:   jwtSecret,
+	}
+}
+
+// SETUP
+
+// starting setup , a new secret is kept but two-factor stays off until a code from the app confirms it
+// starting again replaces a secret that was never confirmed
+
+func (s *TwoFactorService) Setup(userID uuid.UUID) (*domain.TwoFactorSetup, error) {
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := util.SealSecret(secret, s.config.EncryptionKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE users SET totp_secret = $1 , totp_last_step = 0 , updated_at = $2 WHERE id = $3 AND totp_enabled = false`
+
+	result, err := s.db.Exec(query, sealed, s.clock.Now(), userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	return &domain.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(s.config.Issuer, user.Email, secret),
+	}, nil
+}
+
+// confirming setup with a code from the app , turns two-factor on and gives the recovery codes
+
+func (s *TwoFactorService) Confirm(userID uuid.UUID, code string) (*domain.RecoveryCodes, error) {
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	state, err := s.lockState(tx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if state.enabled {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	if state.secret == "" {
+		return nil, domain.ErrTwoFactorNotSetUp
+	}
+
+	// only an app code confirms , there are no recovery codes yet
+
+	if err := s.checkTOTP(tx, userID, state, code); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET totp_enabled = true , updated_at = $1 WHERE id = $2`, s.clock.Now(), userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// turning two-factor off , needs the password and a code (or a recovery code)
+
+func (s *TwoFactorService) Disable(userID uuid.UUID, req *domain.DisableTwoFactorRequest) error {
+
+	user, err := s.userService.GetUserByID(userID)
+
+	if err != nil {
+		return err
+	}
+
+	if !util.CheckPassword(req.Password, user.PasswordHash) {
+		return domain.ErrIncorrectPassword
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := s.verifyCode(tx, userID, req.Code); err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET totp_enabled = false , totp_secret = NULL , totp_last_step = 0 , updated_at = $1 WHERE id = $2`
+
+	if _, err := tx.Exec(query, s.clock.Now(), userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// new set of recovery codes , the old ones stop working
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, code string) (*domain.RecoveryCodes, error) {
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if err := s.verifyCode(tx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// LOGIN
+
+// challenge token for the first login step , backed by a row that counts the wrong codes sent with it
+// the user's expired challenges are cleared out at the same time
+
+func (s *TwoFactorService) challenge(userID uuid.UUID) (string, error) {
+
+	id := uuid.New()
+	now := s.clock.Now()
+
+	if _, err := s.db.Exec(`DELETE FROM two_factor_challenges WHERE user_id = $1 AND expires_at <= $2`, userID, now); err != nil {
+		return "", err
+	}
+
+	query := `
+	       INSERT INTO two_factor_challenges (id , user_id , expires_at , created_at)
+				 VALUES ($1 , $2 , $3 , $4)
+	`
+
+	if _, err := s.db.Exec(query, id, userID, now.Add(s.config.ChallengeExpiry), now); err != nil {
+		return "", err
+	}
+
+	return util.GenerateTwoFactorChallenge(userID, id, s.jwtSecret, now, s.config.ChallengeExpiry)
+}
+
+// second login step , the user of a valid challenge once the code checks out
+// a challenge works once , and stops working after maxChallengeAttempts wrong codes
+
+func (s *TwoFactorService) completeChallenge(token, code string) (uuid.UUID, error) {
+
+	now := s.clock.Now()
+
+	claims, err := util.ValidateTwoFactorChallenge(token, s.jwtSecret, now)
+
+	if err != nil {
+		return uuid.Nil, domain.ErrInvalidToken
+	}
+
+	challengeID, err := uuid.Parse(claims.ID)
+
+	if err != nil {
+		return uuid.Nil, domain.ErrInvalidToken
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	defer tx.Rollback()
+
+	if err := lockChallenge(tx, challengeID, claims.UserID, now); err != nil {
+		return uuid.Nil, err
+	}
+
+	err = s.verifyCode(tx, claims.UserID, code)
+
+	// a wrong code changes nothing else , so only the count is kept
+
+	if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+
+		if _, err := tx.Exec(`UPDATE two_factor_challenges SET failed_attempts = failed_attempts + 1 WHERE id = $1`, challengeID); err != nil {
+			return uuid.Nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return uuid.Nil, err
+		}
+
+		return uuid.Nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE two_factor_challenges SET used_at = $1 WHERE id = $2`, now, challengeID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+// locking a challenge that can still be answered , ErrInvalidToken for a used , expired or worn out one
+
+func lockChallenge(tx *sql.Tx, challengeID, userID uuid.UUID, now time.Time) error {
+
+	var (
+		owner     uuid.UUID
+		attempts  int
+		expiresAt time.Time
+		usedAt    sql.NullTime
+	)
+
+	query := `SELECT user_id , failed_attempts , expires_at , used_at FROM two_factor_challenges WHERE id = $1 FOR UPDATE`
+
+	err := tx.QueryRow(query, challengeID).Scan(&owner, &attempts, &expiresAt, &usedAt)
+
+	if err == sql.ErrNoRows {
+		return domain.ErrInvalidToken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if owner != userID || usedAt.Valid || attempts >= maxChallengeAttempts || !now.Before(expiresAt) {
+		return domain.ErrInvalidToken
+	}
+
+	return nil
+}
+
+// CODES
+
+type twoFactorState struct {
+	secret   string
+	enabled  bool
+	lastStep int64
+}
+
+// locking the user's row , two requests can't use the same code
+
+func (s *TwoFactorService) lockState(tx *sql.Tx, userID uuid.UUID) (*twoFactorState, error) {
+
+	var sealed sql.NullString
+
+	state := &twoFactorState{}
+
+	query := `SELECT totp_secret , totp_enabled , totp_last_step FROM users WHERE id = $1 AND is_active = true FOR UPDATE`
+
+	err := tx.QueryRow(query, userID).Scan(&sealed, &state.enabled, &state.lastStep)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sealed.Valid {
+		if state.secret, err = util.OpenSecret(sealed.String, s.config.EncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// checking a code of a user with two-factor on , 6 digits is an app code and anything else a recovery code
+
+func (s *TwoFactorService) verifyCode(tx *sql.Tx, userID uuid.UUID, code string) error {
+
+	state, err := s.lockState(tx, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if !state.enabled {
+		return domain.ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+
+	if totpCodePattern.MatchString(code) {
+		return s.checkTOTP(tx, userID, state, code)
+	}
+
+	return s.useRecoveryCode(tx, userID, code)
+}
+
+// an app code , it has to be from a later step than the last one used
+
+func (s *TwoFactorService) checkTOTP(tx *sql.Tx, userID uuid.UUID, state *twoFactorState, code string) error {
+
+	step, ok := util.ValidateTOTP(state.secret, strings.TrimSpace(code), s.clock.Now())
+
+	if !ok || step <= state.lastStep {
+		return domain.ErrInvalidTwoFactorCode
+	}
+
+	_, err := tx.Exec(`UPDATE users SET totp_last_step = $1 WHERE id = $2`, step, userID)
+
+	return err
+}
+
+// a recovery code works once
+
+func (s *TwoFactorService) useRecoveryCode(tx *sql.Tx, userID uuid.UUID, code string) error {
+
+	query := `UPDATE recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+
+	result, err := tx.Exec(query, s.clock.Now(), userID, util.HashToken(normalizeRecoveryCode(code)))
+
+	if err != nil {
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// dropping the old codes and making new ones , only their hashes are stored
+
+func (s *TwoFactorService) replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID) (*domain.RecoveryCodes, error) {
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+
+		code, err := newRecoveryCode()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id , code_hash , created_at) VALUES ($1 , $2 , $3)`, userID, util.HashToken(normalizeRecoveryCode(code)), s.clock.Now()); err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	return &domain.RecoveryCodes{Codes: codes}, nil
+}
+
+// recovery codes look like "k7p2m-x9qrt" , 50 random bits
+
+func newRecoveryCode() (string, error) {
+
+	token, err := util.GenerateTOTPSecret()
+
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(token[:10])
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+// codes are compared without case , spaces or dashes
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
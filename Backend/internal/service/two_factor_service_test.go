@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+	"github.com/Suthar345Piyush/invoicego/internal/domain"
+	"github.com/Suthar345Piyush/invoicego/internal/util"
+	"github.com/google/uuid"
+)
+
+// a user with two-factor on , and a function giving the app's code at the clock's time
+// the clock is moved a step on after every code , a code of a step already used is refused
+
+func twoFactorFixture(t *testing.T, clock *fakeClock) (*TwoFactorService, uuid.UUID, func() string) {
+
+	t.Helper()
+
+	db := newTestDB(t)
+	cfg := &config.TwoFactorConfig{Issuer: "InvoiceGo", EncryptionKey: "test key", ChallengeExpiry: 5 * time.Minute}
+	twoFactor := NewTwoFactorService(db, NewUserService(db), clock, cfg, "secret")
+
+	userID := insertTestUser(t, db, "UTC")
+
+	setup, err := twoFactor.Setup(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := func() string {
+
+		t.Helper()
+
+		clock.now = clock.now.Add(30 * time.Second)
+
+		code, err := util.TOTPCode(setup.Secret, clock.now)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return code
+	}
+
+	if _, err := twoFactor.Confirm(userID, code()); err != nil {
+		t.Fatal(err)
+	}
+
+	return twoFactor, userID, code
+}
+
+// wrong codes are counted against the challenge , after maxChallengeAttempts of them even the right code is refused
+
+func TestCompleteChallengeAttemptLimit(t *testing.T) {
+
+	clock := &fakeClock{now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	twoFactor, userID, code := twoFactorFixture(t, clock)
+
+	token, err := twoFactor.challenge(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range maxChallengeAttempts {
+		if _, err := twoFactor.completeChallenge(token, "aaaaa-bbbbb"); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			t.Fatalf("wrong code %d: got %v , want ErrInvalidTwoFactorCode", i+1, err)
+		}
+	}
+
+	if _, err := twoFactor.completeChallenge(token, code()); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("right code after %d wrong ones: got %v , want ErrInvalidToken", maxChallengeAttempts, err)
+	}
+
+	// a new login starts a fresh count
+
+	token, err = twoFactor.challenge(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := twoFactor.completeChallenge(token, code()); err != nil || got != userID {
+		t.Errorf("new challenge: got %s (%v) , want %s", got, err, userID)
+	}
+}
+
+// a challenge answered once can't be answered again , even with another good code
+
+func TestCompleteChallengeUsedOnce(t *testing.T) {
+
+	clock := &fakeClock{now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	twoFactor, userID, code := twoFactorFixture(t, clock)
+
+	token, err := twoFactor.challenge(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := twoFactor.completeChallenge(token, "aaaaa-bbbbb"); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: got %v , want ErrInvalidTwoFactorCode", err)
+	}
+
+	if got, err := twoFactor.completeChallenge(token, code()); err != nil || got != userID {
+		t.Fatalf("right code: got %s (%v) , want %s", got, err, userID)
+	}
+
+	if _, err := twoFactor.completeChallenge(token, code()); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("second use: got %v , want ErrInvalidToken", err)
+	}
+
+	// a challenge can't be answered after it expires either
+
+	token, err = twoFactor.challenge(userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.now = clock.now.Add(5 * time.Minute)
+
+	if _, err := twoFactor.completeChallenge(token, code()); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("after expiry: got %v , want ErrInvalidToken", err)
+	}
+}
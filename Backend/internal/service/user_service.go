@@ -4,6 +4,9 @@ package service
 
 import (
 	"database/sql"
+	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/Suthar345Piyush/invoicego/internal/database"
@@ -63,9 +66,11 @@ func (s *UserService) CreateUser(req *domain.RegisterRequest) (*domain.User, err
 		MonthlyInvoiceCount: 0,
 		MonthlyInvoiceLimit: 5,
 		DefaultCurrency:     "INR",
+		BaseCurrency:        "INR",
 		DefaultPaymentTerms: 30,
 		InvoiceNumberPrefix: "INV",
 		NextInvoiceNumber:   1,
+		Timezone:            "UTC",
 		EmailVerified:       false,
 		IsActive:            true,
 		CreatedAt:           time.Now(),
@@ -78,8 +83,8 @@ func (s *UserService) CreateUser(req *domain.RegisterRequest) (*domain.User, err
 		`
 		       INSERT INTO users (
 						   id , email , password_hash , full_name , subscription_tier , subscription_status , monthly_invoice_count , monthly_invoice_limit , default_currency , default_payment_terms , 
-							 invoice_number_prefix , next_invoice_number , email_verified , is_active , created_at , updated_at
-					 ) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $13 , $14 , $15 , $16)
+							 invoice_number_prefix , next_invoice_number , timezone , email_verified , is_active , created_at , updated_at , base_currency
+					 ) VALUES ($1 , $2 , $3 , $4 , $5 , $6 , $7 , $8 , $9 , $10 , $11 , $12 , $13 , $14 , $15 , $16 , $17 , $18)
 		   `
 
 	// executing query without returning
@@ -87,7 +92,7 @@ func (s *UserService) CreateUser(req *domain.RegisterRequest) (*domain.User, err
 	_, err = s.db.Exec(
 		query,
 		user.ID, user.Email, user.PasswordHash, user.FullName, user.SubscriptionTier, user.SubscriptionStatus, user.MonthlyInvoiceCount, user.MonthlyInvoiceLimit, user.DefaultCurrency,
-		user.DefaultPaymentTerms, user.InvoiceNumberPrefix, user.NextInvoiceNumber, user.EmailVerified, user.IsActive, user.CreatedAt, user.UpdatedAt,
+		user.DefaultPaymentTerms, user.InvoiceNumberPrefix, user.NextInvoiceNumber, user.Timezone, user.EmailVerified, user.IsActive, user.CreatedAt, user.UpdatedAt, user.BaseCurrency,
 	)
 
 	if err != nil {
@@ -98,29 +103,53 @@ func (s *UserService) CreateUser(req *domain.RegisterRequest) (*domain.User, err
 
 }
 
-// after creating user , we getting user by their email and their id
-
-func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
+// columns of the users table in the order scanUser expects
+// the monthly invoice count reads as zero once its month is over
 
-	user := &domain.User{}
+const userColumns = `
+		      id , email , password_hash , full_name , business_name , business_address , business_phone , business_email , tax_id , logo_url , logo_key , brand_primary_color , brand_accent_color , brand_font , reply_to_email , subscription_tier , subscription_status ,
+					CASE WHEN monthly_invoice_period = date_trunc('month' , timezone('UTC' , now()))::date THEN monthly_invoice_count ELSE 0 END , monthly_invoice_limit ,
+					default_currency , base_currency , default_payment_terms , invoice_number_prefix , next_invoice_number , timezone , email_verified , totp_enabled , is_active , created_at , updated_at , last_login_at
+		    `
 
-	query :=
-		`
-			    SELECT id , email , password_hash , full_name , business_name , business_address , business_phone , business_email , tax_id , logo_url , subscription_tier , subscription_status , monthly_invoice_count , monthly_invoice_limit , default_currency , default_payment_terms , invoice_number_prefix  , next_invoice_number , email_verified , is_active , created_at , updated_at
-					last_login_at FROM users WHERE email = $1 AND is_active = true   
-			  `
+// scanning one user row selected with userColumns
 
-		// queryRow at most returns a row after querying the table
-		//when scanning the columns , we have to pass their address
+func scanUser(row rowScanner, user *domain.User) error {
 
 	// using sql.NullTime for nullable timestamp fields
 
 	var LastLoginAt sql.NullTime
 
-	err := s.db.QueryRow(query, email).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.BusinessName, &user.BusinessEmail, &user.BusinessAddress, &user.BusinessPhone, &user.TaxID, &user.LogoURL, &user.SubscriptionStatus, &user.SubscriptionTier, &user.DefaultCurrency, &user.MonthlyInvoiceCount, &user.MonthlyInvoiceLimit, &user.InvoiceNumberPrefix, &user.NextInvoiceNumber, &user.IsActive, &user.UpdatedAt, &user.CreatedAt, &user.EmailVerified, &LastLoginAt,
+	err := row.Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.BusinessName, &user.BusinessAddress, &user.BusinessPhone, &user.BusinessEmail, &user.TaxID, &user.LogoURL, &user.LogoKey, &user.BrandPrimaryColor, &user.BrandAccentColor, &user.BrandFont, &user.ReplyToEmail, &user.SubscriptionTier, &user.SubscriptionStatus, &user.MonthlyInvoiceCount, &user.MonthlyInvoiceLimit,
+		&user.DefaultCurrency, &user.BaseCurrency, &user.DefaultPaymentTerms, &user.InvoiceNumberPrefix, &user.NextInvoiceNumber, &user.Timezone, &user.EmailVerified, &user.TwoFactorEnabled, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &LastLoginAt,
 	)
 
+	if err != nil {
+		return err
+	}
+
+	// converting the sql.NullTime to *time.Time
+
+	if LastLoginAt.Valid {
+		user.LastLoginAt = &LastLoginAt.Time
+	}
+
+	return nil
+}
+
+// after creating user , we getting user by their email and their id
+
+func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
+
+	user := &domain.User{}
+
+	// queryRow at most returns a row after querying the table
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1 AND is_active = true`
+
+	err := scanUser(s.db.QueryRow(query, email), user)
+
 	// if any error not returned from row
 
 	if err == sql.ErrNoRows {
@@ -131,12 +160,6 @@ func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
 		return nil, err
 	}
 
-	// converting the sql.NullTime to *time.Time
-
-	if LastLoginAt.Valid {
-		user.LastLoginAt = &LastLoginAt.Time
-	}
-
 	return user, nil
 
 }
@@ -149,17 +172,9 @@ func (s *UserService) GetUserByID(id uuid.UUID) (*domain.User, error) {
 
 	// writing query
 
-	query := `
-			    SELECT id , email , password_hash , full_name , business_name , business_email , business_phone , business_address , tax_id , logo_url , subscription_tier , subscription_status , monthly_invoice_count , monthly_invoice_limit , default_currency , default_payment_terms , invoice_number_prefix , next_invoice_number , email_verified , is_active , created_at , updated_at , last_login_at FROM users WHERE id = $1 AND is_active = true
-			    `
-
-	// same here for get user by id
-
-	var LastLoginAt sql.NullTime
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND is_active = true`
 
-	err := s.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.BusinessName, &user.BusinessAddress, &user.BusinessEmail, &user.BusinessPhone, &user.TaxID, &user.LogoURL, &user.SubscriptionTier, &user.SubscriptionStatus, &user.MonthlyInvoiceCount, &user.MonthlyInvoiceLimit, &user.DefaultCurrency, &user.DefaultPaymentTerms, &user.InvoiceNumberPrefix, &user.NextInvoiceNumber, &user.EmailVerified, &user.IsActive, &LastLoginAt, &user.UpdatedAt, &user.CreatedAt,
-	)
+	err := scanUser(s.db.QueryRow(query, id), user)
 
 	if err == sql.ErrNoRows {
 		return nil, domain.ErrUserNotFound
@@ -169,13 +184,51 @@ func (s *UserService) GetUserByID(id uuid.UUID) (*domain.User, error) {
 		return nil, err
 	}
 
-	// same , converting sql.NullTime *time.Time
+	return user, nil
 
-	if LastLoginAt.Valid {
-		user.LastLoginAt = &LastLoginAt.Time
+}
+
+// updating the settings of the user , only the fields present in the request are changed
+
+func (s *UserService) UpdateUser(userID uuid.UUID, req *domain.UpdateUserRequest) (*domain.User, error) {
+
+	// timezone must be a valid IANA name like "Asia/Kolkata"
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			return nil, fmt.Errorf("unknown timezone %q: %w", *req.Timezone, domain.ErrInvalidInput)
+		}
 	}
 
-	return user, nil
+	// reply-to must be a plain address , "" removes it
+
+	if req.ReplyToEmail != nil && *req.ReplyToEmail != "" {
+		if addr, err := mail.ParseAddress(*req.ReplyToEmail); err != nil || addr.Address != *req.ReplyToEmail {
+			return nil, fmt.Errorf("invalid reply-to email %q: %w", *req.ReplyToEmail, domain.ErrInvalidInput)
+		}
+	}
+
+	// existing invoices and payments keep the base currency they were converted to , stats convert them on
+
+	var baseCurrency *string
+
+	if req.BaseCurrency != nil {
+		code := strings.ToUpper(*req.BaseCurrency)
+		baseCurrency = &code
+	}
+
+	query := `
+		      UPDATE users SET timezone = COALESCE($1 , timezone) ,
+					reply_to_email = CASE WHEN $2::text IS NULL THEN reply_to_email ELSE NULLIF($2 , '') END ,
+					base_currency = COALESCE($5 , base_currency) ,
+					updated_at = $3 WHERE id = $4 AND is_active = true
+		    `
+
+	if _, err := s.db.Exec(query, req.Timezone, req.ReplyToEmail, time.Now(), userID, baseCurrency); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserByID(userID)
 
 }
 
@@ -0,0 +1,100 @@
+// local backend - files in a directory on the server's disk
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalStorage struct {
+	dir string
+}
+
+// local storage function , creating the directory if it's missing
+
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+
+	if dir == "" {
+		dir = "storage"
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating storage directory: %w", err)
+	}
+
+	return &LocalStorage{dir: dir}, nil
+}
+
+// file path of a key , keys can't leave the storage directory
+
+func (s *LocalStorage) path(key string) (string, error) {
+
+	clean := filepath.Clean("/" + key)
+
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// writing through a temp file and a rename , so readers never see half a file
+
+func (s *LocalStorage) Put(key string, data []byte, contentType string) error {
+
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *LocalStorage) Get(key string) ([]byte, error) {
+
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+// deleting a missing file is not an error
+
+func (s *LocalStorage) Delete(key string) error {
+
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
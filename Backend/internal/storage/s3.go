@@ -0,0 +1,306 @@
+// s3 backend - objects in an s3 bucket , or any service speaking the s3 api (minio , r2 , spaces ...)
+// requests are signed with aws signature version 4
+
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+)
+
+type S3Storage struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+	now       func() time.Time
+}
+
+// s3 storage function , the endpoint defaults to aws for the region
+
+func NewS3Storage(cfg *config.StorageConfig) (*S3Storage, error) {
+
+	if cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+		return nil, fmt.Errorf("s3 storage needs S3_BUCKET , S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := cfg.S3Endpoint
+
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.S3Region + ".amazonaws.com"
+	}
+
+	u, err := url.Parse(endpoint)
+
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", cfg.S3Endpoint)
+	}
+
+	return &S3Storage{
+		endpoint:  u,
+		region:    cfg.S3Region,
+		bucket:    cfg.S3Bucket,
+		accessKey: cfg.S3AccessKey,
+		secretKey: cfg.S3SecretKey,
+		pathStyle: cfg.S3PathStyle,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(key string, data []byte, contentType string) error {
+
+	req, err := s.newRequest(http.MethodPut, key, data)
+
+	if err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, data)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(resp, "put", key)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Get(key string) ([]byte, error) {
+
+	req, err := s.newRequest(http.MethodGet, key, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.responseError(resp, "get", key)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// s3 answers 204 for missing objects as well , so deleting a missing file is not an error here either
+
+func (s *S3Storage) Delete(key string) error {
+
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError(resp, "delete", key)
+	}
+
+	return nil
+}
+
+// request for one object , path style or virtual hosted
+
+func (s *S3Storage) newRequest(method, key string, data []byte) (*http.Request, error) {
+
+	if key == "" || strings.Contains(key, "..") {
+		return nil, fmt.Errorf("invalid storage key %q", key)
+	}
+
+	host := s.endpoint.Host
+	path := "/" + uriEncode(strings.TrimPrefix(key, "/"), false)
+
+	if s.pathStyle {
+		path = "/" + uriEncode(s.bucket, true) + path
+	} else {
+		host = s.bucket + "." + host
+	}
+
+	var body io.Reader
+
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	return http.NewRequest(method, s.endpoint.Scheme+"://"+host+strings.TrimRight(s.endpoint.Path, "/")+path, body)
+}
+
+// signing and sending a request
+
+func (s *S3Storage) do(req *http.Request, payload []byte) (*http.Response, error) {
+
+	s.sign(req, payload)
+
+	resp, err := s.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	return resp, nil
+}
+
+// error with the start of the body s3 sent back , it carries the error code
+
+func (s *S3Storage) responseError(resp *http.Response, op, key string) error {
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	return fmt.Errorf("s3 %s %s: %s: %s", op, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// SIGNATURE V4
+
+// adding the x-amz-* headers and the Authorization header
+// every header set on the request is signed along with the host
+
+func (s *S3Storage) sign(req *http.Request, payload []byte) {
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// query string sorted by name , names and values uri encoded
+
+func canonicalQuery(values url.Values) string {
+
+	pairs := []string{}
+
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// aws uri encoding , everything but unreserved characters is %XX , slashes are kept unless encodeSlash
+
+func uriEncode(value string, encodeSlash bool) string {
+
+	var b strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,34 @@
+// storage - keeping uploaded files (logos , generated pdfs) behind a pluggable backend
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Suthar345Piyush/invoicego/internal/config"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// every backend implements this , keys are slash separated paths like "logos/<user id>/<name>.png"
+
+type Storage interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// creating the storage selected by STORAGE_DRIVER
+
+func New(cfg *config.StorageConfig) (Storage, error) {
+
+	switch cfg.Driver {
+	case "local", "":
+		return NewLocalStorage(cfg.Dir)
+	case "s3":
+		return NewS3Storage(cfg)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,18 @@
+// clock utility code
+// services take a Clock instead of calling time.Now directly , so time based jobs can run against a fixed time
+
+package util
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
+
+// real wall clock
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -4,6 +4,11 @@
 package util
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -11,19 +16,34 @@ import (
 	"github.com/google/uuid"
 )
 
+// token_type keeps the two kinds apart , an access token is never taken as a refresh token and the other way round
+
+const (
+	TokenTypeAccess            = "access"
+	TokenTypeRefresh           = "refresh"
+	TokenTypeEmailVerification = "email_verification"
+	TokenTypeTwoFactor         = "two_factor_challenge"
+)
+
+// sid is the session the token belongs to , access tokens of a signed out session are turned away
+
 type JWTClaims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string
+	TokenType string    `json:"token_type"`
+	SessionID uuid.UUID `json:"sid,omitzero"`
 	jwt.RegisteredClaims
 }
 
 // function to generate the access token
 
-func GenerateAccessToken(userID uuid.UUID, email, secret string, expiry time.Duration) (string, error) {
+func GenerateAccessToken(userID uuid.UUID, email string, sessionID uuid.UUID, secret string, expiry time.Duration) (string, error) {
 
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		TokenType: TokenTypeAccess,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -39,18 +59,96 @@ func GenerateAccessToken(userID uuid.UUID, email, secret string, expiry time.Dur
 }
 
 // function for generating refresh rokens
+// every refresh token gets its own id , so two made in the same second still differ
+// they are signed with a key derived from the secret , not the secret itself
 
 func GenerateRefreshToken(userID uuid.UUID, secret string, expiry time.Duration) (string, error) {
 
-	claims := jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	claims := JWTClaims{
+		UserID:    userID,
+		TokenType: TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token.SignedString(derivedKey(secret, TokenTypeRefresh))
+
+}
+
+// token for the email verification link , it carries the address so it stops working if the email changes
+
+func GenerateEmailVerificationToken(userID uuid.UUID, email, secret string, expiry time.Duration) (string, error) {
+
+	claims := JWTClaims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: TokenTypeEmailVerification,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(derivedKey(secret, TokenTypeEmailVerification))
+
+}
+
+// short lived token from the first login step of a user with two-factor on , exchanged with a code for the real tokens
+// now comes from the caller's clock , challengeID goes in the jti so the caller can count attempts against it
+
+func GenerateTwoFactorChallenge(userID, challengeID uuid.UUID, secret string, now time.Time, expiry time.Duration) (string, error) {
+
+	claims := JWTClaims{
+		UserID:    userID,
+		TokenType: TokenTypeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(derivedKey(secret, TokenTypeTwoFactor))
+
+}
+
+// separate signing key for each kind of token that isn't an access token
+
+func derivedKey(secret, tokenType string) []byte {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(tokenType + "-token"))
+
+	return mac.Sum(nil)
+}
+
+// random opaque token , for one time links that are checked against the database instead of a signature
+
+func RandomToken() (string, error) {
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash of a token for storing it , the token itself is never kept
 
+func HashToken(token string) string {
+
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
 }
 
 // TOKEN VALIDATION FUNCTION
@@ -76,22 +174,40 @@ func GenerateRefreshToken(userID uuid.UUID, secret string, expiry time.Duration)
 
 */
 
-func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
+func ValidateAccessToken(tokenString, secret string) (*JWTClaims, error) {
+	return validateToken(tokenString, []byte(secret), TokenTypeAccess)
+}
+
+func ValidateRefreshToken(tokenString, secret string) (*JWTClaims, error) {
+	return validateToken(tokenString, derivedKey(secret, TokenTypeRefresh), TokenTypeRefresh)
+}
+
+func ValidateEmailVerificationToken(tokenString, secret string) (*JWTClaims, error) {
+	return validateToken(tokenString, derivedKey(secret, TokenTypeEmailVerification), TokenTypeEmailVerification)
+}
+
+// expiry is checked against now , the same clock the challenge was made with
+
+func ValidateTwoFactorChallenge(tokenString, secret string, now time.Time) (*JWTClaims, error) {
+	return validateToken(tokenString, derivedKey(secret, TokenTypeTwoFactor), TokenTypeTwoFactor, jwt.WithTimeFunc(func() time.Time { return now }))
+}
+
+func validateToken(tokenString string, key []byte, tokenType string, options ...jwt.ParserOption) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 
-		return []byte(secret), nil
+		return key, nil
 
-	})
+	}, options...)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.TokenType == tokenType {
 		return claims, nil
 	}
 
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// a challenge token works until its expiry and only for the two-factor step
+
+func TestTwoFactorChallengeExpiry(t *testing.T) {
+
+	userID, challengeID := uuid.New(), uuid.New()
+	issued := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	token, err := GenerateTwoFactorChallenge(userID, challengeID, "secret", issued, 5*time.Minute)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ValidateTwoFactorChallenge(token, "secret", issued.Add(5*time.Minute-time.Second))
+
+	if err != nil || claims.UserID != userID || claims.ID != challengeID.String() {
+		t.Fatalf("before expiry: %+v , %v", claims, err)
+	}
+
+	if _, err := ValidateTwoFactorChallenge(token, "secret", issued.Add(5*time.Minute+time.Second)); err == nil {
+		t.Error("accepted after expiry")
+	}
+
+	if _, err := ValidateTwoFactorChallenge(token, "another secret", issued); err == nil {
+		t.Error("accepted with another secret")
+	}
+}
@@ -0,0 +1,67 @@
+// signed ids for public urls (tracking pixels , share links)
+// the token carries the id and an HMAC of it , so ids can't be guessed or swapped
+
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// signing an id for one purpose , a token made for one purpose is rejected for any other
+
+func SignID(id uuid.UUID, purpose, secret string) string {
+
+	encoding := base64.RawURLEncoding
+
+	return encoding.EncodeToString(id[:]) + "." + encoding.EncodeToString(signatureOf(id, purpose, secret))
+}
+
+// checking a token made by SignID and returning its id
+
+func VerifySignedID(token, purpose, secret string) (uuid.UUID, error) {
+
+	encoding := base64.RawURLEncoding
+
+	idPart, sigPart, found := strings.Cut(token, ".")
+
+	if !found {
+		return uuid.Nil, ErrInvalidSignature
+	}
+
+	raw, err := encoding.DecodeString(idPart)
+
+	if err != nil || len(raw) != 16 {
+		return uuid.Nil, ErrInvalidSignature
+	}
+
+	sig, err := encoding.DecodeString(sigPart)
+
+	if err != nil {
+		return uuid.Nil, ErrInvalidSignature
+	}
+
+	id, _ := uuid.FromBytes(raw)
+
+	if !hmac.Equal(sig, signatureOf(id, purpose, secret)) {
+		return uuid.Nil, ErrInvalidSignature
+	}
+
+	return id, nil
+}
+
+func signatureOf(id uuid.UUID, purpose, secret string) []byte {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + ":"))
+	mac.Write(id[:])
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// the sha1 key of RFC 6238 appendix B , "12345678901234567890" in base32
+
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B vectors , the last six of the eight digits given there
+
+func TestTOTPCodeRFCVectors(t *testing.T) {
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("code at %d = %s , want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+// a code works in its own step and one step either side , not two
+
+func TestValidateTOTPWindow(t *testing.T) {
+
+	issued := time.Unix(1111111109, 0)
+	code, _ := TOTPCode(rfcSecret, issued)
+	step := issued.Unix() / totpStep
+
+	tests := []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{-61 * time.Second, false},
+		{-30 * time.Second, true},
+		{0, true},
+		{20 * time.Second, true},
+		{30 * time.Second, true},
+		{60 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+
+		matched, ok := ValidateTOTP(rfcSecret, code, issued.Add(tt.offset))
+
+		if ok != tt.ok {
+			t.Errorf("code checked %s later: ok = %v , want %v", tt.offset, ok, tt.ok)
+		}
+
+		// the step returned is the code's own , whatever step it was checked in
+
+		if ok && matched != step {
+			t.Errorf("code checked %s later matched step %d , want %d", tt.offset, matched, step)
+		}
+	}
+
+	if _, ok := ValidateTOTP(rfcSecret, "000000", issued); ok && code != "000000" {
+		t.Error("a wrong code was accepted")
+	}
+
+	if _, ok := ValidateTOTP("not base32!", code, issued); ok {
+		t.Error("a code was accepted for a broken secret")
+	}
+}
+
+// the secret is case insensitive , authenticator apps show it either way
+
+func TestTOTPCodeLowercaseSecret(t *testing.T) {
+
+	at := time.Unix(59, 0)
+
+	upper, _ := TOTPCode(rfcSecret, at)
+	lower, err := TOTPCode("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", at)
+
+	if err != nil || lower != upper {
+		t.Errorf("lowercase secret gave %s (%v) , want %s", lower, err, upper)
+	}
+}
+
+func TestSealSecret(t *testing.T) {
+
+	sealed, err := SealSecret(rfcSecret, "the key")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := SealSecret(rfcSecret, "the key")
+
+	if sealed == again {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+
+	if plain, err := OpenSecret(sealed, "the key"); err != nil || plain != rfcSecret {
+		t.Errorf("OpenSecret = %q , %v", plain, err)
+	}
+
+	if _, err := OpenSecret(sealed, "another key"); err == nil {
+		t.Error("opened with the wrong key")
+	}
+
+	if _, err := OpenSecret("bm90IHNlYWxlZA", "the key"); err == nil {
+		t.Error("opened a value that was never sealed")
+	}
+}
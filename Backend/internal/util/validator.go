@@ -2,9 +2,31 @@
 
 package util
 
-import "github.com/go-playground/validator"
+import (
+	"reflect"
 
-var validate = validator.New()
+	"github.com/Suthar345Piyush/invoicego/internal/money"
+	"github.com/go-playground/validator"
+)
+
+var validate = newValidator()
+
+// decimals and rates are validated as numbers , so tags like gte=0 work on them
+
+func newValidator() *validator.Validate {
+
+	v := validator.New()
+
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(money.Decimal).Float64()
+	}, money.Decimal{})
+
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(money.Rate).Float64()
+	}, money.Rate{})
+
+	return v
+}
 
 // function for validating struct
 
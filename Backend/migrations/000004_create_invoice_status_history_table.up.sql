@@ -0,0 +1,11 @@
+CREATE TABLE invoice_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    reason TEXT,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invoice_status_history_invoice_id ON invoice_status_history(invoice_id, changed_at);
@@ -0,0 +1,22 @@
+CREATE TABLE payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    method VARCHAR(50) NOT NULL,
+    reference VARCHAR(255),
+    paid_at DATE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_invoice_id ON payments(invoice_id);
+CREATE INDEX idx_payments_user_id ON payments(user_id);
+
+-- invoices already marked paid get one payment for their full amount , so revenue stays the same
+
+INSERT INTO payments (invoice_id, user_id, amount, currency, method, paid_at, notes)
+SELECT id, user_id, total_amount, currency, 'other', COALESCE(paid_date, updated_at::date), 'recorded when the payments ledger was introduced'
+FROM invoices
+WHERE status = 'paid' AND total_amount > 0;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS recurring_invoice_runs CASCADE;
+DROP TABLE IF EXISTS recurring_schedules CASCADE;
\ No newline at end of file
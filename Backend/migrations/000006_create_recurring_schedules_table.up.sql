@@ -0,0 +1,30 @@
+CREATE TABLE recurring_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    template_invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    frequency VARCHAR(20) NOT NULL,
+    rule VARCHAR(100),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_run_date DATE,
+    auto_send BOOLEAN DEFAULT false,
+    is_active BOOLEAN DEFAULT true,
+    last_run_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- one row per generated period , the unique key keeps generation idempotent across restarts
+
+CREATE TABLE recurring_invoice_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    schedule_id UUID NOT NULL REFERENCES recurring_schedules(id) ON DELETE CASCADE,
+    period_date DATE NOT NULL,
+    invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (schedule_id, period_date)
+);
+
+CREATE INDEX idx_recurring_schedules_user_id ON recurring_schedules(user_id);
+CREATE INDEX idx_recurring_schedules_next_run_date ON recurring_schedules(next_run_date) WHERE is_active = true;
@@ -0,0 +1,5 @@
+ALTER TABLE clients DROP COLUMN IF EXISTS reminders_paused;
+ALTER TABLE invoices DROP COLUMN IF EXISTS reminders_paused;
+DROP TABLE IF EXISTS invoice_reminders CASCADE;
+DROP TABLE IF EXISTS reminder_rules CASCADE;
+DROP TABLE IF EXISTS reminder_templates CASCADE;
\ No newline at end of file
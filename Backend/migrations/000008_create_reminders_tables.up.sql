@@ -0,0 +1,44 @@
+CREATE TABLE reminder_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- one step of the user's reminder sequence , offset is in days from the due date (negative = before)
+
+CREATE TABLE reminder_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    template_id UUID REFERENCES reminder_templates(id) ON DELETE SET NULL,
+    offset_days INT NOT NULL,
+    repeat_every_days INT,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- reminder log , the unique key makes each occurrence go out once
+
+CREATE TABLE invoice_reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES reminder_rules(id) ON DELETE SET NULL,
+    scheduled_for DATE NOT NULL,
+    sent_to VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (invoice_id, rule_id, scheduled_for)
+);
+
+ALTER TABLE invoices ADD COLUMN reminders_paused BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE clients ADD COLUMN reminders_paused BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_reminder_templates_user_id ON reminder_templates(user_id);
+CREATE INDEX idx_reminder_rules_user_id ON reminder_rules(user_id, sort_order);
+CREATE INDEX idx_invoice_reminders_invoice_id ON invoice_reminders(invoice_id);
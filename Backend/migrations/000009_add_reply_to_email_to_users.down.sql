@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS reply_to_email;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN reply_to_email VARCHAR(255);
\ No newline at end of file
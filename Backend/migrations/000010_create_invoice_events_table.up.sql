@@ -0,0 +1,15 @@
+-- invoice activity that isn't a status change (emails , opens , share links) , shown on the invoice timeline
+
+CREATE TABLE invoice_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    event_type VARCHAR(40) NOT NULL,
+    related_id UUID,
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    details TEXT,
+    occurred_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invoice_events_invoice_id ON invoice_events(invoice_id, occurred_at);
+CREATE INDEX idx_invoice_events_related_id ON invoice_events(related_id);
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- public links to an invoice for clients without an account , the url token is the signed link id
+
+CREATE TABLE invoice_share_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    access_count INT NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invoice_share_links_invoice_id ON invoice_share_links(invoice_id);
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS brand_font;
+ALTER TABLE users DROP COLUMN IF EXISTS brand_accent_color;
+ALTER TABLE users DROP COLUMN IF EXISTS brand_primary_color;
+ALTER TABLE users DROP COLUMN IF EXISTS logo_key;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- logo file in storage and brand settings used on generated pdfs
+
+ALTER TABLE users ADD COLUMN logo_key VARCHAR(255);
+ALTER TABLE users ADD COLUMN brand_primary_color VARCHAR(7);
+ALTER TABLE users ADD COLUMN brand_accent_color VARCHAR(7);
+ALTER TABLE users ADD COLUMN brand_font VARCHAR(20);
\ No newline at end of file
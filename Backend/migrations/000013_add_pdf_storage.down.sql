@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invoice_pdf_archives;
+ALTER TABLE invoices DROP COLUMN IF EXISTS pdf_key;
\ No newline at end of file
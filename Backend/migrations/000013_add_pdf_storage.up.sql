@@ -0,0 +1,15 @@
+-- key of the cached pdf in storage , and the archived copy of every pdf emailed to a client
+
+ALTER TABLE invoices ADD COLUMN pdf_key VARCHAR(255);
+
+CREATE TABLE invoice_pdf_archives (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    email_event_id UUID,
+    storage_key VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invoice_pdf_archives_invoice_id ON invoice_pdf_archives(invoice_id, created_at);
\ No newline at end of file
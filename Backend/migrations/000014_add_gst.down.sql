@@ -0,0 +1,11 @@
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS hsn_sac;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS igst_amount;
+ALTER TABLE invoices DROP COLUMN IF EXISTS sgst_amount;
+ALTER TABLE invoices DROP COLUMN IF EXISTS cgst_amount;
+ALTER TABLE invoices DROP COLUMN IF EXISTS buyer_gstin;
+ALTER TABLE invoices DROP COLUMN IF EXISTS seller_gstin;
+ALTER TABLE invoices DROP COLUMN IF EXISTS place_of_supply;
+ALTER TABLE invoices DROP COLUMN IF EXISTS tax_scheme;
\ No newline at end of file
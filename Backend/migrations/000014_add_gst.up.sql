@@ -0,0 +1,13 @@
+-- indian gst invoices , the tax scheme with place of supply and both gstins on the invoice , hsn / sac code and gst rate on every line
+
+ALTER TABLE invoices ADD COLUMN tax_scheme VARCHAR(10) NOT NULL DEFAULT 'flat';
+ALTER TABLE invoices ADD COLUMN place_of_supply VARCHAR(2);
+ALTER TABLE invoices ADD COLUMN seller_gstin VARCHAR(15);
+ALTER TABLE invoices ADD COLUMN buyer_gstin VARCHAR(15);
+ALTER TABLE invoices ADD COLUMN cgst_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN sgst_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN igst_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE invoice_items ADD COLUMN hsn_sac VARCHAR(8);
+ALTER TABLE invoice_items ADD COLUMN tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoice_items ADD COLUMN tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
\ No newline at end of file
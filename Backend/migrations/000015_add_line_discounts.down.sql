@@ -0,0 +1,3 @@
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS discount_value;
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS discount_type;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- line discounts , and every line of an existing flat invoice taxed at the invoice's rate
+
+ALTER TABLE invoice_items ADD COLUMN discount_type VARCHAR(10);
+ALTER TABLE invoice_items ADD COLUMN discount_value DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoice_items ADD COLUMN discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+UPDATE invoice_items SET tax_rate = invoices.tax_rate , tax_amount = ROUND(invoice_items.amount * invoices.tax_rate / 100 , 2)
+FROM invoices WHERE invoices.id = invoice_items.invoice_id AND invoices.tax_scheme = 'flat';
\ No newline at end of file
@@ -0,0 +1,21 @@
+ALTER TABLE payments ALTER COLUMN amount TYPE DECIMAL(15,2);
+
+ALTER TABLE invoice_items ALTER COLUMN tax_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoice_items ALTER COLUMN tax_rate TYPE DECIMAL(5,2);
+ALTER TABLE invoice_items ALTER COLUMN discount_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoice_items ALTER COLUMN discount_value TYPE DECIMAL(15,2);
+ALTER TABLE invoice_items ALTER COLUMN amount TYPE DECIMAL(15,2);
+ALTER TABLE invoice_items ALTER COLUMN unit_price TYPE DECIMAL(15,2);
+ALTER TABLE invoice_items ALTER COLUMN quantity TYPE DECIMAL(10,2);
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS tax_rounding;
+ALTER TABLE invoices DROP COLUMN IF EXISTS rounding_mode;
+
+ALTER TABLE invoices ALTER COLUMN igst_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoices ALTER COLUMN sgst_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoices ALTER COLUMN cgst_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoices ALTER COLUMN total_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoices ALTER COLUMN discount_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoices ALTER COLUMN tax_amount TYPE DECIMAL(15,2);
+ALTER TABLE invoices ALTER COLUMN tax_rate TYPE DECIMAL(5,2);
+ALTER TABLE invoices ALTER COLUMN subtotal TYPE DECIMAL(15,2);
\ No newline at end of file
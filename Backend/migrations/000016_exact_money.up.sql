@@ -0,0 +1,24 @@
+-- exact money , amounts keep 3 decimal places for currencies like KWD , prices / quantities / rates keep 4
+-- and each invoice records how it's rounded
+
+ALTER TABLE invoices ALTER COLUMN subtotal TYPE DECIMAL(18,3);
+ALTER TABLE invoices ALTER COLUMN tax_rate TYPE DECIMAL(8,4);
+ALTER TABLE invoices ALTER COLUMN tax_amount TYPE DECIMAL(18,3);
+ALTER TABLE invoices ALTER COLUMN discount_amount TYPE DECIMAL(18,3);
+ALTER TABLE invoices ALTER COLUMN total_amount TYPE DECIMAL(18,3);
+ALTER TABLE invoices ALTER COLUMN cgst_amount TYPE DECIMAL(18,3);
+ALTER TABLE invoices ALTER COLUMN sgst_amount TYPE DECIMAL(18,3);
+ALTER TABLE invoices ALTER COLUMN igst_amount TYPE DECIMAL(18,3);
+
+ALTER TABLE invoices ADD COLUMN rounding_mode VARCHAR(10) NOT NULL DEFAULT 'half_up';
+ALTER TABLE invoices ADD COLUMN tax_rounding VARCHAR(10) NOT NULL DEFAULT 'line';
+
+ALTER TABLE invoice_items ALTER COLUMN quantity TYPE DECIMAL(14,4);
+ALTER TABLE invoice_items ALTER COLUMN unit_price TYPE DECIMAL(19,4);
+ALTER TABLE invoice_items ALTER COLUMN amount TYPE DECIMAL(18,3);
+ALTER TABLE invoice_items ALTER COLUMN discount_value TYPE DECIMAL(19,4);
+ALTER TABLE invoice_items ALTER COLUMN discount_amount TYPE DECIMAL(18,3);
+ALTER TABLE invoice_items ALTER COLUMN tax_rate TYPE DECIMAL(8,4);
+ALTER TABLE invoice_items ALTER COLUMN tax_amount TYPE DECIMAL(18,3);
+
+ALTER TABLE payments ALTER COLUMN amount TYPE DECIMAL(18,3);
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE payments DROP COLUMN IF EXISTS base_currency;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE invoices DROP COLUMN IF EXISTS base_currency;
+
+ALTER TABLE users DROP COLUMN IF EXISTS base_currency;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- reporting in one base currency per user , every invoice and payment keeps the rate it was converted at
+-- existing invoices and payments are based in their own currency (rate 1) , as their rates at the time aren't known
+
+ALTER TABLE users ADD COLUMN base_currency VARCHAR(3);
+UPDATE users SET base_currency = COALESCE(default_currency , 'INR');
+ALTER TABLE users ALTER COLUMN base_currency SET NOT NULL;
+ALTER TABLE users ALTER COLUMN base_currency SET DEFAULT 'INR';
+
+ALTER TABLE invoices ADD COLUMN base_currency VARCHAR(3);
+ALTER TABLE invoices ADD COLUMN exchange_rate DECIMAL(20,10) NOT NULL DEFAULT 1;
+UPDATE invoices SET base_currency = currency;
+ALTER TABLE invoices ALTER COLUMN base_currency SET NOT NULL;
+
+ALTER TABLE payments ADD COLUMN base_currency VARCHAR(3);
+ALTER TABLE payments ADD COLUMN exchange_rate DECIMAL(20,10) NOT NULL DEFAULT 1;
+UPDATE payments SET base_currency = currency;
+ALTER TABLE payments ALTER COLUMN base_currency SET NOT NULL;
+
+-- rates users enter themselves , one per pair and day
+
+CREATE TABLE exchange_rates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate DECIMAL(20,10) NOT NULL CHECK (rate > 0),
+    effective_date DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id , from_currency , to_currency , effective_date)
+);
\ No newline at end of file
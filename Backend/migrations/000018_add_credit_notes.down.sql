@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS credit_note_items;
+DROP TABLE IF EXISTS credit_notes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS next_credit_note_number;
+ALTER TABLE users DROP COLUMN IF EXISTS credit_note_prefix;
\ No newline at end of file
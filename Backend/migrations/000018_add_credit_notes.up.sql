@@ -0,0 +1,47 @@
+-- credit notes , corrections of sent or paid invoices numbered in their own sequence
+-- every line reverses all or part of a line of the source invoice , the total comes off the invoice's balance
+
+ALTER TABLE users ADD COLUMN credit_note_prefix VARCHAR(10) NOT NULL DEFAULT 'CN';
+ALTER TABLE users ADD COLUMN next_credit_note_number INT NOT NULL DEFAULT 1;
+
+CREATE TABLE credit_notes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    client_id UUID NOT NULL REFERENCES clients(id) ON DELETE RESTRICT,
+    credit_note_number VARCHAR(50) NOT NULL,
+    issue_date DATE NOT NULL,
+    reason TEXT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    base_currency VARCHAR(3) NOT NULL,
+    exchange_rate DECIMAL(20,10) NOT NULL DEFAULT 1,
+    subtotal DECIMAL(18,3) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    cgst_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    sgst_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    igst_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(18,3) NOT NULL CHECK (total_amount > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id , credit_note_number)
+);
+
+CREATE TABLE credit_note_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    credit_note_id UUID NOT NULL REFERENCES credit_notes(id) ON DELETE CASCADE,
+    invoice_item_id UUID NOT NULL REFERENCES invoice_items(id) ON DELETE RESTRICT,
+    description TEXT NOT NULL,
+    hsn_sac VARCHAR(8),
+    quantity DECIMAL(14,4) NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    amount DECIMAL(18,3) NOT NULL,
+    tax_rate DECIMAL(8,4) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_notes_user_id ON credit_notes(user_id);
+CREATE INDEX idx_credit_notes_invoice_id ON credit_notes(invoice_id);
+CREATE INDEX idx_credit_note_items_credit_note_id ON credit_note_items(credit_note_id);
+CREATE INDEX idx_credit_note_items_invoice_item_id ON credit_note_items(invoice_item_id);
\ No newline at end of file
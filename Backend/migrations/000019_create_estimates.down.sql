@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS estimate_items;
+DROP TABLE IF EXISTS estimates;
+
+ALTER TABLE users DROP COLUMN IF EXISTS next_estimate_number;
+ALTER TABLE users DROP COLUMN IF EXISTS estimate_prefix;
\ No newline at end of file
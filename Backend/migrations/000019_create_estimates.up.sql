@@ -0,0 +1,65 @@
+-- estimates (quotes) sent before the work starts , numbered in their own sequence
+-- lines are stored like invoice lines , an accepted estimate is turned into an invoice and keeps the link to it
+
+ALTER TABLE users ADD COLUMN estimate_prefix VARCHAR(10) NOT NULL DEFAULT 'EST';
+ALTER TABLE users ADD COLUMN next_estimate_number INT NOT NULL DEFAULT 1;
+
+CREATE TABLE estimates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients(id) ON DELETE RESTRICT,
+    estimate_number VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    issue_date DATE NOT NULL,
+    expiry_date DATE NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    subtotal DECIMAL(18,3) NOT NULL DEFAULT 0,
+    tax_rate DECIMAL(8,4) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    tax_scheme VARCHAR(10) NOT NULL DEFAULT 'flat',
+    rounding_mode VARCHAR(10) NOT NULL DEFAULT 'half_up',
+    tax_rounding VARCHAR(10) NOT NULL DEFAULT 'line',
+    place_of_supply VARCHAR(2),
+    seller_gstin VARCHAR(15),
+    buyer_gstin VARCHAR(15),
+    cgst_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    sgst_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    igst_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    template_id VARCHAR(50) NOT NULL DEFAULT 'default',
+    notes TEXT,
+    terms_and_conditions TEXT,
+    sent_at TIMESTAMP,
+    responded_at TIMESTAMP,
+    client_note TEXT,
+    invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL,
+    converted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id , estimate_number)
+);
+
+CREATE TABLE estimate_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    estimate_id UUID NOT NULL REFERENCES estimates(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    quantity DECIMAL(14,4) NOT NULL DEFAULT 1,
+    unit_price DECIMAL(19,4) NOT NULL,
+    amount DECIMAL(18,3) NOT NULL,
+    discount_type VARCHAR(10),
+    discount_value DECIMAL(19,4) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    hsn_sac VARCHAR(8),
+    tax_rate DECIMAL(8,4) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(18,3) NOT NULL DEFAULT 0,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_estimates_user_id ON estimates(user_id);
+CREATE INDEX idx_estimates_client_id ON estimates(client_id);
+CREATE INDEX idx_estimates_status_expiry ON estimates(status , expiry_date);
+CREATE INDEX idx_estimates_invoice_id ON estimates(invoice_id);
+CREATE INDEX idx_estimate_items_estimate_id ON estimate_items(estimate_id , sort_order);
\ No newline at end of file
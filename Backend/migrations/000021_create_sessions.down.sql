@@ -0,0 +1,7 @@
+ALTER TABLE refresh_tokens ADD COLUMN revoked_at TIMESTAMP;
+
+UPDATE refresh_tokens SET revoked_at = sessions.revoked_at FROM sessions WHERE sessions.id = refresh_tokens.family_id;
+
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS fk_refresh_tokens_session;
+
+DROP TABLE IF EXISTS sessions;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- sessions , one per login with the device it came from
+-- the refresh token family of a login is its session , revoking the session ends the family and its access tokens
+
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device VARCHAR(100),
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_sessions_revoked_at ON sessions(revoked_at);
+
+-- token families issued before this become sessions , revocation now lives on the session
+
+INSERT INTO sessions (id , user_id , created_at , last_used_at , expires_at , revoked_at)
+SELECT family_id , user_id , MIN(created_at) , COALESCE(MAX(created_at) , CURRENT_TIMESTAMP) , MAX(expires_at) , MAX(revoked_at)
+FROM refresh_tokens GROUP BY family_id , user_id;
+
+ALTER TABLE refresh_tokens ADD CONSTRAINT fk_refresh_tokens_session FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
+ALTER TABLE refresh_tokens DROP COLUMN revoked_at;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- two-factor login with an authenticator app (TOTP)
+-- totp_secret is encrypted , it's set when setup starts and totp_enabled turns on once a code confirms it
+-- totp_last_step is the time step of the last accepted code , so the same code can't be used twice
+
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- one time recovery codes for when the app is lost , stored as sha256 hashes
+
+CREATE TABLE recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS monthly_invoice_period;
\ No newline at end of file
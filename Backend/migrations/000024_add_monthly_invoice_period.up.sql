@@ -0,0 +1,5 @@
+-- month the free tier monthly_invoice_count belongs to , the count starts again from zero in a new month
+
+ALTER TABLE users ADD COLUMN monthly_invoice_period DATE;
+
+UPDATE users SET monthly_invoice_period = date_trunc('month' , timezone('UTC' , now()))::date;
\ No newline at end of file
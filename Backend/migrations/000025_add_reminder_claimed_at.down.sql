@@ -0,0 +1 @@
+ALTER TABLE invoice_reminders DROP COLUMN IF EXISTS claimed_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- when the current attempt at a reminder was claimed , a claim still pending long after it is taken to be lost and claimed again
+
+ALTER TABLE invoice_reminders ADD COLUMN claimed_at TIMESTAMP;
+
+UPDATE invoice_reminders SET claimed_at = created_at;
\ No newline at end of file
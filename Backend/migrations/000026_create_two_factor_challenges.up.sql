@@ -0,0 +1,13 @@
+-- login challenges of users with two-factor on , the id is the jti of the challenge token
+-- failed_attempts counts wrong codes , a challenge with too many of them or one that was used (used_at) is dead
+
+CREATE TABLE two_factor_challenges (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_two_factor_challenges_user_id ON two_factor_challenges(user_id);
\ No newline at end of file